@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// config is the JSON file given with -config, describing a crawl declaratively
+type config struct {
+	Scope           scopeConfig                `json:"scope"`
+	ExtractionRules []extractionRuleConfig     `json:"extraction_rules"`
+	Sections        []sectionConfig            `json:"sections"`
+	RateLimits      map[string]rateLimitConfig `json:"rate_limits"` // by host
+}
+
+type scopeConfig struct {
+	Policy  string   `json:"policy"`  // host, domain or path, as for the SCOPE env var
+	Domains []string `json:"domains"` // as for SCOPE_DOMAINS, instead of policy
+	Exclude []string `json:"exclude"` // as for SCOPE_EXCLUDE
+}
+
+type extractionRuleConfig struct {
+	Field    string `json:"field"`
+	Selector string `json:"selector"`
+	Attr     string `json:"attr"`
+	Regexp   string `json:"regexp"` // instead of selector
+}
+
+// rateLimitConfig overrides POLITENESS_DELAY and POLITENESS_MAX_CONCURRENT for a host, see crawler.HostLimits
+type rateLimitConfig struct {
+	QPS         float64 `json:"qps"`
+	Concurrency int     `json:"concurrency"`
+	Delay       string  `json:"delay"` // a duration, e.g. "500ms"
+}
+
+// sectionConfig overrides settings for the pages whose path and query match a pattern
+type sectionConfig struct {
+	Pattern         string                 `json:"pattern"`
+	MaxPages        int                    `json:"max_pages"`
+	ExtractionRules []extractionRuleConfig `json:"extraction_rules"`
+}
+
+// loadConfig reads and validates the config file at path
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cfg config
+	if err := dec.Decode(&cfg); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line, col := position(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %s", path, line, col, err)
+		}
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			line, col := position(data, typeErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %s should be %s, not %s", path, line, col, typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	if problems := cfg.validate(); len(problems) > 0 {
+		return nil, fmt.Errorf("%s is invalid:\n\t%s", path, strings.Join(problems, "\n\t"))
+	}
+	return &cfg, nil
+}
+
+// position returns the line and column of a byte offset in data, both counting from 1
+func position(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// validate returns a description of each problem with the config, naming the setting at fault
+func (c *config) validate() []string {
+	problems := []string{}
+
+	switch c.Scope.Policy {
+	case "", "host", "domain", "path":
+	default:
+		problems = append(problems, fmt.Sprintf("scope.policy: unknown policy %q, expected host, domain or path", c.Scope.Policy))
+	}
+	if c.Scope.Policy != "" && len(c.Scope.Domains) > 0 {
+		problems = append(problems, "scope: set either policy or domains, not both")
+	}
+	if len(c.Scope.Exclude) > 0 && len(c.Scope.Domains) == 0 {
+		problems = append(problems, "scope.exclude: only applies to domains, which isn't set")
+	}
+
+	for i, rule := range c.ExtractionRules {
+		if err := rule.toRule("").Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("extraction_rules[%d]: %s", i, err))
+		}
+	}
+
+	for i, section := range c.Sections {
+		if section.Pattern == "" {
+			problems = append(problems, fmt.Sprintf("sections[%d].pattern: required", i))
+		}
+		if section.MaxPages < 0 {
+			problems = append(problems, fmt.Sprintf("sections[%d].max_pages: must not be negative", i))
+		}
+		for j, rule := range section.ExtractionRules {
+			if err := rule.toRule(section.Pattern).Validate(); err != nil {
+				problems = append(problems, fmt.Sprintf("sections[%d].extraction_rules[%d]: %s", i, j, err))
+			}
+		}
+	}
+
+	hosts := make([]string, 0, len(c.RateLimits))
+	for host := range c.RateLimits {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		limit := c.RateLimits[host]
+		if limit.QPS < 0 {
+			problems = append(problems, fmt.Sprintf("rate_limits[%q].qps: must not be negative", host))
+		}
+		if limit.Concurrency < 0 {
+			problems = append(problems, fmt.Sprintf("rate_limits[%q].concurrency: must not be negative", host))
+		}
+		if limit.Delay != "" {
+			if d, err := time.ParseDuration(limit.Delay); err != nil || d < 0 {
+				problems = append(problems, fmt.Sprintf("rate_limits[%q].delay: %q isn't a duration, e.g. 500ms", host, limit.Delay))
+			}
+		}
+	}
+
+	return problems
+}
+
+func (r extractionRuleConfig) toRule(pattern string) crawler.ExtractionRule {
+	return crawler.ExtractionRule{Field: r.Field, Selector: r.Selector, Attr: r.Attr, Regexp: r.Regexp, Pattern: pattern}
+}
+
+// hostLimits returns the politeness limits of each host with rate limits, which are combined with those of the env vars
+// rather than being options, so that the env vars don't replace them. The config must be valid.
+func (c *config) hostLimits() map[string]crawler.HostLimits {
+	if len(c.RateLimits) == 0 {
+		return nil
+	}
+	limits := make(map[string]crawler.HostLimits, len(c.RateLimits))
+	for host, limit := range c.RateLimits {
+		delay, _ := time.ParseDuration(limit.Delay)
+		limits[host] = crawler.HostLimits{QPS: limit.QPS, Delay: delay, MaxConcurrent: limit.Concurrency}
+	}
+	return limits
+}
+
+// options returns the crawler options the config describes
+func (c *config) options() []crawler.Option {
+	opts := []crawler.Option{}
+
+	switch {
+	case len(c.Scope.Domains) > 0:
+		opts = append(opts, crawler.WithScopePolicy(crawler.Domains{Include: c.Scope.Domains, Exclude: c.Scope.Exclude}))
+	case c.Scope.Policy == "domain":
+		opts = append(opts, crawler.WithScopePolicy(crawler.SameRegistrableDomain{}))
+	case c.Scope.Policy == "path":
+		opts = append(opts, crawler.WithScopePolicy(crawler.PathPrefix{}))
+	}
+
+	for _, rule := range c.ExtractionRules {
+		opts = append(opts, crawler.WithExtractionRules(rule.toRule("")))
+	}
+
+	for _, section := range c.Sections {
+		if section.MaxPages > 0 {
+			opts = append(opts, crawler.WithPatternBudget(section.Pattern, section.MaxPages))
+		}
+		for _, rule := range section.ExtractionRules {
+			opts = append(opts, crawler.WithExtractionRules(rule.toRule(section.Pattern)))
+		}
+	}
+
+	return opts
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "crawl.json")
+	require.NoError(t, os.WriteFile(path, []byte(contents), os.ModePerm))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		path := writeConfig(t, `{
+  "scope": {"domains": ["monzo.com"], "exclude": ["legacy.monzo.com"]},
+  "extraction_rules": [{"field": "heading", "selector": "h1"}, {"field": "sku", "regexp": "SKU-[0-9]{6}"}],
+  "sections": [{"pattern": "/blog/*", "max_pages": 5, "extraction_rules": [{"field": "author", "selector": ".author"}]}],
+  "rate_limits": {"legacy.monzo.com": {"qps": 2, "concurrency": 1, "delay": "1s"}}
+}`)
+
+		cfg, err := loadConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{"monzo.com"}, cfg.Scope.Domains)
+		require.Len(t, cfg.options(), 5)
+		require.Equal(t, map[string]crawler.HostLimits{
+			"legacy.monzo.com": {QPS: 2, Delay: time.Second, MaxConcurrent: 1},
+		}, cfg.hostLimits())
+	})
+
+	t.Run("syntax error position", func(t *testing.T) {
+		path := writeConfig(t, "{\n  \"scope\": {\"policy\": \"host\",}\n}")
+
+		_, err := loadConfig(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), path+":2:31:")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		path := writeConfig(t, `{"extraction_rule": []}`)
+
+		_, err := loadConfig(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown field "extraction_rule"`)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		path := writeConfig(t, `{"sections": [{"pattern": "/blog/*", "max_pages": "5"}]}`)
+
+		_, err := loadConfig(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "max_pages should be int, not string")
+	})
+
+	t.Run("all problems reported", func(t *testing.T) {
+		path := writeConfig(t, `{
+  "scope": {"policy": "site"},
+  "extraction_rules": [{"field": "heading", "selector": "h1["}, {"field": "sku", "regexp": "SKU-("}],
+  "sections": [{"max_pages": -1, "extraction_rules": [{"selector": ".author"}]}],
+  "rate_limits": {"legacy.monzo.com": {"qps": -1, "concurrency": -1, "delay": "1 second"}}
+}`)
+
+		_, err := loadConfig(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `scope.policy: unknown policy "site"`)
+		require.Contains(t, err.Error(), `extraction_rules[0]: field heading has selector "h1["`)
+		require.Contains(t, err.Error(), `extraction_rules[1]: field sku has regexp "SKU-("`)
+		require.Contains(t, err.Error(), "sections[0].pattern: required")
+		require.Contains(t, err.Error(), "sections[0].max_pages: must not be negative")
+		require.Contains(t, err.Error(), `sections[0].extraction_rules[0]: selector ".author" has no field`)
+		require.Contains(t, err.Error(), `rate_limits["legacy.monzo.com"].qps: must not be negative`)
+		require.Contains(t, err.Error(), `rate_limits["legacy.monzo.com"].concurrency: must not be negative`)
+		require.Contains(t, err.Error(), `rate_limits["legacy.monzo.com"].delay: "1 second" isn't a duration, e.g. 500ms`)
+	})
+}
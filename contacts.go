@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// writeContactsReport writes the email addresses and phone numbers found on each page crawled as CSV, with a row per
+// page and contact, in the order the pages were crawled
+func writeContactsReport(w io.Writer, r *crawler.Report) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"page", "type", "contact", "source"})
+	for _, page := range r.Pages {
+		for _, contact := range page.Contacts {
+			out.Write([]string{page.URL, contact.Kind(), contact.Value, contact.Source})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteContactsReport(t *testing.T) {
+	r := &crawler.Report{Pages: []crawler.PageRecord{
+		{URL: "http://monzo.com/", Contacts: []crawler.Contact{
+			{Source: crawler.ContactMailto, Value: "help@monzo.com"},
+			{Source: crawler.ContactTel, Value: "+44 800 802 1281"},
+		}},
+		{URL: "http://monzo.com/about"},
+		{URL: "http://monzo.com/press", Contacts: []crawler.Contact{{Source: crawler.ContactText, Value: "press@monzo.com"}}},
+	}}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeContactsReport(out, r))
+	require.Equal(t, `page,type,contact,source
+http://monzo.com/,email,help@monzo.com,mailto
+http://monzo.com/,phone,+44 800 802 1281,tel
+http://monzo.com/press,email,press@monzo.com,text
+`, out.String())
+}
@@ -0,0 +1,116 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"golang.org/x/net/html"
+)
+
+// The accessibility checks made of each page
+const (
+	AccessibilityMissingAlt  = "missing-alt"  // an image without an alt attribute, which an empty one marks as decorative
+	AccessibilityEmptyLink   = "empty-link"   // a link without any text, image alt text or aria-label to name it
+	AccessibilityMissingLang = "missing-lang" // the page's html element has no lang attribute
+)
+
+// AccessibilityIssue is a problem found by one of the accessibility checks
+type AccessibilityIssue struct {
+	Check  string // AccessibilityMissingAlt, AccessibilityEmptyLink or AccessibilityMissingLang
+	Target string // the image's src or the link's href, resolved if possible, empty for AccessibilityMissingLang
+}
+
+// WithAccessibilityChecks records the images of each page without alt text, its links without any text to name them,
+// and whether its html element lacks a lang attribute, on Page.Accessibility, see Report.Accessibility
+func WithAccessibilityChecks() Option {
+	return func(c *crawler) {
+		c.accessibility = true
+	}
+}
+
+// accessibilityChecker makes the accessibility checks of a page as parsePage tokenizes it
+type accessibilityChecker struct {
+	links     *linkextract.Extractor // resolves targets against the page's base href
+	issues    []AccessibilityIssue
+	seen      map[AccessibilityIssue]bool
+	document  bool // set once an html, head or body element is seen, so that fragments and other formats aren't checked
+	lang      bool
+	inLink    bool
+	linkHref  string
+	linkNamed bool
+}
+
+func newAccessibilityChecker(links *linkextract.Extractor) *accessibilityChecker {
+	return &accessibilityChecker{links: links, seen: map[AccessibilityIssue]bool{}}
+}
+
+// startTag checks an element, and starts checking the name of a link
+func (a *accessibilityChecker) startTag(tag html.Token) {
+	switch tag.Data {
+	case "html":
+		a.document = true
+		a.lang = strings.TrimSpace(attrVal(tag, "lang")) != ""
+	case "head", "body":
+		a.document = true
+	case "img":
+		if !hasAttr(tag, "alt") {
+			a.add(AccessibilityMissingAlt, attrVal(tag, "src"))
+		} else if a.inLink && strings.TrimSpace(attrVal(tag, "alt")) != "" {
+			a.linkNamed = true
+		}
+	case "a":
+		if !hasAttr(tag, "href") || tag.Type == html.SelfClosingTagToken {
+			return
+		}
+		a.inLink, a.linkHref = true, attrVal(tag, "href")
+		a.linkNamed = strings.TrimSpace(attrVal(tag, "aria-label")) != "" || attrVal(tag, "aria-labelledby") != "" || strings.TrimSpace(attrVal(tag, "title")) != ""
+	}
+}
+
+// text names the link being checked, if any, with text of the page
+func (a *accessibilityChecker) text(text string) {
+	if a.inLink && strings.TrimSpace(text) != "" {
+		a.linkNamed = true
+	}
+}
+
+// endTag finishes checking a link
+func (a *accessibilityChecker) endTag(name string) {
+	if name == "a" && a.inLink {
+		if !a.linkNamed {
+			a.add(AccessibilityEmptyLink, a.linkHref)
+		}
+		a.inLink = false
+	}
+}
+
+// finish returns the issues found, once each
+func (a *accessibilityChecker) finish() []AccessibilityIssue {
+	if a.document && !a.lang {
+		a.add(AccessibilityMissingLang, "")
+	}
+	return a.issues
+}
+
+func (a *accessibilityChecker) add(check, target string) {
+	if target != "" {
+		if u, err := a.links.Resolve(target); err == nil && u != nil {
+			target = displayURL(u)
+		}
+	}
+	issue := AccessibilityIssue{Check: check, Target: target}
+	if !a.seen[issue] {
+		a.seen[issue] = true
+		a.issues = append(a.issues, issue)
+	}
+}
+
+// hasAttr reports whether a tag has an attribute, even if it's empty
+func hasAttr(tag html.Token, key string) bool {
+	for _, attr := range tag.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
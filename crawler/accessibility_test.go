@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessibilityChecks(t *testing.T) {
+	parse := func(body string) []AccessibilityIssue {
+		page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/help/"}}
+		parsePage(page, bytes.NewBufferString(body))
+		return page.accessibility
+	}
+
+	require.Equal(t, []AccessibilityIssue{
+		{Check: AccessibilityMissingAlt, Target: "https://monzo.com/help/logo.png"},
+		{Check: AccessibilityEmptyLink, Target: "https://monzo.com/careers"},
+		{Check: AccessibilityMissingAlt, Target: "https://monzo.com/icons/app.svg"},
+		{Check: AccessibilityEmptyLink, Target: "https://monzo.com/app"},
+	}, parse(`<html lang="en"><body>
+		<img src="logo.png">
+		<img src="divider.png" alt="">
+		<img src="logo.png">
+		<a href="/careers">  </a>
+		<a href="/careers"><span> </span></a>
+		<a href="/app"><img src="/icons/app.svg"></a>
+		<a href="/blog"><img src="/icons/blog.svg" alt="Blog"></a>
+		<a href="/twitter" aria-label="Monzo on Twitter"><svg></svg></a>
+		<a href="/about">About <b>us</b></a>
+		<a name="top"></a>
+	</body></html>`))
+
+	require.Equal(t, []AccessibilityIssue{{Check: AccessibilityMissingLang}}, parse(`<html><body><p>Hello</p></body></html>`))
+	require.Equal(t, []AccessibilityIssue{{Check: AccessibilityMissingLang}}, parse(`<html lang=" "><body></body></html>`))
+	require.Empty(t, parse(`{"not": "html"}`))
+
+	t.Run("crawl", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":      {Body: `<html><body><a href="/about"></a></body></html>`},
+			"/about": {Body: `<html lang="en"><body><img src="/team.jpg" alt="The team"></body></html>`},
+		})
+		defer srv.Close()
+
+		for _, enabled := range []bool{false, true} {
+			report := &Report{}
+			out := &bytes.Buffer{}
+			opts := []Option{WithReport(report), WithLogger(newTestLogger(io.Discard))}
+			if enabled {
+				opts = append(opts, WithAccessibilityChecks())
+			}
+			require.NoError(t, New(1, srv.Client(), opts...).Crawl(srv.URL+"/", out))
+
+			if !enabled {
+				require.NotContains(t, out.String(), "Accessibility:")
+				require.Empty(t, report.Accessibility())
+				continue
+			}
+			require.Contains(t, out.String(), "Accessibility:\n\tempty-link: "+srv.URL+"/about\n\tmissing-lang\n")
+			require.Equal(t, []AccessibilityRecord{{Page: srv.URL + "/", EmptyLinks: 1, MissingLang: true}}, report.Accessibility())
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"golang.org/x/net/html"
+)
+
+// AnchorText is the text of one of a page's <a> links, naming its target
+type AnchorText struct {
+	URL   *url.URL
+	Text  string // the link's text, including the alt text of images within it, with whitespace collapsed
+	Title string // the link's title attribute, with whitespace collapsed
+}
+
+// WithAnchorText records the text and title of each of a page's <a> links, in the order they're on the page, on
+// Page.AnchorTexts, see Report.AnchorTexts
+func WithAnchorText() Option {
+	return func(c *crawler) {
+		c.anchorText = true
+	}
+}
+
+// anchorTextCollector collects the text of a page's links as parsePage tokenizes it
+type anchorTextCollector struct {
+	links   *linkextract.Extractor // resolves the links against the page's base href
+	texts   []AnchorText
+	current *AnchorText // the link whose text is being collected, if any
+	text    strings.Builder
+}
+
+func newAnchorTextCollector(links *linkextract.Extractor) *anchorTextCollector {
+	return &anchorTextCollector{links: links}
+}
+
+// startTag starts collecting the text of a link, or adds the alt text of an image within one
+func (a *anchorTextCollector) startTag(tag html.Token) {
+	switch tag.Data {
+	case "a":
+		// a link can't contain another, so browsers close the first
+		a.endLink()
+		if !hasAttr(tag, "href") || tag.Type == html.SelfClosingTagToken {
+			return
+		}
+		u, err := a.links.Resolve(attrVal(tag, "href"))
+		if err != nil || u == nil {
+			return
+		}
+		a.current = &AnchorText{URL: u, Title: strings.Join(strings.Fields(attrVal(tag, "title")), " ")}
+	case "img":
+		if a.current != nil {
+			a.addText(" " + attrVal(tag, "alt") + " ")
+		}
+	}
+}
+
+// addText adds text of the page to the link being collected, if any
+func (a *anchorTextCollector) addText(text string) {
+	if a.current != nil {
+		a.text.WriteString(text)
+	}
+}
+
+// endTag finishes collecting a link's text
+func (a *anchorTextCollector) endTag(name string) {
+	if name == "a" {
+		a.endLink()
+	}
+}
+
+func (a *anchorTextCollector) endLink() {
+	if a.current == nil {
+		return
+	}
+	a.current.Text = strings.Join(strings.Fields(a.text.String()), " ")
+	a.texts = append(a.texts, *a.current)
+	a.current = nil
+	a.text.Reset()
+}
+
+// finish returns the texts of the page's links, including that of one left unclosed
+func (a *anchorTextCollector) finish() []AnchorText {
+	a.endLink()
+	return a.texts
+}
@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnchorText(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/help/"}}
+	parsePage(page, bytes.NewBufferString(`<html><body>
+		<a href="/about">About
+			<b>us</b></a>
+		<a href="contact" title=" Get in
+			touch ">Contact</a>
+		<a href="/blog"><img src="/icons/blog.svg" alt="Blog"> posts</a>
+		<a href="/careers"></a>
+		<a href="mailto:help@monzo.com">Email</a>
+		<a name="top">Top</a>
+		<a href="/app"><script>var name = "app"</script>App <a href="/cards">Cards
+	</body></html>`))
+
+	link := func(path string) *url.URL {
+		return &url.URL{Scheme: "https", Host: "monzo.com", Path: path}
+	}
+	require.Equal(t, []AnchorText{
+		{URL: link("/about"), Text: "About us"},
+		{URL: link("/help/contact"), Text: "Contact", Title: "Get in touch"},
+		{URL: link("/blog"), Text: "Blog posts"},
+		{URL: link("/careers")},
+		{URL: link("/app"), Text: "App"},
+		{URL: link("/cards"), Text: "Cards"},
+	}, page.anchorTexts)
+
+	t.Run("crawl", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":      {Body: `<a href="/about">About us</a><a href="/about" title="More">About</a><a href="/careers">Jobs</a>`},
+			"/about": {Body: `<a href="/">Home</a><a href="/careers">Jobs</a><a href="/careers"><img src="/jobs.png"></a>`},
+		})
+		defer srv.Close()
+
+		for _, enabled := range []bool{false, true} {
+			report := &Report{}
+			out := &bytes.Buffer{}
+			opts := []Option{WithReport(report), WithLogger(newTestLogger(io.Discard))}
+			if enabled {
+				opts = append(opts, WithAnchorText())
+			}
+			require.NoError(t, New(1, srv.Client(), opts...).Crawl(srv.URL+"/", out))
+
+			if !enabled {
+				require.NotContains(t, out.String(), "AnchorTexts:")
+				require.Empty(t, report.AnchorTexts())
+				continue
+			}
+			require.Contains(t, out.String(), "AnchorTexts:\n\t"+srv.URL+"/about: About us\n\t"+srv.URL+"/about: About\tMore\n")
+			require.Equal(t, []AnchorTextUsage{
+				{URL: srv.URL + "/careers", Links: 3, Pages: 2, Texts: []AnchorTextCount{{Text: "Jobs", Links: 2}, {Links: 1}}},
+				{URL: srv.URL + "/about", Links: 2, Pages: 1, Texts: []AnchorTextCount{{Text: "About", Links: 1}, {Text: "About us", Links: 1}}},
+				{URL: srv.URL + "/", Links: 1, Pages: 1, Texts: []AnchorTextCount{{Text: "Home", Links: 1}}},
+			}, report.AnchorTexts())
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// patternBudget limits the number of URLs crawled which match a glob pattern
+type patternBudget struct {
+	pattern string
+	re      *regexp.Regexp
+	max     int
+}
+
+// WithPatternBudget crawls at most max URLs whose path and query match pattern, in which "*" matches any sequence of
+// characters, e.g. "/search*" or "/products/*/reviews". This allows noisy sections to be sampled while the rest of a
+// site is crawled fully. A URL matching several patterns must be within all of their budgets.
+func WithPatternBudget(pattern string, max int) Option {
+	return func(c *crawler) {
+		c.patternBudgets = append(c.patternBudgets, patternBudget{
+			pattern: pattern,
+			re:      globRegexp(pattern),
+			max:     max,
+		})
+	}
+}
+
+// spendPatternBudgets records a URL against the budgets of each pattern it matches, as tracked by spent, returning
+// false without recording it if any of those budgets are exhausted
+func (c *crawler) spendPatternBudgets(u *url.URL, spent map[string]int) bool {
+	matched := []string{}
+	for _, budget := range c.patternBudgets {
+		if budget.re.MatchString(u.RequestURI()) {
+			if spent[budget.pattern] >= budget.max {
+				return false
+			}
+			matched = append(matched, budget.pattern)
+		}
+	}
+
+	for _, pattern := range matched {
+		spent[pattern]++
+	}
+	return true
+}
+
+// globRegexp compiles a glob pattern, in which "*" matches any sequence of characters, to an anchored regexp
+func globRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1) + "$")
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendPatternBudgets(t *testing.T) {
+	c := New(1, nil, WithPatternBudget("/search*", 2), WithPatternBudget("/products/*/reviews", 1)).(*crawler)
+	spent := map[string]int{}
+
+	spend := func(rawURL string) bool {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return c.spendPatternBudgets(u, spent)
+	}
+
+	require.True(t, spend("http://www.google.com/search?q=1"))
+	require.True(t, spend("http://www.google.com/search/advanced"))
+	require.False(t, spend("http://www.google.com/search?q=2"))
+
+	require.True(t, spend("http://www.google.com/products/1/reviews"))
+	require.False(t, spend("http://www.google.com/products/2/reviews"))
+	require.True(t, spend("http://www.google.com/products/2"))
+
+	require.True(t, spend("http://www.google.com/about"))
+	require.Equal(t, map[string]int{"/search*": 2, "/products/*/reviews": 1}, spent)
+}
+
+func TestWithMaxLinksPerPage(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":  {Body: `<a href="/">Home</a><a href="/1">1</a><a href="/2">2</a><a href="/3">3</a><a href="/4">4</a>`},
+		"/1": {Body: `<a href="/">Home</a><a href="/2">2</a>`},
+		"/2": {Body: `2`},
+		"/3": {Body: `3`},
+		"/4": {Body: `4`},
+	})
+	defer srv.Close()
+
+	crawl := func(opts ...Option) ([]string, *CrawlResult) {
+		capped := []string{}
+		opts = append(opts, WithMaxLinksPerPage(2), WithLogger(newTestLogger(io.Discard)), WithSubscriber(func(e Event) {
+			if skipped, ok := e.(URLSkipped); ok && skipped.Reason == SkipMaxLinks {
+				capped = append(capped, skipped.URL.Path)
+			}
+		}))
+		result, err := New(1, srv.Client(), opts...).CrawlWithResult(context.Background(), []string{srv.URL + "/"}, io.Discard)
+		require.NoError(t, err)
+		return capped, result
+	}
+
+	capped, result := crawl()
+	require.Equal(t, []string{"/3", "/4"}, capped, "links already seen shouldn't count against the cap")
+	require.Equal(t, 3, result.Pages)
+	require.Equal(t, 2, result.Summary.Limited)
+	require.Equal(t, SkipMaxLinks, result.Limit)
+
+	capped, _ = crawl(WithPriority(func(u *url.URL, depth int, referrer *Page) int {
+		if u.Path == "/4" {
+			return 1
+		}
+		return 0
+	}))
+	require.Equal(t, []string{"/2", "/3"}, capped, "the highest scoring links should be enqueued")
+}
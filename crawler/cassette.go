@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNotRecorded = errors.New("no recorded response")
+
+// cassetteURLHeader records the URL finally fetched in a recorded response, so redirects are replayed as such
+const cassetteURLHeader = "X-Cassette-Url"
+
+// Cassette is an http client which records each response to a file in a directory the first time its request is made,
+// and replays it from the file afterwards without making the request. Crawling with a cassette makes repeated crawls
+// of a real site deterministic and possible offline, e.g. for tests or debugging how a page was parsed.
+//
+// Requests are identified by their method and URL only. Redirects are followed by the underlying client and recorded
+// as the final response, so WithRedirectPolicy can't be used with a cassette.
+type Cassette struct {
+	dir    string
+	client httpClient
+}
+
+// NewCassette returns a cassette recording to and replaying from dir, which is created if it doesn't exist. Requests
+// without a recorded response are made with client or, if client is nil, fail with an error wrapping ErrNotRecorded.
+func NewCassette(dir string, client httpClient) (*Cassette, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cassette{dir: dir, client: client}, nil
+}
+
+// Do replays the recorded response to req, or makes and records it if there isn't one
+func (c *Cassette) Do(req *http.Request) (*http.Response, error) {
+	path := c.path(req)
+
+	recorded, err := os.ReadFile(path)
+	if err == nil {
+		return replay(recorded, req)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if c.client == nil {
+		return nil, errors.Wrapf(ErrNotRecorded, "%s %s", req.Method, req.URL)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.record(path, resp); err != nil {
+		resp.Body.Close()
+		return nil, errors.Wrap(err, "recording response")
+	}
+	return resp, nil
+}
+
+// path returns the file a request's response is recorded in
+func (c *Cassette) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + req.URL.String()))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".http")
+}
+
+// record writes a response to path, leaving its body to be read again. The file is written under a temporary name and
+// renamed, so a crawl interrupted while recording never leaves a truncated response to be replayed.
+func (c *Cassette) record(path string, resp *http.Response) error {
+	if resp.Request != nil {
+		resp.Header.Set(cassetteURLHeader, resp.Request.URL.String())
+		defer resp.Header.Del(cassetteURLHeader)
+	}
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "recording")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(dump); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// replay reads a recorded response to req
+func replay(recorded []byte, req *http.Request) (*http.Response, error) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(recorded)), req)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading recorded response")
+	}
+	if rawURL := resp.Header.Get(cassetteURLHeader); rawURL != "" {
+		resp.Header.Del(cassetteURLHeader)
+		if final, err := url.Parse(rawURL); err == nil && final.String() != req.URL.String() {
+			redirected := req.Clone(req.Context())
+			redirected.URL = final
+			resp.Request = redirected
+		}
+	}
+	return resp, nil
+}
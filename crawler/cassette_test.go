@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCassette(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":    {Title: "Home", Links: []string{"/old", "/missing"}},
+		"/old": {RedirectTo: "/new"},
+		"/new": {Title: "New"},
+	})
+	defer srv.Close()
+	dir := t.TempDir()
+
+	crawl := func(client httpClient) (string, *Summary) {
+		var out bytes.Buffer
+		summary := &Summary{}
+		c := New(1, client, WithSummary(summary), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URLFor("/"), &out))
+		for _, host := range summary.Hosts {
+			host.FetchDuration = 0 // depends on how long the requests took
+		}
+		return out.String(), summary
+	}
+
+	recording, err := NewCassette(dir, srv.Client())
+	require.NoError(t, err)
+	recorded, recordedSummary := crawl(recording)
+	require.Equal(t, 1, srv.Requests("/"))
+	require.Contains(t, recorded, "RedirectedTo:\n\t"+srv.URLFor("/new")+"\n")
+
+	replaying, err := NewCassette(dir, nil)
+	require.NoError(t, err)
+	replayed, replayedSummary := crawl(replaying)
+	require.Equal(t, 1, srv.Requests("/"), "replayed requests aren't made")
+	require.ElementsMatch(t, pageBlocks(t, recorded), pageBlocks(t, replayed))
+	require.Equal(t, recordedSummary, replayedSummary)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URLFor("/unrecorded"), nil)
+	require.NoError(t, err)
+	_, err = replaying.Do(req)
+	require.Equal(t, ErrNotRecorded, errors.Cause(err))
+}
+
+// pageBlocks splits crawl output in to its pages, whose order depends on the order they were fetched in, ignoring
+// their fetch durations
+func pageBlocks(t *testing.T, out string) []string {
+	pages, err := ReadPages(strings.NewReader(out))
+	require.NoError(t, err)
+
+	blocks := []string{}
+	for _, page := range pages {
+		page.FetchDuration = 0
+		blocks = append(blocks, string(page.Marshal()))
+	}
+	return blocks
+}
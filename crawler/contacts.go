@@ -0,0 +1,111 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"golang.org/x/net/html"
+)
+
+// The sources of a page's contact information
+const (
+	ContactMailto = "mailto" // an email address linked to with a mailto: URL
+	ContactTel    = "tel"    // a phone number linked to with a tel: URL
+	ContactText   = "text"   // an email address in the page's visible text
+)
+
+// emailPattern matches email addresses in text, requiring a top level domain of letters so that e.g. versioned package
+// names such as "react@18.2.0" aren't matched
+var emailPattern = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}`)
+
+// Contact is an email address or phone number found on a page
+type Contact struct {
+	Source string // ContactMailto, ContactTel or ContactText
+	Value  string // the email address or phone number, as written
+}
+
+// WithContactExtraction records the email addresses and phone numbers of each page's mailto: and tel: links, and the
+// email addresses in its visible text, on Page.Contacts
+func WithContactExtraction() Option {
+	return func(c *crawler) {
+		c.contacts = true
+	}
+}
+
+// Kind returns "phone" for phone numbers and "email" for email addresses
+func (c Contact) Kind() string {
+	if c.Source == ContactTel {
+		return "phone"
+	}
+	return "email"
+}
+
+// contactCollector collects the contact information of a page as parsePage tokenizes it
+type contactCollector struct {
+	links  *linkextract.Extractor // extracts the page's mailto: and tel: links
+	linked []Contact
+	text   []string // the email addresses in the page's visible text
+}
+
+func newContactCollector(base *url.URL) *contactCollector {
+	links := linkextract.New(base,
+		linkextract.WithElements(linkextract.Elements{"a": {"href"}, "area": {"href"}}),
+		linkextract.WithSchemes(ContactMailto, ContactTel),
+	)
+	return &contactCollector{links: links}
+}
+
+// startTag collects the email addresses or phone number of a mailto: or tel: link
+func (c *contactCollector) startTag(tag html.Token) {
+	found, _ := c.links.Token(tag)
+	for _, link := range found {
+		// e.g. "mailto:help@monzo.com,press@monzo.com?subject=Hi" or "tel:+44-800-802-1281"
+		addresses := link.URL.Opaque
+		if addresses == "" {
+			addresses = link.URL.Host + link.URL.Path
+		}
+		if unescaped, err := url.PathUnescape(addresses); err == nil {
+			addresses = unescaped
+		}
+		if link.URL.Scheme == ContactTel {
+			c.linked = append(c.linked, Contact{Source: ContactTel, Value: strings.TrimSpace(addresses)})
+			continue
+		}
+		for _, address := range strings.Split(addresses, ",") {
+			c.linked = append(c.linked, Contact{Source: ContactMailto, Value: strings.TrimSpace(address)})
+		}
+	}
+}
+
+// addText collects the email addresses in some of the page's visible text
+func (c *contactCollector) addText(text string) {
+	if strings.Contains(text, "@") {
+		c.text = append(c.text, emailPattern.FindAllString(text, -1)...)
+	}
+}
+
+// finish returns the contact information collected, once each, with email addresses which are both linked to and in
+// the page's text only found as links
+func (c *contactCollector) finish() []Contact {
+	var contacts []Contact
+	seen := map[string]bool{}
+	add := func(contact Contact) {
+		key := contact.Value
+		if contact.Source != ContactTel {
+			key = strings.ToLower(key) // domains, and in practice local parts, are case insensitive
+		}
+		if contact.Value != "" && !seen[key] {
+			seen[key] = true
+			contacts = append(contacts, contact)
+		}
+	}
+	for _, contact := range c.linked {
+		add(contact)
+	}
+	for _, address := range c.text {
+		add(Contact{Source: ContactText, Value: address})
+	}
+	return contacts
+}
@@ -0,0 +1,49 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectContacts(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/contact"}}
+	body := []byte(`<html><head><script>var support = "hidden@monzo.com"</script></head><body>
+		<a href="mailto:help@monzo.com?subject=Hello">help@monzo.com</a>
+		<a href="mailto:press@monzo.com,%20careers@monzo.com">Press and careers</a>
+		<a href="tel:+44-800-802-1281">Call us</a>
+		<a href="/about">About</a>
+		<p>Complaints to Complaints@Monzo.com, or complaints@monzo.com. Built with react@18.2.0.</p>
+		<map><area href="mailto:map@monzo.com"></map>
+	</body></html>`)
+
+	require.Equal(t, []Contact{
+		{Source: ContactMailto, Value: "help@monzo.com"},
+		{Source: ContactMailto, Value: "press@monzo.com"},
+		{Source: ContactMailto, Value: "careers@monzo.com"},
+		{Source: ContactTel, Value: "+44-800-802-1281"},
+		{Source: ContactMailto, Value: "map@monzo.com"},
+		{Source: ContactText, Value: "Complaints@Monzo.com"},
+	}, parsed(page, body).contacts)
+}
+
+func TestContactExtraction(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/": {Body: `<html><body><a href="mailto:help@monzo.com">Email us</a> or call <a href="tel:08008021281">0800 802 1281</a></body></html>`},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	out := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithReport(report), WithContactExtraction(), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", out))
+
+	require.Contains(t, out.String(), "Contacts:\n\tmailto: help@monzo.com\n\ttel: 08008021281\n")
+	require.Len(t, report.Pages, 1)
+	require.Equal(t, []Contact{{Source: ContactMailto, Value: "help@monzo.com"}, {Source: ContactTel, Value: "08008021281"}}, report.Pages[0].Contacts)
+	require.Equal(t, "phone", report.Pages[0].Contacts[1].Kind())
+}
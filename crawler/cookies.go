@@ -0,0 +1,96 @@
+package crawler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Cookie is a cookie set by a page's response, without its value, which may be a secret, e.g. a session ID
+type Cookie struct {
+	Name     string
+	Secure   bool
+	HttpOnly bool
+	SameSite string // "Strict", "Lax" or "None", or empty if the cookie doesn't set a valid SameSite attribute
+}
+
+// WithCookieAudit records the cookies set by each page's response on Page.Cookies, so that those missing the Secure,
+// HttpOnly or SameSite attributes can be reported, see Report.CookieIssues
+func WithCookieAudit() Option {
+	return func(c *crawler) {
+		c.cookieAudit = true
+	}
+}
+
+// responseCookies returns the cookies set by the Set-Cookie headers of a response
+func responseCookies(resp *http.Response) []Cookie {
+	var cookies []Cookie
+	for _, cookie := range resp.Cookies() {
+		cookies = append(cookies, Cookie{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		})
+	}
+	return cookies
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
+
+// Missing returns the security attributes the cookie doesn't set, in the order Secure, HttpOnly, SameSite
+func (c Cookie) Missing() []string {
+	var missing []string
+	if !c.Secure {
+		missing = append(missing, "Secure")
+	}
+	if !c.HttpOnly {
+		missing = append(missing, "HttpOnly")
+	}
+	if c.SameSite == "" {
+		missing = append(missing, "SameSite")
+	}
+	return missing
+}
+
+// attributes formats the cookie's security attributes as in a Set-Cookie header, e.g. "Secure; SameSite=Lax"
+func (c Cookie) attributes() string {
+	attrs := []string{}
+	if c.Secure {
+		attrs = append(attrs, "Secure")
+	}
+	if c.HttpOnly {
+		attrs = append(attrs, "HttpOnly")
+	}
+	if c.SameSite != "" {
+		attrs = append(attrs, "SameSite="+c.SameSite)
+	}
+	return strings.Join(attrs, "; ")
+}
+
+// parseCookie parses a cookie in the format written by Page.Marshal, e.g. "session: Secure; SameSite=Lax"
+func parseCookie(value string) Cookie {
+	name, attrs := splitPair(value)
+	cookie := Cookie{Name: name}
+	for _, attr := range strings.Split(attrs, "; ") {
+		switch {
+		case attr == "Secure":
+			cookie.Secure = true
+		case attr == "HttpOnly":
+			cookie.HttpOnly = true
+		case strings.HasPrefix(attr, "SameSite="):
+			cookie.SameSite = strings.TrimPrefix(attr, "SameSite=")
+		}
+	}
+	return cookie
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieAudit(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/": {Links: []string{"/account", "/about"}, Header: http.Header{"Set-Cookie": {
+			"session=secret; Secure; HttpOnly; SameSite=Lax",
+			"tracking=abc; Path=/",
+		}}},
+		"/account": {Header: http.Header{"Set-Cookie": {"tracking=def", "csrf=xyz; Secure; SameSite=Strict"}}},
+		"/about":   {},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	out := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithReport(report), WithCookieAudit(), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", out))
+
+	require.Contains(t, out.String(), "Cookies:\n\tsession: Secure; HttpOnly; SameSite=Lax\n\ttracking: \n")
+	require.NotContains(t, out.String(), "secret")
+
+	host := srv.Listener.Addr().String()
+	require.Equal(t, []CookieRecord{
+		{Host: host, Name: "tracking", FirstSeen: srv.URL + "/", Missing: []string{"Secure", "HttpOnly", "SameSite"}},
+		{Host: host, Name: "csrf", FirstSeen: srv.URL + "/account", Missing: []string{"HttpOnly"}},
+	}, report.CookieIssues())
+}
+
+func TestCookieIssuesGroupedByHost(t *testing.T) {
+	report := &Report{Pages: []PageRecord{
+		{URL: "http://a.monzo.com/", Cookies: []Cookie{{Name: "one"}}},
+		{URL: "http://b.monzo.com/", Cookies: []Cookie{{Name: "two"}}},
+		{URL: "http://a.monzo.com/more", Cookies: []Cookie{{Name: "three", Secure: true, HttpOnly: true}}},
+	}}
+
+	issues := []string{}
+	for _, record := range report.CookieIssues() {
+		issues = append(issues, record.Host+" "+record.Name)
+	}
+	require.Equal(t, []string{"a.monzo.com one", "a.monzo.com three", "b.monzo.com two"}, issues)
+}
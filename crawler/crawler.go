@@ -4,82 +4,676 @@ package crawler
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/html"
 )
 
-var ErrHttpStatusCode = errors.New("received HTTP error status code")
+var (
+	ErrHttpStatusCode = errors.New("received HTTP error status code")
+	ErrNoSeeds        = errors.New("no seed URLs to crawl")
+)
 
 type httpClient interface {
-	Get(string) (*http.Response, error)
+	Do(*http.Request) (*http.Response, error)
+}
+
+// FetchError is returned when a page could not be fetched
+type FetchError struct {
+	URL        *url.URL
+	Referrer   *url.URL
+	StatusCode int // the response's status code, if one was received
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	if e.Referrer == nil {
+		return e.Err.Error()
+	}
+	return e.Err.Error() + " (linked from " + displayURL(e.Referrer) + ")"
+}
+
+// Cause returns the underlying error so that errors.Cause can see through a FetchError
+func (e *FetchError) Cause() error {
+	return e.Err
 }
 
 type Page struct {
-	URL   *url.URL
-	Links []*url.URL
+	URL           *url.URL
+	Referrer      *url.URL // the first page found linking to URL, nil for the seed
+	StatusCode    int
+	ContentLength int64                 // the number of body bytes read, regardless of the Content-Length header
+	FetchDuration time.Duration         // the time taken to request the page and read its body
+	RedirectedTo  *url.URL              // the URL finally fetched if the request was redirected
+	Location      *url.URL              // the target of a redirect response which wasn't followed
+	Refresh       *url.URL              // the target of a <meta http-equiv="refresh"> redirect, see WithFollowMetaRefresh
+	ContentHash   string                // the hex encoded SHA-256 of the response body
+	LastModified  time.Time             // the response's Last-Modified time, zero if it had none
+	Protocol      string                // the protocol the page was fetched over, e.g. HTTP/3.0, recorded with WithHTTP3
+	UserAgent     string                // the User-Agent the page was requested with, recorded with WithUserAgents
+	Headers       http.Header           // the response headers selected with WithCaptureHeaders
+	Cookies       []Cookie              // the cookies set by the response, recorded with WithCookieAudit
+	Title         string                // the text of the page's first title element, with whitespace collapsed
+	Description   string                // the content of the page's first description meta tag, with whitespace collapsed
+	Language      string                // the page's language code, empty if it couldn't be determined
+	NoIndex       bool                  // set by a noindex robots meta tag or X-Robots-Tag header
+	NoFollow      bool                  // set by a nofollow robots meta tag or X-Robots-Tag header
+	Next          *url.URL              // the next page in a paginated series, from rel="next"
+	Prev          *url.URL              // the previous page in a paginated series, from rel="prev"
+	AMP           *url.URL              // the page's AMP version, from <link rel="amphtml">
+	Alternates    []*url.URL            // the page's mobile and translated versions, from <link rel="alternate">
+	OffsiteHops   int                   // the number of links followed out of scope to reach the page, see WithOffsiteDepth
+	Matches       []SearchMatch         // occurrences of the patterns given to WithSearch in the page's text
+	MixedContent  []MixedContent        // the http:// assets of an https:// page, see WithMixedContentDetection
+	Forms         []Form                // the endpoints the page's forms submit to, see WithFormDiscovery
+	InsecureForms []InsecureForm        // the forms submitting over http or to another origin, see WithInsecureFormDetection
+	Subresources  []Subresource         // the scripts and stylesheets loaded from other origins, see WithSubresourceIntegrity
+	ThirdParty    []ThirdPartyReference // the URLs on other registrable domains referenced, see WithThirdPartyInventory
+	Trackers      []Tracker             // the analytics and tracking scripts loaded, see WithTrackerDetection
+	Contacts      []Contact             // the email addresses and phone numbers found, see WithContactExtraction
+	Accessibility []AccessibilityIssue  // the problems found by the accessibility checks, see WithAccessibilityChecks
+	AnchorTexts   []AnchorText          // the text and title of each of the page's <a> links, see WithAnchorText
+	Images        []Image               // the page's images with their alt text and dimensions, see WithImageInventory
+	Fields        map[string][]string   // the values extracted by each rule given to WithExtractionRules, by field
+	Soft404       string                // why the page looks like an error page despite its status, see WithSoft404Detection
+	Links         []*url.URL
+
+	filtered       bool                 // set if a response filter skipped the page, so it wasn't parsed
+	malformedLinks []error              // the errors parsing any of the page's links which were malformed
+	accessibility  []AccessibilityIssue // the problems found by the accessibility checks, made whether or not enabled
+	anchorTexts    []AnchorText         // the texts of the page's links, collected whether or not enabled
+	images         []Image              // the page's images, collected whether or not enabled
+	assets         []linkextract.Link   // the URLs of the page's thirdPartyElements, collected whether or not enabled
+	subresources   []Subresource        // the page's cross-origin scripts and stylesheets, collected whether or not enabled
+	forms          []Form               // every form of the page, collected whether or not enabled
+	contacts       []Contact            // the page's contact information, collected whether or not enabled
+	anchors        map[string]bool      // the ids and anchor names of the page's elements, nil if it isn't HTML
+	fragmentLinks  []fragmentLink       // the page's links with fragments, see WithFragmentValidation
+	body           []byte               // the response body, kept for WithMirror and WithSoft404Detection
+	contentType    string               // the response's Content-Type
+}
+
+// base returns the URL the page's links are relative to, where it was found, e.g. a directory's index after a redirect
+// to add a slash
+func (p *Page) base() *url.URL {
+	if p.RedirectedTo != nil {
+		return p.RedirectedTo
+	}
+	return p.URL
 }
 
 func (p *Page) Marshal() []byte {
-	out := []byte("URL:\n\t" + p.URL.String() + "\nLinks: \n")
+	out := []byte("URL:\n\t" + displayURL(p.URL) + "\n")
+	if p.Referrer != nil {
+		out = append(out, []byte("Referrer:\n\t"+displayURL(p.Referrer)+"\n")...)
+	}
+	if p.RedirectedTo != nil {
+		out = append(out, []byte("RedirectedTo:\n\t"+displayURL(p.RedirectedTo)+"\n")...)
+	}
+	if p.Location != nil {
+		out = append(out, []byte("Location:\n\t"+displayURL(p.Location)+"\n")...)
+	}
+	if p.Refresh != nil {
+		out = append(out, []byte("Refresh:\n\t"+displayURL(p.Refresh)+"\n")...)
+	}
+	out = append(out, []byte(fmt.Sprintf("Status:\n\t%d\nContentLength:\n\t%d\nFetchDuration:\n\t%s\nContentHash:\n\t%s\n", p.StatusCode, p.ContentLength, p.FetchDuration, p.ContentHash))...)
+	if !p.LastModified.IsZero() {
+		out = append(out, []byte("LastModified:\n\t"+p.LastModified.UTC().Format(time.RFC3339)+"\n")...)
+	}
+	if p.Protocol != "" {
+		out = append(out, []byte("Protocol:\n\t"+p.Protocol+"\n")...)
+	}
+	if p.UserAgent != "" {
+		out = append(out, []byte("UserAgent:\n\t"+p.UserAgent+"\n")...)
+	}
+	if p.Title != "" {
+		out = append(out, []byte("Title:\n\t"+p.Title+"\n")...)
+	}
+	if p.Description != "" {
+		out = append(out, []byte("Description:\n\t"+p.Description+"\n")...)
+	}
+	if p.Soft404 != "" {
+		out = append(out, []byte("Soft404:\n\t"+p.Soft404+"\n")...)
+	}
+	if p.Language != "" {
+		out = append(out, []byte("Language:\n\t"+p.Language+"\n")...)
+	}
+	if p.NoIndex || p.NoFollow {
+		directives := []string{}
+		if p.NoIndex {
+			directives = append(directives, "noindex")
+		}
+		if p.NoFollow {
+			directives = append(directives, "nofollow")
+		}
+		out = append(out, []byte("Robots:\n\t"+strings.Join(directives, ", ")+"\n")...)
+	}
+	if p.OffsiteHops > 0 {
+		out = append(out, []byte(fmt.Sprintf("OffsiteHops:\n\t%d\n", p.OffsiteHops))...)
+	}
+	if p.Next != nil {
+		out = append(out, []byte("Next:\n\t"+displayURL(p.Next)+"\n")...)
+	}
+	if p.Prev != nil {
+		out = append(out, []byte("Prev:\n\t"+displayURL(p.Prev)+"\n")...)
+	}
+	if p.AMP != nil {
+		out = append(out, []byte("AMP:\n\t"+displayURL(p.AMP)+"\n")...)
+	}
+	if len(p.Alternates) > 0 {
+		out = append(out, []byte("Alternates:\n")...)
+		for _, alternate := range p.Alternates {
+			out = append(out, []byte("\t"+displayURL(alternate)+"\n")...)
+		}
+	}
+	if len(p.Matches) > 0 {
+		out = append(out, []byte("Matches:\n")...)
+		for _, match := range p.Matches {
+			out = append(out, []byte("\t"+match.Pattern+": "+match.Context+"\n")...)
+		}
+	}
+	if len(p.MixedContent) > 0 {
+		out = append(out, []byte("MixedContent:\n")...)
+		for _, mixed := range p.MixedContent {
+			out = append(out, []byte("\t"+mixed.Element+": "+displayURL(mixed.URL)+"\n")...)
+		}
+	}
+	if len(p.ThirdParty) > 0 {
+		out = append(out, []byte("ThirdParty:\n")...)
+		for _, ref := range p.ThirdParty {
+			out = append(out, []byte("\t"+ref.Element+": "+displayURL(ref.URL)+"\n")...)
+		}
+	}
+	if len(p.Trackers) > 0 {
+		out = append(out, []byte("Trackers:\n")...)
+		for _, tracker := range p.Trackers {
+			out = append(out, []byte("\t"+tracker.Name+": "+displayURL(tracker.URL)+"\n")...)
+		}
+	}
+	if len(p.Contacts) > 0 {
+		out = append(out, []byte("Contacts:\n")...)
+		for _, contact := range p.Contacts {
+			out = append(out, []byte("\t"+contact.Source+": "+contact.Value+"\n")...)
+		}
+	}
+	if len(p.Subresources) > 0 {
+		out = append(out, []byte("Subresources:\n")...)
+		for _, sub := range p.Subresources {
+			line := "\t" + sub.Status + ": " + sub.Element + " " + displayURL(sub.URL)
+			if sub.Integrity != "" {
+				line += " " + sub.Integrity
+			}
+			out = append(out, []byte(line+"\n")...)
+		}
+	}
+	if len(p.Forms) > 0 {
+		out = append(out, []byte("Forms:\n")...)
+		for _, form := range p.Forms {
+			out = append(out, []byte("\t"+form.Method+" "+displayURL(form.Action)+"\n")...)
+		}
+	}
+	if len(p.InsecureForms) > 0 {
+		out = append(out, []byte("InsecureForms:\n")...)
+		for _, form := range p.InsecureForms {
+			out = append(out, []byte("\t"+form.Reason+": "+form.Method+" "+displayURL(form.Action)+"\n")...)
+		}
+	}
+	if len(p.Accessibility) > 0 {
+		out = append(out, []byte("Accessibility:\n")...)
+		for _, issue := range p.Accessibility {
+			line := "\t" + issue.Check
+			if issue.Target != "" {
+				line += ": " + issue.Target
+			}
+			out = append(out, []byte(line+"\n")...)
+		}
+	}
+	if len(p.AnchorTexts) > 0 {
+		out = append(out, []byte("AnchorTexts:\n")...)
+		for _, anchor := range p.AnchorTexts {
+			line := "\t" + displayURL(anchor.URL) + ": " + anchor.Text
+			if anchor.Title != "" {
+				line += "\t" + anchor.Title
+			}
+			out = append(out, []byte(line+"\n")...)
+		}
+	}
+	if len(p.Images) > 0 {
+		out = append(out, []byte("Images:\n")...)
+		for _, image := range p.Images {
+			attrs := []string{}
+			if image.Width != "" {
+				attrs = append(attrs, "width="+image.Width)
+			}
+			if image.Height != "" {
+				attrs = append(attrs, "height="+image.Height)
+			}
+			if image.HasAlt {
+				attrs = append(attrs, "alt="+strconv.Quote(image.Alt))
+			}
+			out = append(out, []byte("\t"+displayURL(image.URL)+": "+strings.Join(attrs, " ")+"\n")...)
+		}
+	}
+	if len(p.Fields) > 0 {
+		out = append(out, []byte("Fields:\n")...)
+		fields := make([]string, 0, len(p.Fields))
+		for field := range p.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			for _, v := range p.Fields[field] {
+				out = append(out, []byte("\t"+field+": "+v+"\n")...)
+			}
+		}
+	}
+	if len(p.Cookies) > 0 {
+		out = append(out, []byte("Cookies:\n")...)
+		for _, cookie := range p.Cookies {
+			out = append(out, []byte("\t"+cookie.Name+": "+cookie.attributes()+"\n")...)
+		}
+	}
+	if len(p.Headers) > 0 {
+		out = append(out, []byte("Headers:\n")...)
+		keys := make([]string, 0, len(p.Headers))
+		for k := range p.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range p.Headers[k] {
+				out = append(out, []byte("\t"+k+": "+v+"\n")...)
+			}
+		}
+	}
+	out = append(out, []byte("Links: \n")...)
 	for _, link := range p.Links {
-		out = append(out, []byte("\t"+link.String()+"\n")...)
+		out = append(out, []byte("\t"+displayURL(link)+"\n")...)
 	}
 	return out
 }
 
+// Crawler crawls websites. A Crawler may be used for any number of crawls, including several at once from different
+// goroutines, each with its own set of URLs seen.
 type Crawler interface {
 	Crawl(string, io.Writer) error
+	CrawlAll(context.Context, []string, io.Writer) error
+	CrawlWithResult(context.Context, []string, io.Writer) (*CrawlResult, error)
+	CrawlToSink(context.Context, []string, Sink) (*CrawlResult, error)
+	Pause()
+	Resume()
 }
 
 type crawler struct {
-	workerCount int
-	httpClient  httpClient
+	workerCount        int
+	httpClient         httpClient
+	captureHeaders     []string
+	summary            *Summary
+	maxPages           int
+	maxLinks           int
+	paginationPriority bool
+	scorer             Scorer
+	seeds              []string
+	ignoreRobots       bool
+	redirectPolicy     *RedirectPolicy
+	hostAliases        map[string]string // maps each alias to its canonical host
+	urlLimits          URLLimits
+	normalizers        []func(*url.URL) *url.URL
+	trapLimits         TrapLimits
+	patternBudgets     []patternBudget
+	gate               gate
+	subscribers        []func(Event)
+	tracer             trace.Tracer
+	errorReport        io.Writer
+	requestHooks       []func(*http.Request) error
+	responseFilters    []func(*http.Response) (bool, error)
+	linkFilters        []func(*Page, *url.URL) bool
+	scopePolicy        ScopePolicy
+	offsiteDepth       int
+	searchPatterns     []*regexp.Regexp
+	extractors         []extractor
+	extractionErr      error
+	logger             *slog.Logger
+	progressInterval   time.Duration
+	progress           func(Progress)
+	pageFormat         func(*Page) ([]byte, error)
+	report             *Report
+	maxBodySize        int64
+	linkOpts           []linkextract.Option
+	followMetaRefresh  bool
+	followAlternates   bool
+	soft404Rules       *Soft404Rules
+	http3              bool
+	fileRoot           string
+	mirrorDir          string
+	userAgent          string
+	userAgents         []string
+	userAgentRotation  UserAgentRotation
+	jitterMin          time.Duration
+	jitterMax          time.Duration
+	politeness         *politeness
+	hostSharding       bool
+	topPages           int
+	sitemaps           bool
+	sitemapSeeds       bool
+	mixedContent       bool
+	insecureForms      bool
+	forms              bool
+	followForms        bool
+	cookieAudit        bool
+	sriCheck           bool
+	sriVerify          bool
+	thirdParty         bool
+	trackers           []TrackerSignature
+	contacts           bool
+	accessibility      bool
+	anchorText         bool
+	images             bool
+	fragments          bool
+	userAgentTurn      atomic.Uint64 // the number of requests sent with a rotated user agent, see userAgentFor
+	eventsMu           sync.Mutex    // serialises the events of every crawl, see WithSubscriber
+	collectMu          sync.Mutex    // guards summary and report, which every crawl adds to
+}
+
+// Option configures optional crawler behaviour
+type Option func(*crawler)
+
+// WithCaptureHeaders records the named response headers on each Page
+func WithCaptureHeaders(names ...string) Option {
+	return func(c *crawler) {
+		for _, name := range names {
+			c.captureHeaders = append(c.captureHeaders, http.CanonicalHeaderKey(name))
+		}
+	}
 }
 
-func New(workerCount int, httpClient httpClient) Crawler {
-	return &crawler{
+// WithSeeds crawls the given URLs, each of which may be a seed template, in addition to the URL passed to Crawl
+func WithSeeds(rawURLs ...string) Option {
+	return func(c *crawler) {
+		c.seeds = append(c.seeds, rawURLs...)
+	}
+}
+
+// defaultMaxBodySize is the number of bytes of each response body read unless WithMaxBodySize is given
+const defaultMaxBodySize = 32 << 20
+
+// WithMaxBodySize limits the number of bytes of each response body read, truncating larger pages, zero meaning
+// unlimited. The default is 32MiB.
+func WithMaxBodySize(n int64) Option {
+	return func(c *crawler) {
+		c.maxBodySize = n
+	}
+}
+
+// WithLinkExtraction configures how links are extracted from pages, e.g. to follow the src of iframes with
+// linkextract.WithElement("iframe", "src")
+func WithLinkExtraction(opts ...linkextract.Option) Option {
+	return func(c *crawler) {
+		c.linkOpts = append(c.linkOpts, opts...)
+	}
+}
+
+// WithFollowMetaRefresh crawls the targets of meta refresh redirects, which are otherwise only recorded on the Page.
+// Like other redirects, they're followed from nofollow pages.
+func WithFollowMetaRefresh() Option {
+	return func(c *crawler) {
+		c.followMetaRefresh = true
+	}
+}
+
+// WithFollowAlternates crawls the AMP and alternate versions of each page, see Page.AMP and Page.Alternates, so that
+// Report.BrokenAlternates can find those which are missing or broken
+func WithFollowAlternates() Option {
+	return func(c *crawler) {
+		c.followAlternates = true
+	}
+}
+
+// WithMaxPages limits the number of pages crawled, zero meaning unlimited
+func WithMaxPages(n int) Option {
+	return func(c *crawler) {
+		c.maxPages = n
+	}
+}
+
+// WithMaxLinksPerPage enqueues at most n of each page's links to URLs not seen before, highest scoring first if
+// WithPriority is used and otherwise in the order they're on the page, so that navigation menus and tag clouds can't
+// take over a crawl. Pagination, meta refresh, alternate and form links aren't capped. Zero means unlimited.
+func WithMaxLinksPerPage(n int) Option {
+	return func(c *crawler) {
+		c.maxLinks = n
+	}
+}
+
+// WithPaginationPriority follows rel="next"/rel="prev" pagination chains to their end, even once the page budget
+// set by WithMaxPages has been spent
+func WithPaginationPriority() Option {
+	return func(c *crawler) {
+		c.paginationPriority = true
+	}
+}
+
+// WithIgnoreRobotsDirectives writes noindex pages and follows the links of nofollow pages, rather than honouring
+// their robots meta tags and X-Robots-Tag headers
+func WithIgnoreRobotsDirectives() Option {
+	return func(c *crawler) {
+		c.ignoreRobots = true
+	}
+}
+
+// WithURLNormalizer applies normalize to every seed and link before it's deduplicated and scheduled, allowing site
+// specific equivalences to be encoded, e.g. stripping locale prefixes or session path segments. normalize may modify
+// and return its argument, or return nil to drop the URL. Several normalizers are applied in the order given.
+func WithURLNormalizer(normalize func(*url.URL) *url.URL) Option {
+	return func(c *crawler) {
+		c.normalizers = append(c.normalizers, normalize)
+	}
+}
+
+// WithRequestHook calls hook with each request before it's sent, e.g. to sign it or add headers. Hooks are called in
+// the order they were added, and an error returned by any of them aborts the crawl.
+func WithRequestHook(hook func(*http.Request) error) Option {
+	return func(c *crawler) {
+		c.requestHooks = append(c.requestHooks, hook)
+	}
+}
+
+// WithLogger logs the crawler's fetches, skipped URLs and non-fatal errors to l rather than slog's default logger
+func WithLogger(l *slog.Logger) Option {
+	return func(c *crawler) {
+		c.logger = l
+	}
+}
+
+// WithSummary accumulates statistics about each crawl in to s, which can be read once Crawl has returned. Crawls
+// running at once add to s as each of them finishes.
+func WithSummary(s *Summary) Option {
+	return func(c *crawler) {
+		c.summary = s
+	}
+}
+
+func New(workerCount int, httpClient httpClient, opts ...Option) Crawler {
+	c := &crawler{
 		workerCount: workerCount,
 		httpClient:  httpClient,
+		logger:      slog.Default(),
+		maxBodySize: defaultMaxBodySize,
+		userAgent:   DefaultUserAgent,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
+// Crawl crawls every page reachable from rawURL which is in scope, by default on the same host, writing each to out.
+// rawURL may be a seed template, see ExpandSeedTemplate, in which case every generated seed is crawled and pages in
+// scope of any of them are crawled, as are pages in scope of any seeds added with WithSeeds.
 func (c *crawler) Crawl(rawURL string, out io.Writer) error {
-	seedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return err
+	return c.CrawlAll(context.Background(), []string{rawURL}, out)
+}
+
+// CrawlAll crawls several sites, or several entry points to one, at once, sharing the workers, the set of URLs seen
+// and out between them. Each of rawURLs may be a seed template, and a page is in scope if it's in scope of any seed,
+// so links between the sites are followed but never crawled twice. Cancelling ctx stops the crawl, abandoning any
+// fetches in flight, and CrawlAll returns ctx's error.
+func (c *crawler) CrawlAll(ctx context.Context, rawURLs []string, out io.Writer) error {
+	return c.crawl(ctx, rawURLs, WriterSink(out, c.formatPage), &CrawlResult{})
+}
+
+// CrawlWithResult crawls as CrawlAll does, also returning how the crawl went. The result is returned even if the crawl
+// fails, describing the pages crawled before it did.
+func (c *crawler) CrawlWithResult(ctx context.Context, rawURLs []string, out io.Writer) (*CrawlResult, error) {
+	result := &CrawlResult{}
+	err := c.crawl(ctx, rawURLs, WriterSink(out, c.formatPage), result)
+	return result, err
+}
+
+// crawl runs a crawl for CrawlAll, CrawlWithResult and CrawlToSink, describing it in result once it's finished
+func (c *crawler) crawl(ctx context.Context, rawURLs []string, sink Sink, result *CrawlResult) (err error) {
+	start := time.Now()
+	if c.extractionErr != nil {
+		return c.extractionErr
+	}
+	if len(rawURLs) == 0 && len(c.seeds) == 0 {
+		return ErrNoSeeds
 	}
 
-	var wg sync.WaitGroup
-	cache := map[string]struct{}{}
-	newURLs := make(chan *url.URL)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the workers if the crawl ends early
 
-	wg.Add(1)
-	go func() {
-		newURLs <- seedURL
-	}()
+	rawSeeds := []string{}
+	for _, tmpl := range append(append([]string{}, rawURLs...), c.seeds...) {
+		expanded, err := ExpandSeedTemplate(tmpl)
+		if err != nil {
+			return err
+		}
+		rawSeeds = append(rawSeeds, expanded...)
+	}
 
-	go func() {
-		defer close(newURLs)
-		wg.Wait()
+	seedURLs := []*url.URL{}
+	for _, rawSeed := range rawSeeds {
+		seedURL, err := url.Parse(rawSeed)
+		if err != nil {
+			return err
+		}
+		seedURLs = append(seedURLs, seedURL)
+	}
+
+	summary := &Summary{top: c.topPages}
+	var report *Report
+	if c.report != nil {
+		report = &Report{}
+	}
+	defer c.collect(summary, report)
+
+	progress := newProgressTracker()
+	subscribers := append([]func(Event){logEvents(c.logger), progress.record}, c.subscribers...)
+	if c.errorReport != nil {
+		subscribers = append(subscribers, reportErrors(c.errorReport, c.logger))
+	}
+	if report != nil {
+		subscribers = append(subscribers, report.record)
+	}
+	if c.tracer != nil {
+		traceFetches, endSpan := c.startCrawlSpan(ctx, rawSeeds[0])
+		defer func() {
+			endSpan(summary, err)
+		}()
+		subscribers = append(subscribers, traceFetches)
+	}
+	s := newSession(ctx, c, &eventBus{mu: &c.eventsMu, subscribers: subscribers}, summary, c.scope(seedURLs))
+	defer func() {
+		*result = CrawlResult{Pages: summary.Pages, Errors: summary.Errors, Duration: time.Since(start), Limit: s.limit, Summary: *summary}
 	}()
 
+	var tick <-chan time.Time
+	if c.progress != nil {
+		ticker := time.NewTicker(c.progressInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+		defer func() {
+			c.progress(progress.snapshot())
+		}()
+	}
+
+	client, release, err := c.crawlClient(s.inScope)
+	if err != nil {
+		return err
+	}
+	defer release()
+	var soft404s *soft404Detector
+	if c.soft404Rules != nil {
+		soft404s = newSoft404Detector(*c.soft404Rules, func(ctx context.Context, u *url.URL) ([]byte, int, *url.URL, error) {
+			// the probe waits its turn like any other request to the host
+			if !c.gate.wait(ctx) || !c.jitter(ctx) {
+				return nil, 0, nil, ctx.Err()
+			}
+			release, ok := c.politeness.acquire(ctx, u)
+			if !ok {
+				return nil, 0, nil, ctx.Err()
+			}
+			defer release()
+			return c.fetchFinal(ctx, client, u)
+		})
+	}
+	var sri *sriVerifier
+	if c.sriVerify {
+		sri = newSRIVerifier(func(ctx context.Context, u *url.URL) ([]byte, int, error) {
+			return c.fetchBody(ctx, client, u)
+		})
+	}
+	if c.mirrorDir != "" {
+		s.mirror = newMirror(c.mirrorDir, c.linkOpts)
+		defer func() {
+			// an interrupted crawl still leaves a browsable copy of the pages saved so far
+			if rewriteErr := s.mirror.rewriteLinks(); err == nil {
+				err = rewriteErr
+			}
+		}()
+	}
+	if (c.sitemaps && report != nil) || c.sitemapSeeds {
+		sitemap := c.fetchSitemaps(ctx, client, seedURLs)
+		if c.sitemaps && report != nil {
+			report.Sitemap = sitemap
+		}
+		if c.sitemapSeeds {
+			for _, rawURL := range sitemap {
+				if u, err := url.Parse(rawURL); err == nil && s.inScope(u) {
+					seedURLs = append(seedURLs, u)
+				}
+			}
+		}
+	}
+	s.enqueueSeeds(seedURLs)
+
 	pageChans := []<-chan *Page{}
 	errChans := []<-chan error{}
 	for i := 0; i < c.workerCount; i++ {
-		pageChan, errChan := getPages(c.httpClient, newURLs)
+		pageChan, errChan := c.getPages(ctx, client, s.newURLs[i%len(s.newURLs)], i, s.events, soft404s, sri)
 		pageChans = append(pageChans, pageChan)
 		errChans = append(errChans, errChan)
 	}
-	pageChan := mergePages(pageChans...)
-	errChan := mergeErrors(errChans...)
+	pageChan := mergePages(ctx, pageChans...)
+	errChan := mergeErrors(ctx, errChans...)
 
 	for {
 		select {
@@ -87,46 +681,41 @@ func (c *crawler) Crawl(rawURL string, out io.Writer) error {
 			if !ok {
 				return nil
 			}
-
-			if _, err := out.Write(page.Marshal()); err != nil {
+			if err := s.handlePage(page, sink); err != nil {
 				return err
 			}
-
-			for _, link := range page.Links {
-				if link.Hostname() == seedURL.Hostname() {
-					if _, ok := cache[link.String()]; !ok {
-						cache[link.String()] = struct{}{}
-
-						wg.Add(1)
-						go func(newURL *url.URL) {
-							newURLs <- newURL
-						}(link)
-					}
-				}
-			}
-
-			wg.Done()
 		case err, ok := <-errChan:
 			if !ok {
 				return nil
 			}
-
-			if errors.Cause(err) == ErrHttpStatusCode {
-				fmt.Fprintln(os.Stderr, err)
-				wg.Done()
-				break
-			}
-			if err, ok := err.(net.Error); ok && err.Timeout() {
-				fmt.Fprintln(os.Stderr, err)
-				wg.Done()
-				break
+			if err := s.handleError(err); err != nil {
+				return err
 			}
-			return err
+		case u := <-s.outscored:
+			s.skipOutscored(u)
+		case <-tick:
+			c.progress(progress.snapshot())
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
-func getPages(httpClient httpClient, urls <-chan *url.URL) (<-chan *Page, <-chan error) {
+// collect adds the summary and report of a crawl to those given with WithSummary and WithReport
+func (c *crawler) collect(summary *Summary, report *Report) {
+	c.collectMu.Lock()
+	defer c.collectMu.Unlock()
+
+	if c.summary != nil {
+		c.summary.add(summary)
+	}
+	if c.report != nil {
+		report.Summary = *summary
+		c.report.add(report)
+	}
+}
+
+func (c *crawler) getPages(ctx context.Context, httpClient httpClient, urls <-chan *url.URL, worker int, events *eventBus, soft404s *soft404Detector, sri *sriVerifier) (<-chan *Page, <-chan error) {
 	pages := make(chan *Page)
 	errs := make(chan error)
 
@@ -134,76 +723,477 @@ func getPages(httpClient httpClient, urls <-chan *url.URL) (<-chan *Page, <-chan
 		defer close(pages)
 		defer close(errs)
 
-		for url := range urls {
-			resp, err := httpClient.Get(url.String())
+		// send delivers a page or error to the crawl, reporting false if the crawl has ended and the worker should stop
+		send := func(page *Page, err error) bool {
 			if err != nil {
-				errs <- err
-				continue
+				select {
+				case errs <- err:
+					return true
+				case <-ctx.Done():
+					return false
+				}
 			}
-
-			if resp.StatusCode >= 400 {
-				errs <- errors.Wrapf(ErrHttpStatusCode, "%s returned status code: %d", url, resp.StatusCode)
-				continue
+			select {
+			case pages <- page:
+				return true
+			case <-ctx.Done():
+				return false
 			}
+		}
 
-			var buf bytes.Buffer
-			if _, err := io.Copy(&buf, resp.Body); err != nil {
-				errs <- err
-				continue
+		for {
+			var url *url.URL
+			select {
+			case u, ok := <-urls:
+				if !ok {
+					return
+				}
+				url = u
+			case <-ctx.Done():
+				return
 			}
-
-			if err := resp.Body.Close(); err != nil {
-				errs <- err
-				continue
+			if !c.gate.wait(ctx) || !c.jitter(ctx) {
+				return
+			}
+			release, ok := c.politeness.acquire(ctx, url)
+			if !ok {
+				return
+			}
+			page, err := c.getPage(ctx, httpClient, url, worker, events, soft404s, sri)
+			release()
+			if page != nil && !page.filtered && soft404s != nil {
+				// once the page's request is over, as probing its host may be another request to the same origin
+				page.Soft404 = soft404s.detect(ctx, page, page.body)
+				if c.mirrorDir == "" {
+					page.body = nil
+				}
+			}
+			if !send(page, err) {
+				return
 			}
-
-			pages <- &Page{URL: url, Links: collectLinks(url, &buf)}
 		}
 	}(pages, errs)
 
 	return pages, errs
 }
 
-// collectLinks collects and formats each anchor tag link found on a web page
-func collectLinks(pageURL *url.URL, r io.Reader) []*url.URL {
-	links := []*url.URL{}
+// getPage fetches and reads a page, returning a FetchError if it couldn't be fetched or responded with an HTTP error
+// status code
+func (c *crawler) getPage(ctx context.Context, httpClient httpClient, url *url.URL, worker int, events *eventBus, soft404s *soft404Detector, sri *sriVerifier) (*Page, error) {
+	events.publish(FetchStarted{URL: url, Worker: worker})
+	start := time.Now()
+	resp, err := c.fetch(ctx, httpClient, url)
+	if err != nil {
+		events.publish(FetchCompleted{URL: url, Worker: worker, Duration: time.Since(start), Err: err})
+		return nil, &FetchError{URL: url, Err: err}
+	}
 
-	t := html.NewTokenizer(r)
-	for {
-		if tkn := t.Next(); tkn == html.ErrorToken {
-			return links
+	if resp.StatusCode >= 400 {
+		resp.Body.Close()
+		events.publish(FetchCompleted{URL: url, Worker: worker, StatusCode: resp.StatusCode, Duration: time.Since(start)})
+		return nil, &FetchError{URL: url, StatusCode: resp.StatusCode, Err: errors.Wrapf(ErrHttpStatusCode, "%s returned status code: %d", url, resp.StatusCode)}
+	}
+
+	page, err := c.readPage(ctx, url, resp, start, worker, events, soft404s, sri)
+	if err != nil {
+		return nil, err
+	}
+	c.audit(ctx, page, sri)
+	return page, nil
+}
+
+// audit records the results of the checks enabled on a page, from what parsePage collected of it
+func (c *crawler) audit(ctx context.Context, page *Page, sri *sriVerifier) {
+	if c.accessibility {
+		page.Accessibility = page.accessibility
+	}
+	if c.anchorText {
+		page.AnchorTexts = page.anchorTexts
+	}
+	if c.images {
+		page.Images = page.images
+	}
+	if c.mixedContent {
+		page.MixedContent = findMixedContent(page)
+	}
+	if c.forms {
+		page.Forms = findForms(page)
+	}
+	if c.insecureForms {
+		page.InsecureForms = findInsecureForms(page)
+	}
+	if c.thirdParty {
+		page.ThirdParty = findThirdParty(page)
+	}
+	if len(c.trackers) > 0 {
+		page.Trackers = findTrackers(page, c.trackers)
+	}
+	if c.contacts {
+		page.Contacts = page.contacts
+	}
+	if c.sriCheck {
+		page.Subresources = page.subresources
+		if sri != nil {
+			sri.verify(ctx, page.Subresources)
 		}
+	}
+}
+
+// readPage reads and parses the body of a successful response. The body is parsed as it's read unless response
+// filters, search, extraction rules or soft 404 detection need all of it at once, so a worker only holds a whole page
+// in memory if it must.
+func (c *crawler) readPage(ctx context.Context, url *url.URL, resp *http.Response, start time.Time, worker int, events *eventBus, soft404s *soft404Detector, sri *sriVerifier) (*Page, error) {
+	defer resp.Body.Close()
+
+	page := &Page{
+		URL:         url,
+		StatusCode:  resp.StatusCode,
+		Headers:     c.selectHeaders(resp.Header),
+		contentType: resp.Header.Get("Content-Type"),
+	}
+	if resp.Request != nil && resp.Request.URL.String() != url.String() {
+		page.RedirectedTo = resp.Request.URL
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if location, err := resp.Location(); err == nil {
+			page.Location = location
+		}
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		page.LastModified = lastModified
+	}
+	if c.http3 {
+		page.Protocol = resp.Proto
+	}
+	if len(c.userAgents) > 0 && resp.Request != nil {
+		page.UserAgent = resp.Request.Header.Get("User-Agent")
+	}
+	if c.cookieAudit {
+		page.Cookies = responseCookies(resp)
+	}
+	applyRobotsHeaders(page, resp.Header)
+
+	r := io.Reader(resp.Body)
+	if c.maxBodySize > 0 {
+		r = io.LimitReader(r, c.maxBodySize)
+	}
+	hash := sha256.New()
+	body := &countingReader{r: io.TeeReader(r, hash)}
+	fetched := func() error {
+		page.ContentLength = body.n
+		page.FetchDuration = time.Since(start)
+		page.ContentHash = hex.EncodeToString(hash.Sum(nil))
+		events.publish(FetchCompleted{URL: url, Worker: worker, StatusCode: resp.StatusCode, ContentLength: body.n, Duration: page.FetchDuration, Err: body.err})
+		if body.err != nil {
+			return &FetchError{URL: url, Err: body.err}
+		}
+		return nil
+	}
+
+	if len(c.responseFilters) == 0 && len(c.searchPatterns) == 0 && len(c.extractors) == 0 && soft404s == nil && c.mirrorDir == "" {
+		parsePage(page, body, c.linkOpts...)
+		// the tokenizer stops at the first error, so make sure the rest of the body is hashed and counted
+		io.Copy(io.Discard, body)
+		if err := fetched(); err != nil {
+			return nil, err
+		}
+		return page, nil
+	}
 
-		tag := t.Token()
-		if tag.Data == "a" {
-			for _, attr := range tag.Attr {
-				if attr.Key == "href" {
-					if link := formatURL(pageURL, attr.Val); link != nil {
-						links = append(links, link)
-						continue
+	var buf bytes.Buffer
+	buf.ReadFrom(body)
+	if err := fetched(); err != nil {
+		return nil, err
+	}
+
+	if process, err := c.filterResponse(resp, buf.Bytes()); err != nil {
+		return nil, &FetchError{URL: url, StatusCode: resp.StatusCode, Err: err}
+	} else if !process {
+		return &Page{URL: url, StatusCode: resp.StatusCode, filtered: true}, nil
+	}
+
+	if len(c.searchPatterns) > 0 {
+		page.Matches = search(buf.Bytes(), c.searchPatterns)
+	}
+	if len(c.extractors) > 0 {
+		page.Fields = extract(url, buf.Bytes(), c.extractors)
+	}
+	parsePage(page, bytes.NewReader(buf.Bytes()), c.linkOpts...)
+	if soft404s != nil || c.mirrorDir != "" {
+		page.body = buf.Bytes()
+	}
+	return page, nil
+}
+
+// countingReader counts the bytes read from r and records the first error other than io.EOF, so that a body can be
+// read by something which doesn't report errors, e.g. the html tokenizer
+type countingReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// fetch requests a URL, applying the request hooks first, which may replace its User-Agent
+func (c *crawler) fetch(ctx context.Context, httpClient httpClient, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if userAgent := c.userAgentFor(u); userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	for _, hook := range c.requestHooks {
+		if err := hook(req); err != nil {
+			return nil, errors.Wrap(err, "request hook")
+		}
+	}
+	resp, err := httpClient.Do(req)
+	if redirectErr, ok := redirectError(err); ok {
+		// the client's error only repeats the URL requested, the chain is what matters
+		return nil, redirectErr
+	}
+	return resp, err
+}
+
+// selectHeaders returns the captured subset of a response's headers, or nil if none are configured or present
+func (c *crawler) selectHeaders(header http.Header) http.Header {
+	var selected http.Header
+	for _, name := range c.captureHeaders {
+		if values, ok := header[name]; ok {
+			if selected == nil {
+				selected = http.Header{}
+			}
+			selected[name] = values
+		}
+	}
+	return selected
+}
+
+// parsePage tokenizes a web page in a single pass, collecting and formatting each anchor tag link, its title and
+// description, detecting the page's language from its html lang attribute, falling back to a guess from its text,
+// making the accessibility checks and collecting the text of its links, its images, and the assets, forms and contact
+// information the audits check
+func parsePage(page *Page, r io.Reader, linkOpts ...linkextract.Option) {
+	page.Links = []*url.URL{}
+	links := linkextract.New(page.base(), linkOpts...)
+	// the audits resolve URLs against any base href, whether or not the links are
+	assets := linkextract.New(page.base(), linkextract.WithElements(thirdPartyElements), linkextract.WithBaseHref())
+	a11y := newAccessibilityChecker(links)
+	anchorTexts := newAnchorTextCollector(links)
+	contacts := newContactCollector(page.base())
+	anchors := map[string]bool{}
+	var lang languageDetector
+	inScript, inTitle := false, false
+
+	t := html.NewTokenizer(r)
+	for {
+		switch t.Next() {
+		case html.ErrorToken:
+			if page.Language == "" {
+				page.Language = lang.detect()
+			}
+			page.accessibility = a11y.finish()
+			page.anchorTexts = anchorTexts.finish()
+			page.contacts = contacts.finish()
+			if a11y.document {
+				page.anchors = anchors
+			}
+			return
+		case html.TextToken:
+			// the text can only be read once
+			text := string(t.Text())
+			if inTitle && page.Title == "" {
+				page.Title = strings.Join(strings.Fields(text), " ")
+			}
+			if !inScript {
+				lang.addText(text)
+				a11y.text(text)
+				anchorTexts.addText(text)
+				contacts.addText(text)
+			}
+		case html.EndTagToken:
+			inScript, inTitle = false, false
+			name, _ := t.TagName()
+			a11y.endTag(string(name))
+			anchorTexts.endTag(string(name))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag := t.Token()
+			a11y.startTag(tag)
+			anchorTexts.startTag(tag)
+			collectImage(page, links, tag)
+			collectAnchors(page, links, anchors, tag)
+			pageAssets, _ := assets.Token(tag)
+			page.assets = append(page.assets, pageAssets...)
+			collectForm(page, assets, tag)
+			collectSubresource(page, assets, tag)
+			contacts.startTag(tag)
+			switch tag.Data {
+			case "script", "style":
+				inScript = tag.Type == html.StartTagToken
+			case "title":
+				inTitle = tag.Type == html.StartTagToken
+			case "html":
+				page.Language = normalizeLanguage(attrVal(tag, "lang"))
+			case "meta":
+				switch strings.ToLower(attrVal(tag, "name")) {
+				case "robots":
+					applyRobotsDirectives(page, attrVal(tag, "content"))
+				case "description":
+					if page.Description == "" {
+						page.Description = strings.Join(strings.Fields(attrVal(tag, "content")), " ")
+					}
+				}
+				if strings.ToLower(attrVal(tag, "http-equiv")) == "refresh" && page.Refresh == nil {
+					if target := metaRefreshURL(attrVal(tag, "content")); target != "" {
+						page.Refresh, _ = links.Resolve(target)
 					}
 				}
+			case "link":
+				collectPagination(page, links, tag)
+				collectAlternates(page, links, tag)
+			case "a":
+				collectPagination(page, links, tag)
+			}
+
+			found, malformed := links.Token(tag)
+			for _, link := range found {
+				page.Links = append(page.Links, link.URL)
+			}
+			for _, err := range malformed {
+				page.malformedLinks = append(page.malformedLinks, err)
 			}
 		}
 	}
 }
 
-// formatURL formats a url relative to the page which it links from and strips the query fragment if found.
-func formatURL(pageURL *url.URL, rawURL string) *url.URL {
-	rel, err := pageURL.Parse(rawURL)
-	if err != nil {
-		panic(err)
+// metaRefreshURL returns the URL of a meta refresh's content, e.g. "0; url='/new'", or an empty string if it only
+// reloads the page
+func metaRefreshURL(content string) string {
+	// the delay is separated from the URL by a semicolon or comma, and the URL may be prefixed by "url="
+	i := strings.IndexAny(content, ";,")
+	if i < 0 {
+		return ""
+	}
+	target := strings.TrimSpace(content[i+1:])
+	if len(target) >= 3 && strings.EqualFold(target[:3], "url") {
+		if rest := strings.TrimSpace(target[3:]); strings.HasPrefix(rest, "=") {
+			target = strings.TrimSpace(rest[1:])
+		}
 	}
-	if rel.Scheme == "http" || rel.Scheme == "https" {
-		rel.Fragment = "" // strip anchors to avoid crawling the same page twice...
-		return rel
+	if len(target) >= 2 && (target[0] == '\'' || target[0] == '"') {
+		if end := strings.IndexByte(target[1:], target[0]); end >= 0 {
+			target = target[1 : end+1]
+		} else {
+			target = target[1:]
+		}
 	}
+	return target
+}
 
-	return nil
+// collectAnchors records the id of an element, or the name of an anchor, and the target and fragment of a link with
+// a fragment
+func collectAnchors(page *Page, links *linkextract.Extractor, anchors map[string]bool, tag html.Token) {
+	if id := attrVal(tag, "id"); id != "" {
+		anchors[id] = true
+	}
+	if tag.Data != "a" && tag.Data != "area" {
+		return
+	}
+	if name := attrVal(tag, "name"); name != "" && tag.Data == "a" {
+		anchors[name] = true
+	}
+	href := attrVal(tag, "href")
+	i := strings.Index(href, "#")
+	if i < 0 {
+		return
+	}
+	target, err := links.Resolve(href)
+	if err != nil || target == nil {
+		return
+	}
+	target.Fragment, target.RawFragment = "", ""
+	fragment := href[i+1:]
+	if unescaped, err := url.PathUnescape(fragment); err == nil {
+		fragment = unescaped
+	}
+	page.fragmentLinks = append(page.fragmentLinks, fragmentLink{target: target, fragment: fragment})
 }
 
-// merge fans in zero or more page channels in to a single page channel
-func mergePages(pageChans ...<-chan *Page) <-chan *Page {
+// collectAlternates records the AMP version of a page and its other versions linked to with rel="alternate", except
+// for those of another content type, e.g. feeds
+func collectAlternates(page *Page, links *linkextract.Extractor, tag html.Token) {
+	href := attrVal(tag, "href")
+	if href == "" {
+		return
+	}
+	for _, rel := range strings.Fields(strings.ToLower(attrVal(tag, "rel"))) {
+		switch {
+		case rel == "amphtml" && page.AMP == nil:
+			page.AMP, _ = links.Resolve(href)
+		case rel == "alternate":
+			if typ := strings.ToLower(attrVal(tag, "type")); typ != "" && typ != "text/html" {
+				return
+			}
+			if alternate, _ := links.Resolve(href); alternate != nil {
+				page.Alternates = append(page.Alternates, alternate)
+			}
+		}
+	}
+}
+
+// collectPagination records the first rel="next" and rel="prev" links found on a page
+func collectPagination(page *Page, links *linkextract.Extractor, tag html.Token) {
+	href := attrVal(tag, "href")
+	if href == "" {
+		return
+	}
+	for _, rel := range strings.Fields(strings.ToLower(attrVal(tag, "rel"))) {
+		switch {
+		case rel == "next" && page.Next == nil:
+			page.Next, _ = links.Resolve(href)
+		case (rel == "prev" || rel == "previous") && page.Prev == nil:
+			page.Prev, _ = links.Resolve(href)
+		}
+	}
+}
+
+// attrVal returns the value of a tag's attribute, or an empty string if it isn't set
+func attrVal(tag html.Token, key string) string {
+	for _, attr := range tag.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// normalize applies each URL normalizer in turn to a copy of u, returning nil if any of them drop the URL
+func (c *crawler) normalize(u *url.URL) *url.URL {
+	if len(c.normalizers) == 0 {
+		return u
+	}
+
+	copied := *u
+	u = &copied
+	for _, normalize := range c.normalizers {
+		if u = normalize(u); u == nil {
+			return nil
+		}
+	}
+	return u
+}
+
+// merge fans in zero or more page channels in to a single page channel, until ctx is done
+func mergePages(ctx context.Context, pageChans ...<-chan *Page) <-chan *Page {
 	var wg sync.WaitGroup
 	out := make(chan *Page)
 
@@ -213,7 +1203,11 @@ func mergePages(pageChans ...<-chan *Page) <-chan *Page {
 			defer wg.Done()
 
 			for page := range pageChan {
-				out <- page
+				select {
+				case out <- page:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}(pageChan)
 	}
@@ -226,8 +1220,8 @@ func mergePages(pageChans ...<-chan *Page) <-chan *Page {
 	return out
 }
 
-// merge fans in zero or more error channels in to a single error channel
-func mergeErrors(errChans ...<-chan error) <-chan error {
+// merge fans in zero or more error channels in to a single error channel, until ctx is done
+func mergeErrors(ctx context.Context, errChans ...<-chan error) <-chan error {
 	var wg sync.WaitGroup
 	out := make(chan error)
 
@@ -237,7 +1231,11 @@ func mergeErrors(errChans ...<-chan error) <-chan error {
 			defer wg.Done()
 
 			for err := range errChan {
-				out <- err
+				select {
+				case out <- err:
+				case <-ctx.Done():
+					return
+				}
 			}
 		}(errChan, out)
 	}
@@ -5,6 +5,7 @@
 package crawler
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	io "io"
 	http "net/http"
@@ -34,17 +35,17 @@ func (m *MockhttpClient) EXPECT() *MockhttpClientMockRecorder {
 	return m.recorder
 }
 
-// Get mocks base method
-func (m *MockhttpClient) Get(arg0 string) (*http.Response, error) {
-	ret := m.ctrl.Call(m, "Get", arg0)
+// Do mocks base method
+func (m *MockhttpClient) Do(arg0 *http.Request) (*http.Response, error) {
+	ret := m.ctrl.Call(m, "Do", arg0)
 	ret0, _ := ret[0].(*http.Response)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Get indicates an expected call of Get
-func (mr *MockhttpClientMockRecorder) Get(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockhttpClient)(nil).Get), arg0)
+// Do indicates an expected call of Do
+func (mr *MockhttpClientMockRecorder) Do(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockhttpClient)(nil).Do), arg0)
 }
 
 // MockCrawler is a mock of Crawler interface
@@ -71,14 +72,71 @@ func (m *MockCrawler) EXPECT() *MockCrawlerMockRecorder {
 }
 
 // Crawl mocks base method
-func (m *MockCrawler) Crawl(arg0 string) (io.Writer, error) {
-	ret := m.ctrl.Call(m, "Crawl", arg0)
-	ret0, _ := ret[0].(io.Writer)
+func (m *MockCrawler) Crawl(arg0 string, arg1 io.Writer) error {
+	ret := m.ctrl.Call(m, "Crawl", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Crawl indicates an expected call of Crawl
+func (mr *MockCrawlerMockRecorder) Crawl(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Crawl", reflect.TypeOf((*MockCrawler)(nil).Crawl), arg0, arg1)
+}
+
+// CrawlAll mocks base method
+func (m *MockCrawler) CrawlAll(arg0 context.Context, arg1 []string, arg2 io.Writer) error {
+	ret := m.ctrl.Call(m, "CrawlAll", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CrawlAll indicates an expected call of CrawlAll
+func (mr *MockCrawlerMockRecorder) CrawlAll(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CrawlAll", reflect.TypeOf((*MockCrawler)(nil).CrawlAll), arg0, arg1, arg2)
+}
+
+// CrawlWithResult mocks base method
+func (m *MockCrawler) CrawlWithResult(arg0 context.Context, arg1 []string, arg2 io.Writer) (*CrawlResult, error) {
+	ret := m.ctrl.Call(m, "CrawlWithResult", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*CrawlResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Crawl indicates an expected call of Crawl
-func (mr *MockCrawlerMockRecorder) Crawl(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Crawl", reflect.TypeOf((*MockCrawler)(nil).Crawl), arg0)
+// CrawlWithResult indicates an expected call of CrawlWithResult
+func (mr *MockCrawlerMockRecorder) CrawlWithResult(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CrawlWithResult", reflect.TypeOf((*MockCrawler)(nil).CrawlWithResult), arg0, arg1, arg2)
+}
+
+// CrawlToSink mocks base method
+func (m *MockCrawler) CrawlToSink(arg0 context.Context, arg1 []string, arg2 Sink) (*CrawlResult, error) {
+	ret := m.ctrl.Call(m, "CrawlToSink", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*CrawlResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CrawlToSink indicates an expected call of CrawlToSink
+func (mr *MockCrawlerMockRecorder) CrawlToSink(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CrawlToSink", reflect.TypeOf((*MockCrawler)(nil).CrawlToSink), arg0, arg1, arg2)
+}
+
+// Pause mocks base method
+func (m *MockCrawler) Pause() {
+	m.ctrl.Call(m, "Pause")
+}
+
+// Pause indicates an expected call of Pause
+func (mr *MockCrawlerMockRecorder) Pause() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockCrawler)(nil).Pause))
+}
+
+// Resume mocks base method
+func (m *MockCrawler) Resume() {
+	m.ctrl.Call(m, "Resume")
+}
+
+// Resume indicates an expected call of Resume
+func (mr *MockCrawlerMockRecorder) Resume() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockCrawler)(nil).Resume))
 }
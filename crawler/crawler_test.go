@@ -2,16 +2,319 @@ package crawler
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
 	gomock "github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
+func TestCrawl(t *testing.T) {
+	t.Run("referrer", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":    {Links: []string{"/one", "/missing"}},
+			"/one": {Links: []string{"/"}},
+		})
+		defer srv.Close()
+
+		var out bytes.Buffer
+		c := New(2, srv.Client())
+		require.NoError(t, c.Crawl(srv.URL+"/", &out))
+
+		pages := strings.Split(out.String(), "URL:\n")
+		require.Len(t, pages, 3) // the seed is not crawled again when linked back to
+		for _, page := range pages[1:] {
+			if strings.HasPrefix(page, "\t"+srv.URL+"/one\n") {
+				require.Contains(t, page, "Referrer:\n\t"+srv.URL+"/\n")
+				require.Contains(t, page, "Links: \n\t"+srv.URL+"/\n")
+			}
+		}
+	})
+
+	t.Run("page budget", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><head><link rel="next" href="/page/2"></head><body><a href="/a"></a><a href="/b"></a></body></html>`)
+		})
+		mux.HandleFunc("/page/2", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><body><a rel="prev" href="/"></a><a rel="next" href="/page/3"></a></body></html>`)
+		})
+		mux.HandleFunc("/page/3", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><body><a rel="prev" href="/page/2"></a></body></html>`)
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		tests := []struct {
+			title    string
+			opts     []Option
+			expected int
+			limited  bool
+		}{
+			{"unlimited", nil, 5, false},
+			{"max pages", []Option{WithMaxPages(2)}, 2, true},
+			{"pagination priority", []Option{WithMaxPages(2), WithPaginationPriority()}, 4, true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				var out bytes.Buffer
+				summary := &Summary{}
+				c := New(2, srv.Client(), append(tt.opts, WithSummary(summary))...)
+				require.NoError(t, c.Crawl(srv.URL+"/", &out))
+				require.Equal(t, tt.expected, strings.Count(out.String(), "URL:\n"))
+				require.Equal(t, tt.limited, summary.Limited > 0)
+			})
+		}
+	})
+
+	t.Run("robots directives", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Robots-Tag", "noindex")
+			fmt.Fprint(w, `<html><body><a href="/nofollow"></a></body></html>`)
+		})
+		mux.HandleFunc("/nofollow", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><head><meta name="robots" content="nofollow"></head><body><a href="/hidden"></a></body></html>`)
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		tests := []struct {
+			title    string
+			opts     []Option
+			expected []string
+		}{
+			{"honoured", nil, []string{"/nofollow"}},
+			{"ignored", []Option{WithIgnoreRobotsDirectives()}, []string{"/", "/nofollow", "/hidden"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				var out bytes.Buffer
+				c := New(2, srv.Client(), tt.opts...)
+				require.NoError(t, c.Crawl(srv.URL+"/", &out))
+
+				urls := []string{}
+				for _, page := range strings.Split(out.String(), "URL:\n\t")[1:] {
+					urls = append(urls, strings.TrimPrefix(page[:strings.Index(page, "\n")], srv.URL))
+				}
+				require.ElementsMatch(t, tt.expected, urls)
+			})
+		}
+	})
+
+	t.Run("host aliases", func(t *testing.T) {
+		var srvURL *url.URL
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `<html><body><a href="http://localhost:%[1]s/"></a><a href="http://localhost:%[1]s/a"></a></body></html>`, srvURL.Port())
+		}))
+		defer srv.Close()
+		srvURL, _ = url.Parse(srv.URL)
+
+		tests := []struct {
+			title    string
+			opts     []Option
+			expected int
+		}{
+			{"without", nil, 1},
+			{"with", []Option{WithHostAliases(srvURL.Hostname(), "localhost")}, 2},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				var out bytes.Buffer
+				c := New(2, srv.Client(), tt.opts...)
+				require.NoError(t, c.Crawl(srv.URL+"/", &out))
+				require.Equal(t, tt.expected, strings.Count(out.String(), "URL:\n"))
+			})
+		}
+	})
+
+	t.Run("url normalizer", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><body><a href="/en/a"></a><a href="/fr/a"></a><a href="/b?session=1"></a><a href="/private"></a></body></html>`)
+		}))
+		defer srv.Close()
+
+		stripLocale := func(u *url.URL) *url.URL {
+			u.Path = strings.TrimPrefix(strings.TrimPrefix(u.Path, "/en"), "/fr")
+			return u
+		}
+		stripSession := func(u *url.URL) *url.URL {
+			u.RawQuery = ""
+			return u
+		}
+		dropPrivate := func(u *url.URL) *url.URL {
+			if u.Path == "/private" {
+				return nil
+			}
+			return u
+		}
+
+		var out bytes.Buffer
+		c := New(2, srv.Client(), WithURLNormalizer(stripLocale), WithURLNormalizer(stripSession), WithURLNormalizer(dropPrivate))
+		require.NoError(t, c.Crawl(srv.URL+"/", &out))
+
+		urls := []string{}
+		for _, page := range strings.Split(out.String(), "URL:\n\t")[1:] {
+			urls = append(urls, strings.TrimPrefix(page[:strings.Index(page, "\n")], srv.URL))
+		}
+		require.ElementsMatch(t, []string{"/", "/a", "/b"}, urls)
+	})
+
+	t.Run("seed template", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><body><a href="/a"></a></body></html>`)
+		}))
+		defer srv.Close()
+
+		var out bytes.Buffer
+		c := New(2, srv.Client())
+		require.NoError(t, c.Crawl(srv.URL+"/{a,b,c}", &out))
+		require.Equal(t, 3, strings.Count(out.String(), "URL:\n"))
+	})
+
+	t.Run("additional seeds", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><body><a href="/a"></a></body></html>`)
+		}))
+		defer srv.Close()
+
+		var out bytes.Buffer
+		c := New(2, srv.Client(), WithSeeds(srv.URL+"/b", srv.URL+"/a", srv.URL+"/{c,d}"))
+		require.NoError(t, c.Crawl(srv.URL+"/a", &out))
+		require.Equal(t, 4, strings.Count(out.String(), "URL:\n"))
+	})
+	t.Run("offsite depth", func(t *testing.T) {
+		partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<html><head><title>Partner</title></head><body><a href="/more"></a></body></html>`)
+		}))
+		defer partner.Close()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `<html><body><a href="/a"></a><a href="%s/"></a></body></html>`, strings.Replace(partner.URL, "127.0.0.1", "localhost", 1))
+		}))
+		defer srv.Close()
+
+		tests := []struct {
+			title    string
+			opts     []Option
+			expected int
+		}{
+			{"none", nil, 2},
+			{"one hop", []Option{WithOffsiteDepth(1)}, 3},
+			{"two hops", []Option{WithOffsiteDepth(2)}, 4},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				var out bytes.Buffer
+				c := New(2, srv.Client(), tt.opts...)
+				require.NoError(t, c.Crawl(srv.URL+"/", &out))
+				require.Equal(t, tt.expected, strings.Count(out.String(), "URL:\n"))
+				if tt.expected > 2 {
+					require.Contains(t, out.String(), "Title:\n\tPartner\nOffsiteHops:\n\t1\n")
+				}
+			})
+		}
+	})
+
+	t.Run("request hook", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `<html><body><a href="/a"></a></body></html>`)
+		}))
+		defer srv.Close()
+
+		var out bytes.Buffer
+		c := New(2, srv.Client(), WithRequestHook(func(r *http.Request) error {
+			r.Header.Set("Authorization", "secret")
+			return nil
+		}))
+		require.NoError(t, c.Crawl(srv.URL+"/", &out))
+		require.Equal(t, 2, strings.Count(out.String(), "URL:\n"))
+
+		hookErr := errors.New("no credentials")
+		c = New(2, srv.Client(), WithRequestHook(func(r *http.Request) error {
+			return hookErr
+		}))
+		require.Equal(t, hookErr, errors.Cause(c.Crawl(srv.URL+"/", &out)))
+	})
+
+	t.Run("meta refresh", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":    {Body: `<html><head><meta http-equiv="refresh" content="0;url=/new"></head></html>`},
+			"/new": {},
+		})
+		defer srv.Close()
+
+		var out bytes.Buffer
+		require.NoError(t, New(1, srv.Client()).Crawl(srv.URLFor("/"), &out))
+		require.Contains(t, out.String(), "Refresh:\n\t"+srv.URLFor("/new")+"\n")
+		require.Equal(t, 0, srv.Requests("/new"))
+
+		out.Reset()
+		require.NoError(t, New(1, srv.Client(), WithFollowMetaRefresh()).Crawl(srv.URLFor("/"), &out))
+		require.Equal(t, 1, srv.Requests("/new"))
+		require.Contains(t, out.String(), "URL:\n\t"+srv.URLFor("/new")+"\nReferrer:\n\t"+srv.URLFor("/")+"\n")
+	})
+
+	t.Run("malformed links", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":    {Links: []string{"http://[::1", "/%zz", "/one"}},
+			"/one": {Links: []string{"http://www.te st.com/"}},
+		})
+		defer srv.Close()
+
+		skipped := []URLSkipped{}
+		var out bytes.Buffer
+		c := New(2, srv.Client(), WithLogger(newTestLogger(io.Discard)), WithSubscriber(func(e Event) {
+			if e, ok := e.(URLSkipped); ok && e.Reason == SkipMalformed {
+				skipped = append(skipped, e)
+			}
+		}))
+		require.NoError(t, c.Crawl(srv.URLFor("/"), &out))
+		require.Equal(t, 2, strings.Count(out.String(), "URL:\n"))
+		require.Len(t, skipped, 3)
+		for _, e := range skipped {
+			require.Nil(t, e.URL)
+			require.NotNil(t, e.Referrer)
+			require.Error(t, e.Err)
+		}
+	})
+}
+
+func TestFetchError(t *testing.T) {
+	pageURL, err := url.Parse("http://www.google.com/missing")
+	require.NoError(t, err)
+	referrer, err := url.Parse("http://www.google.com")
+	require.NoError(t, err)
+
+	fetchErr := &FetchError{URL: pageURL, Err: errors.Wrap(ErrHttpStatusCode, "404")}
+	require.Equal(t, ErrHttpStatusCode, errors.Cause(fetchErr))
+	require.Equal(t, "404: received HTTP error status code", fetchErr.Error())
+
+	fetchErr.Referrer = referrer
+	require.Equal(t, "404: received HTTP error status code (linked from http://www.google.com)", fetchErr.Error())
+}
+
 func TestGetPages(t *testing.T) {
 	dummyURL, err := url.Parse("http://www.google.com")
 	require.NoError(t, err)
@@ -19,10 +322,10 @@ func TestGetPages(t *testing.T) {
 	t.Run("http client error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		mockHTTPClient := NewMockhttpClient(ctrl)
-		mockHTTPClient.EXPECT().Get(dummyURL.String()).Return(nil, errors.New("error"))
+		mockHTTPClient.EXPECT().Do(requestFor(dummyURL.String())).Return(nil, errors.New("error"))
 
 		URLChan := make(chan *url.URL)
-		pageChan, errChan := getPages(mockHTTPClient, URLChan)
+		pageChan, errChan := (&crawler{}).getPages(context.Background(), mockHTTPClient, URLChan, 0, newEventBus(), nil, nil)
 
 		URLChan <- dummyURL
 		close(URLChan)
@@ -46,7 +349,7 @@ func TestGetPages(t *testing.T) {
 		for _, code := range errCodes {
 			ctrl := gomock.NewController(t)
 			mockHTTPClient := NewMockhttpClient(ctrl)
-			mockHTTPClient.EXPECT().Get(dummyURL.String()).Return(
+			mockHTTPClient.EXPECT().Do(requestFor(dummyURL.String())).Return(
 				&http.Response{
 					StatusCode: code,
 					Body:       ioutil.NopCloser(&bytes.Buffer{}),
@@ -55,7 +358,7 @@ func TestGetPages(t *testing.T) {
 			)
 
 			URLChan := make(chan *url.URL)
-			pageChan, errChan := getPages(mockHTTPClient, URLChan)
+			pageChan, errChan := (&crawler{}).getPages(context.Background(), mockHTTPClient, URLChan, 0, newEventBus(), nil, nil)
 
 			URLChan <- dummyURL
 			close(URLChan)
@@ -75,30 +378,28 @@ func TestGetPages(t *testing.T) {
 	})
 
 	t.Run("success", func(t *testing.T) {
+		body := `
+			<html>
+				<body>
+					<h1>Test</h1>
+					<a href="http://www.test.com"></a>
+					<a href="test"></a>
+				</body>
+			</html>
+		`
+
 		ctrl := gomock.NewController(t)
 		mockHTTPClient := NewMockhttpClient(ctrl)
-		mockHTTPClient.EXPECT().Get(dummyURL.String()).Return(
+		mockHTTPClient.EXPECT().Do(requestFor(dummyURL.String())).Return(
 			&http.Response{
 				StatusCode: 200,
-				Body: ioutil.NopCloser(
-					bytes.NewBufferString(
-						`
-							<html>
-								<body>
-									<h1>Test</h1>
-									<a href="http://www.test.com"></a>
-									<a href="test"></a>
-								</body>
-							</html>
-						`,
-					),
-				),
+				Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
 			},
 			nil,
 		)
 
 		URLChan := make(chan *url.URL)
-		pageChan, errChan := getPages(mockHTTPClient, URLChan)
+		pageChan, errChan := (&crawler{}).getPages(context.Background(), mockHTTPClient, URLChan, 0, newEventBus(), nil, nil)
 
 		URLChan <- dummyURL
 		close(URLChan)
@@ -106,6 +407,11 @@ func TestGetPages(t *testing.T) {
 		result, ok := <-pageChan
 		require.True(t, ok)
 		require.Equal(t, dummyURL, result.URL)
+		require.Equal(t, 200, result.StatusCode)
+		require.Equal(t, int64(len(body)), result.ContentLength)
+		require.True(t, result.FetchDuration > 0)
+		hash := sha256.Sum256([]byte(body))
+		require.Equal(t, hex.EncodeToString(hash[:]), result.ContentHash)
 
 		links := []string{}
 		for _, link := range result.Links {
@@ -118,109 +424,388 @@ func TestGetPages(t *testing.T) {
 
 		ctrl.Finish()
 	})
+
+	t.Run("max body size", func(t *testing.T) {
+		body := `<html><body><a href="/kept"></a><a href="/truncated"></a></body></html>`
+
+		ctrl := gomock.NewController(t)
+		mockHTTPClient := NewMockhttpClient(ctrl)
+		mockHTTPClient.EXPECT().Do(requestFor(dummyURL.String())).Return(
+			&http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))},
+			nil,
+		)
+
+		URLChan := make(chan *url.URL)
+		pageChan, _ := (&crawler{maxBodySize: 40}).getPages(context.Background(), mockHTTPClient, URLChan, 0, newEventBus(), nil, nil)
+
+		URLChan <- dummyURL
+		close(URLChan)
+
+		result := <-pageChan
+		require.Equal(t, int64(40), result.ContentLength)
+		hash := sha256.Sum256([]byte(body[:40]))
+		require.Equal(t, hex.EncodeToString(hash[:]), result.ContentHash)
+		require.Len(t, result.Links, 1)
+
+		ctrl.Finish()
+	})
+
+	t.Run("body read error", func(t *testing.T) {
+		readErr := errors.New("connection reset")
+
+		ctrl := gomock.NewController(t)
+		mockHTTPClient := NewMockhttpClient(ctrl)
+		mockHTTPClient.EXPECT().Do(requestFor(dummyURL.String())).Return(
+			&http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(io.MultiReader(strings.NewReader("<html><body>"), &errReader{readErr})),
+			},
+			nil,
+		)
+
+		URLChan := make(chan *url.URL)
+		pageChan, errChan := (&crawler{}).getPages(context.Background(), mockHTTPClient, URLChan, 0, newEventBus(), nil, nil)
+
+		URLChan <- dummyURL
+		close(URLChan)
+
+		err, ok := <-errChan
+		require.True(t, ok)
+		require.Equal(t, readErr, err.(*FetchError).Err)
+
+		_, ok = <-pageChan
+		require.False(t, ok)
+
+		ctrl.Finish()
+	})
 }
 
-func TestCollectLinks(t *testing.T) {
-	dummyURL, err := url.Parse("http://www.google.com")
-	require.NoError(t, err)
+// errReader fails every read with err
+type errReader struct {
+	err error
+}
 
-	tests := []struct {
-		title, html string
-		expected    []string
-	}{
-		{
-			"empty",
-			"",
-			[]string{},
-		},
-		{
-			"no links",
-			`<html><body><h1>test</h1></body></html>`,
-			[]string{},
-		},
-		{
-			"single",
-			`<html><body><a href="test"></a></body></html>`,
-			[]string{"http://www.google.com/test"},
-		},
-		{
-			"multiple",
-			`<html><body><a href="test1"></a><a href="test2"></a></body></html>`,
-			[]string{"http://www.google.com/test1", "http://www.google.com/test2"},
-		},
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestSelectHeaders(t *testing.T) {
+	header := http.Header{
+		"Cache-Control": []string{"no-cache"},
+		"Content-Type":  []string{"text/html"},
+		"Set-Cookie":    []string{"a=1", "b=2"},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.title, func(t *testing.T) {
-			result := collectLinks(dummyURL, bytes.NewBufferString(tt.html))
-			require.Equal(t, len(tt.expected), len(result))
+	t.Run("none configured", func(t *testing.T) {
+		require.Nil(t, New(1, nil).(*crawler).selectHeaders(header))
+	})
 
-			urls := []string{}
-			for _, r := range result {
-				urls = append(urls, r.String())
-			}
-			require.ElementsMatch(t, tt.expected, urls)
-		})
-	}
+	t.Run("configured", func(t *testing.T) {
+		c := New(1, nil, WithCaptureHeaders("content-type", "set-cookie", "x-missing")).(*crawler)
+		require.Equal(t, http.Header{
+			"Content-Type": []string{"text/html"},
+			"Set-Cookie":   []string{"a=1", "b=2"},
+		}, c.selectHeaders(header))
+	})
 }
 
-func TestFormatURL(t *testing.T) {
-	dummyURL, err := url.Parse("http://www.google.com/one/two")
+func TestParsePage(t *testing.T) {
+	dummyURL, err := url.Parse("http://www.google.com")
 	require.NoError(t, err)
 
-	t.Run("valid", func(t *testing.T) {
+	t.Run("links", func(t *testing.T) {
 		tests := []struct {
-			title, rawURL, expected string
+			title, html string
+			expected    []string
 		}{
 			{
-				"absolute",
-				"http://www.test.com",
-				"http://www.test.com",
-			},
-			{
-				"relative",
-				"test",
-				"http://www.google.com/one/test",
+				"empty",
+				"",
+				[]string{},
 			},
 			{
-				"relative parent",
-				"../../test",
-				"http://www.google.com/test",
+				"no links",
+				`<html><body><h1>test</h1></body></html>`,
+				[]string{},
 			},
 			{
-				"root",
-				"/test",
-				"http://www.google.com/test",
+				"single",
+				`<html><body><a href="test"></a></body></html>`,
+				[]string{"http://www.google.com/test"},
 			},
 			{
-				"anchor",
-				"#test",
-				"http://www.google.com/one/two",
+				"multiple",
+				`<html><body><a href="test1"></a><a href="test2"></a></body></html>`,
+				[]string{"http://www.google.com/test1", "http://www.google.com/test2"},
 			},
 		}
 
 		for _, tt := range tests {
 			t.Run(tt.title, func(t *testing.T) {
-				result := formatURL(dummyURL, tt.rawURL)
-				require.Equal(t, tt.expected, result.String())
+				page := &Page{URL: dummyURL}
+				parsePage(page, bytes.NewBufferString(tt.html))
+				require.Equal(t, len(tt.expected), len(page.Links))
+
+				urls := []string{}
+				for _, r := range page.Links {
+					urls = append(urls, r.String())
+				}
+				require.ElementsMatch(t, tt.expected, urls)
 			})
 		}
 	})
 
-	t.Run("invalid", func(t *testing.T) {
+	t.Run("pagination", func(t *testing.T) {
+		page := &Page{URL: dummyURL}
+		parsePage(page, bytes.NewBufferString(`
+			<html>
+				<head>
+					<link rel="prev" href="/page/1">
+					<link rel="next" href="/page/3">
+				</head>
+				<body><a rel="next nofollow" href="/page/4"></a></body>
+			</html>
+		`))
+		require.Equal(t, "http://www.google.com/page/1", page.Prev.String())
+		require.Equal(t, "http://www.google.com/page/3", page.Next.String())
+		require.Len(t, page.Links, 1)
+		require.Equal(t, "http://www.google.com/page/4", page.Links[0].String())
+	})
+
+	t.Run("language", func(t *testing.T) {
 		tests := []struct {
-			title, rawURL string
+			title, html, expected string
 		}{
 			{
-				"mailto",
-				"mailto:test@test.com",
+				"none",
+				`<html><body><h1>test</h1></body></html>`,
+				"",
+			},
+			{
+				"lang attribute",
+				`<html lang="en_GB"><body><p>Le chat est sur la table avec les enfants et des amis.</p></body></html>`,
+				"en-gb",
+			},
+			{
+				"text fallback",
+				`<html><body><p>Le chat est sur la table avec les enfants et des amis.</p></body></html>`,
+				"fr",
+			},
+			{
+				"scripts ignored",
+				`<html><body><script>var the = this; for (;;) { the.and = this.is.that }</script><p>hello</p></body></html>`,
+				"",
 			},
 		}
 
 		for _, tt := range tests {
 			t.Run(tt.title, func(t *testing.T) {
-				require.Nil(t, formatURL(dummyURL, tt.rawURL))
+				page := &Page{URL: dummyURL}
+				parsePage(page, bytes.NewBufferString(tt.html))
+				require.Equal(t, tt.expected, page.Language)
 			})
 		}
 	})
+
+	t.Run("title", func(t *testing.T) {
+		page := &Page{URL: dummyURL}
+		parsePage(page, bytes.NewBufferString(`<html><head><title>
+			Test   page
+		</title></head><body><svg><title>icon</title></svg></body></html>`))
+		require.Equal(t, "Test page", page.Title)
+	})
+
+	t.Run("description", func(t *testing.T) {
+		page := &Page{URL: dummyURL}
+		parsePage(page, bytes.NewBufferString(`<html><head>
+			<meta name="Description" content="  A test
+				page ">
+			<meta name="description" content="another">
+		</head></html>`))
+		require.Equal(t, "A test page", page.Description)
+	})
+
+	t.Run("alternates", func(t *testing.T) {
+		page := &Page{URL: dummyURL}
+		parsePage(page, bytes.NewBufferString(`<html><head>
+			<link rel="amphtml" href="/amp">
+			<link rel="amphtml" href="/amp2">
+			<link rel="alternate" media="only screen and (max-width: 640px)" href="http://m.google.com/">
+			<link rel="alternate" hreflang="fr" type="text/html" href="/fr">
+			<link rel="alternate" type="application/atom+xml" href="/feed">
+		</head></html>`))
+
+		require.Equal(t, "http://www.google.com/amp", page.AMP.String())
+		require.Len(t, page.Alternates, 2)
+		require.Equal(t, "http://m.google.com/", page.Alternates[0].String())
+		require.Equal(t, "http://www.google.com/fr", page.Alternates[1].String())
+		require.Empty(t, page.Links)
+	})
+
+	t.Run("meta refresh", func(t *testing.T) {
+		tests := []struct {
+			title, content, expected string
+		}{
+			{"url", `0;url=/new`, "http://www.google.com/new"},
+			{"spaces and case", `5 ; URL = /new`, "http://www.google.com/new"},
+			{"quoted", `0; url='/new page'`, "http://www.google.com/new%20page"},
+			{"comma", `0,/new`, "http://www.google.com/new"},
+			{"reload only", `30`, ""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				page := &Page{URL: dummyURL}
+				parsePage(page, bytes.NewBufferString(`<html><head><meta http-equiv="Refresh" content="`+tt.content+`"></head></html>`))
+				if tt.expected == "" {
+					require.Nil(t, page.Refresh)
+					return
+				}
+				require.Equal(t, tt.expected, page.Refresh.String())
+			})
+		}
+	})
+}
+
+// requestFor matches an *http.Request for the given URL
+type requestFor string
+
+func (u requestFor) Matches(x interface{}) bool {
+	req, ok := x.(*http.Request)
+	return ok && req.URL.String() == string(u)
+}
+
+func (u requestFor) String() string {
+	return "is a request for " + string(u)
+}
+
+// parsed parses body as the page's, returning the page
+func parsed(page *Page, body []byte) *Page {
+	parsePage(page, bytes.NewReader(body))
+	return page
+}
+
+func TestCrawlAll(t *testing.T) {
+	shop := crawltest.NewServer(crawltest.Site{
+		"/":       {Links: []string{"/basket"}},
+		"/basket": {Links: []string{"/"}},
+	})
+	defer shop.Close()
+	blog := crawltest.NewServer(crawltest.Site{
+		"/":     {Links: []string{"/post", shop.URLFor("/basket")}},
+		"/post": {},
+	})
+	defer blog.Close()
+
+	t.Run("several sites", func(t *testing.T) {
+		var out bytes.Buffer
+		c := New(2, http.DefaultClient, WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.CrawlAll(context.Background(), []string{shop.URLFor("/"), blog.URLFor("/")}, &out))
+
+		pages, err := ReadPages(&out)
+		require.NoError(t, err)
+		urls := []string{}
+		for _, page := range pages {
+			urls = append(urls, page.URL.String())
+		}
+		require.ElementsMatch(t, []string{shop.URLFor("/"), shop.URLFor("/basket"), blog.URLFor("/"), blog.URLFor("/post")}, urls)
+		require.Equal(t, 1, shop.Requests("/basket"), "links between the sites aren't crawled twice")
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		slow := crawltest.NewServer(crawltest.Site{
+			"/": {Latency: time.Second},
+		})
+		defer slow.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		c := New(1, http.DefaultClient, WithLogger(newTestLogger(io.Discard)))
+		start := time.Now()
+		require.Equal(t, context.DeadlineExceeded, c.CrawlAll(ctx, []string{slow.URLFor("/")}, io.Discard))
+		require.True(t, time.Since(start) < time.Second)
+	})
+
+	t.Run("no seeds", func(t *testing.T) {
+		require.Equal(t, ErrNoSeeds, New(1, http.DefaultClient).CrawlAll(context.Background(), nil, io.Discard))
+	})
+}
+
+func TestCrawlLeavesNothingRunning(t *testing.T) {
+	site := crawltest.Site{
+		"/": {Links: []string{"/a", "/b", "/c", "/d", "/e"}},
+	}
+	for _, path := range []string{"/a", "/b", "/c", "/d", "/e"} {
+		site[path] = crawltest.Page{Latency: time.Second}
+	}
+
+	// crawl runs a crawl of the site, returning the number of goroutines it left running and its error
+	crawl := func(ctx context.Context, out io.Writer) (int, error) {
+		before := runtime.NumGoroutine()
+		srv := crawltest.NewServer(site)
+		c := New(2, srv.Client(), WithLogger(newTestLogger(io.Discard)))
+		err := c.CrawlAll(ctx, []string{srv.URLFor("/")}, out)
+		srv.Close()
+
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if runtime.NumGoroutine() <= before {
+				break
+			}
+		}
+		return runtime.NumGoroutine() - before, err
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		left, err := crawl(ctx, io.Discard)
+		require.Equal(t, context.DeadlineExceeded, err)
+		require.True(t, left <= 0, "got %d goroutines left running", left)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		left, err := crawl(context.Background(), failingWriter{})
+		require.Error(t, err)
+		require.True(t, left <= 0, "got %d goroutines left running", left)
+	})
+}
+
+// failingWriter fails every write, as a full disk would
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func TestCrawlConcurrently(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":  {Links: []string{"/a", "/b", "/missing"}},
+		"/a": {Links: []string{"/b"}},
+		"/b": {Links: []string{"/"}},
+	})
+	defer srv.Close()
+
+	summary := &Summary{}
+	report := &Report{}
+	c := New(2, srv.Client(), WithSummary(summary), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+
+	const crawls = 4
+	outs := make([]bytes.Buffer, crawls)
+	var wg sync.WaitGroup
+	for i := range outs {
+		wg.Add(1)
+		go func(out *bytes.Buffer) {
+			defer wg.Done()
+			require.NoError(t, c.Crawl(srv.URLFor("/"), out))
+		}(&outs[i])
+	}
+	wg.Wait()
+
+	for _, out := range outs {
+		require.Equal(t, 3, strings.Count(out.String(), "URL:\n"), "each crawl has its own URLs seen")
+	}
+	require.Equal(t, 3*crawls, summary.Pages)
+	require.Equal(t, crawls, summary.Errors)
+	require.Len(t, report.Pages, 3*crawls)
+	require.Equal(t, *summary, report.Summary)
 }
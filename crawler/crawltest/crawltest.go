@@ -0,0 +1,121 @@
+// Package crawltest provides a test server serving a site described declaratively, for testing crawls without
+// hand-writing handlers for every page
+package crawltest
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Site describes the pages served by a Server, keyed by path, e.g. "/about". Requests for any other path are
+// responded to with the page keyed by "*", e.g. to serve a soft 404, or 404 Not Found if there isn't one.
+type Site map[string]Page
+
+// Page describes the response to requests for a path of a Site
+type Page struct {
+	Status     int           // the response's status code, 200 if zero, or 302 if RedirectTo is set
+	Title      string        // the text of the page's title element
+	Links      []string      // the hrefs of the page's links, which may be relative
+	Body       string        // the response body, replacing the HTML otherwise generated from Title and Links
+	Header     http.Header   // headers added to the response, e.g. an X-Robots-Tag or a Content-Type other than HTML
+	Latency    time.Duration // how long to wait before responding
+	RedirectTo string        // the Location of a redirect response, which may be another redirect to form a chain
+}
+
+// Server is an httptest.Server serving a Site, which records the requests made for each path
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+// NewServer starts a server serving site, which the caller should Close when finished with
+func NewServer(site Site) *Server {
+	s := &Server{requests: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+
+		page, ok := site[r.URL.Path]
+		if !ok {
+			page, ok = site["*"]
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		page.serve(w, r)
+	}))
+	return s
+}
+
+// URLFor returns the absolute URL of a path of the site
+func (s *Server) URLFor(path string) string {
+	return s.URL + path
+}
+
+// Requests returns the number of requests made for a path of the site so far
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (p Page) serve(w http.ResponseWriter, r *http.Request) {
+	if p.Latency > 0 {
+		select {
+		case <-time.After(p.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for k, vs := range p.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+
+	if p.RedirectTo != "" {
+		status := p.Status
+		if status == 0 {
+			status = http.StatusFound
+		}
+		http.Redirect(w, r, p.RedirectTo, status)
+		return
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if p.Status != 0 {
+		w.WriteHeader(p.Status)
+	}
+	fmt.Fprint(w, p.html())
+}
+
+// html returns the page's body, generating it from its title and links unless Body is set
+func (p Page) html() string {
+	if p.Body != "" {
+		return p.Body
+	}
+
+	var b strings.Builder
+	b.WriteString("<html><head>")
+	if p.Title != "" {
+		b.WriteString("<title>" + html.EscapeString(p.Title) + "</title>")
+	}
+	b.WriteString("</head><body>")
+	for _, link := range p.Links {
+		fmt.Fprintf(&b, `<a href="%s"></a>`, html.EscapeString(link))
+	}
+	b.WriteString("</body></html>")
+	return b.String()
+}
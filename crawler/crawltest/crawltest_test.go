@@ -0,0 +1,72 @@
+package crawltest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer(Site{
+		"/":      {Title: "Home & away", Links: []string{"/a", "http://example.com/"}},
+		"/gone":  {Status: http.StatusGone},
+		"/raw":   {Body: "<p>raw</p>", Header: http.Header{"X-Robots-Tag": {"noindex"}}},
+		"/old":   {RedirectTo: "/older"},
+		"/older": {RedirectTo: "/", Status: http.StatusMovedPermanently},
+		"/slow":  {Latency: 20 * time.Millisecond},
+	})
+	defer srv.Close()
+
+	get := func(path string) (*http.Response, string) {
+		resp, err := srv.Client().Get(srv.URLFor(path))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	resp, body := get("/")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `<html><head><title>Home &amp; away</title></head><body><a href="/a"></a><a href="http://example.com/"></a></body></html>`, body)
+
+	resp, _ = get("/gone")
+	require.Equal(t, http.StatusGone, resp.StatusCode)
+
+	resp, _ = get("/missing")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, body = get("/raw")
+	require.Equal(t, "<p>raw</p>", body)
+	require.Equal(t, "noindex", resp.Header.Get("X-Robots-Tag"))
+
+	resp, _ = get("/old")
+	require.Equal(t, srv.URLFor("/"), resp.Request.URL.String())
+	require.Equal(t, 1, srv.Requests("/older"))
+	require.Equal(t, 2, srv.Requests("/"))
+
+	start := time.Now()
+	get("/slow")
+	require.True(t, time.Since(start) >= 20*time.Millisecond)
+}
+
+func TestServerFallback(t *testing.T) {
+	srv := NewServer(Site{
+		"/": {Title: "Home"},
+		"*": {Title: "Oops"},
+	})
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URLFor("/missing"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "<title>Oops</title>")
+	require.Equal(t, 1, srv.Requests("/missing"))
+}
@@ -0,0 +1,106 @@
+package crawler
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// Error classes recorded in an error report
+const (
+	ErrorClassHTTPStatus = "http_status"
+	ErrorClassTimeout    = "timeout"
+	ErrorClassSoft404    = "soft_404"
+	ErrorClassFragment   = "broken_fragment"
+	ErrorClassRedirect   = "redirect"
+	ErrorClassOther      = "other"
+)
+
+// WithErrorReport writes each non-fatal error to w as a line of JSON, see ErrorRecord
+func WithErrorReport(w io.Writer) Option {
+	return func(c *crawler) {
+		c.errorReport = w
+	}
+}
+
+// ErrorRecord is a line of an error report
+type ErrorRecord struct {
+	URL        string   `json:"url"`
+	Referrer   string   `json:"referrer,omitempty"`
+	StatusCode int      `json:"status,omitempty"`
+	Class      string   `json:"class"`
+	Error      string   `json:"error"`
+	Attempts   int      `json:"attempts"`
+	Chain      []string `json:"chain,omitempty"` // the URLs requested, for redirect loops and chains too long to follow
+}
+
+// Broken reports whether the error is a broken link, i.e. an HTTP error status code, a soft 404 or a fragment naming
+// no element of its page
+func (r ErrorRecord) Broken() bool {
+	return r.Class == ErrorClassHTTPStatus || r.Class == ErrorClassSoft404 || r.Class == ErrorClassFragment
+}
+
+// errorClass classifies a non-fatal error as one of the ErrorClass constants
+func errorClass(err error) string {
+	if errors.Cause(err) == ErrHttpStatusCode {
+		return ErrorClassHTTPStatus
+	}
+	if errors.Cause(err) == ErrSoft404 {
+		return ErrorClassSoft404
+	}
+	if errors.Cause(err) == ErrBrokenFragment {
+		return ErrorClassFragment
+	}
+	if cause := errors.Cause(err); cause == ErrRedirectLoop || cause == ErrRedirectChain {
+		return ErrorClassRedirect
+	}
+	if netErr, ok := errors.Cause(err).(net.Error); ok && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	return ErrorClassOther
+}
+
+// newErrorRecord describes a non-fatal error
+func newErrorRecord(err error) ErrorRecord {
+	record := ErrorRecord{
+		Class:    errorClass(err),
+		Error:    errors.Cause(err).Error(),
+		Attempts: 1,
+	}
+	if fetchErr, ok := err.(*FetchError); ok {
+		record.URL = displayURL(fetchErr.URL)
+		if fetchErr.Referrer != nil {
+			record.Referrer = displayURL(fetchErr.Referrer)
+		}
+		record.StatusCode = fetchErr.StatusCode
+		record.Error = fetchErr.Err.Error()
+		if redirectErr, ok := fetchErr.Err.(*RedirectError); ok {
+			for _, u := range redirectErr.Chain {
+				record.Chain = append(record.Chain, displayURL(u))
+			}
+		}
+	}
+	return record
+}
+
+// reportErrors returns a subscriber writing an ErrorRecord to w for each non-fatal error, logging the first failed
+// write to l and dropping the rest of the report
+func reportErrors(w io.Writer, l *slog.Logger) func(Event) {
+	enc := json.NewEncoder(w)
+	failed := false
+
+	return func(e Event) {
+		occurred, ok := e.(ErrorOccurred)
+		if !ok || failed {
+			return
+		}
+
+		if err := enc.Encode(newErrorRecord(occurred.Err)); err != nil {
+			l.Error("error writing error report, dropping the rest of it", "error", err.Error())
+			failed = true
+		}
+	}
+}
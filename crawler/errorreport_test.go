@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorReport(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/missing"></a><a href="/broken"></a></body></html>`)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	report := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithErrorReport(report), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	records := []ErrorRecord{}
+	dec := json.NewDecoder(report)
+	for dec.More() {
+		var record ErrorRecord
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	require.ElementsMatch(t, []ErrorRecord{
+		{
+			URL:        srv.URL + "/missing",
+			Referrer:   srv.URL + "/",
+			StatusCode: 404,
+			Class:      ErrorClassHTTPStatus,
+			Error:      srv.URL + "/missing returned status code: 404: received HTTP error status code",
+			Attempts:   1,
+		},
+		{
+			URL:        srv.URL + "/broken",
+			Referrer:   srv.URL + "/",
+			StatusCode: 500,
+			Class:      ErrorClassHTTPStatus,
+			Error:      srv.URL + "/broken returned status code: 500: received HTTP error status code",
+			Attempts:   1,
+		},
+	}, records)
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		title    string
+		err      error
+		expected string
+	}{
+		{"http status", &FetchError{Err: errors.Wrap(ErrHttpStatusCode, "404")}, ErrorClassHTTPStatus},
+		{"timeout", &FetchError{Err: errors.Wrap(timeoutError{}, "get")}, ErrorClassTimeout},
+		{"soft 404", &FetchError{Err: errors.Wrap(ErrSoft404, "title")}, ErrorClassSoft404},
+		{"redirect loop", &FetchError{Err: &RedirectError{Err: ErrRedirectLoop}}, ErrorClassRedirect},
+		{"redirect chain", &FetchError{Err: &RedirectError{Err: ErrRedirectChain}}, ErrorClassRedirect},
+		{"other", &FetchError{Err: errors.New("connection refused")}, ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			require.Equal(t, tt.expected, errorClass(tt.err))
+		})
+	}
+}
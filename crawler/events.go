@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"log/slog"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Event is published to subscribers as a crawl progresses. It is one of FetchStarted, FetchCompleted, PageParsed,
+// URLEnqueued, URLSkipped, TrapDetected or ErrorOccurred.
+type Event interface {
+	event()
+}
+
+// FetchStarted is published by a worker as it requests a URL
+type FetchStarted struct {
+	URL    *url.URL
+	Worker int // the index of the worker fetching URL
+}
+
+// FetchCompleted is published by a worker once it has a response to a request, or the request failed
+type FetchCompleted struct {
+	URL           *url.URL
+	Worker        int
+	StatusCode    int
+	ContentLength int64
+	Duration      time.Duration
+	Err           error // set if no response was received, or its body couldn't be read
+}
+
+// PageParsed is published for each page crawled, whether or not it's written to the output
+type PageParsed struct {
+	Page *Page
+}
+
+// URLEnqueued is published for each URL scheduled for crawling, including the seeds
+type URLEnqueued struct {
+	URL      *url.URL
+	Referrer *url.URL // nil for the seeds
+}
+
+// SkipReason describes why a discovered URL wasn't crawled
+type SkipReason string
+
+const (
+	SkipOutOfScope    SkipReason = "out of scope"
+	SkipDuplicate     SkipReason = "duplicate"
+	SkipURLLimit      SkipReason = "url limit"
+	SkipCrawlTrap     SkipReason = "crawl trap"
+	SkipMaxPages      SkipReason = "max pages"
+	SkipPatternBudget SkipReason = "pattern budget"
+	SkipNormalizer    SkipReason = "normalizer"
+	SkipNoFollow      SkipReason = "nofollow"
+	SkipLinkFilter    SkipReason = "link filter"
+	SkipMaxLinks      SkipReason = "max links per page"
+
+	// SkipMalformed is the reason for skipping a link which couldn't be parsed, so has no URL
+	SkipMalformed SkipReason = "malformed"
+
+	// SkipResponseFilter is the reason for skipping a page which was fetched, but rejected by a response filter
+	SkipResponseFilter SkipReason = "response filter"
+
+	// SkipOutscored is the reason for skipping a URL which was enqueued, but left waiting once the page budget was
+	// spent on URLs scoring higher, see WithPriority
+	SkipOutscored SkipReason = "outscored"
+)
+
+// URLSkipped is published for each discovered URL which isn't crawled
+type URLSkipped struct {
+	URL      *url.URL // nil for SkipMalformed
+	Referrer *url.URL
+	Reason   SkipReason
+	Err      error // the limit exceeded, for SkipURLLimit and SkipCrawlTrap, or the parse error for SkipMalformed
+}
+
+// TrapDetected is published the first time a URL is found to be part of a crawl trap
+type TrapDetected struct {
+	Pattern  string
+	Referrer *url.URL
+	Err      error
+}
+
+// ErrorOccurred is published for each non-fatal error, e.g. HTTP error status codes and timeouts
+type ErrorOccurred struct {
+	Err error
+}
+
+func (FetchStarted) event()   {}
+func (FetchCompleted) event() {}
+func (PageParsed) event()     {}
+func (URLEnqueued) event()    {}
+func (URLSkipped) event()     {}
+func (TrapDetected) event()   {}
+func (ErrorOccurred) event()  {}
+
+// WithSubscriber calls fn with every event published during a crawl. Subscribers are called one event at a time, in
+// the order they were added, even by crawls running at once, so needn't be safe for concurrent use but should return
+// promptly.
+func WithSubscriber(fn func(Event)) Option {
+	return func(c *crawler) {
+		c.subscribers = append(c.subscribers, fn)
+	}
+}
+
+// eventBus publishes the events of a single crawl to its subscribers
+type eventBus struct {
+	mu          *sync.Mutex // shared by the buses of every crawl by a crawler, as they share subscribers
+	subscribers []func(Event)
+}
+
+func newEventBus(subscribers ...func(Event)) *eventBus {
+	return &eventBus{mu: &sync.Mutex{}, subscribers: subscribers}
+}
+
+func (b *eventBus) publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, fn := range b.subscribers {
+		fn(e)
+	}
+}
+
+// logEvents returns a subscriber logging links skipped for exceeding URL limits or being malformed, crawl traps and
+// non-fatal errors as warnings, and fetches and links skipped for any other reason at debug level
+func logEvents(l *slog.Logger) func(Event) {
+	return func(e Event) {
+		switch e := e.(type) {
+		case FetchCompleted:
+			if e.Err == nil {
+				l.Debug("fetched page",
+					"url", displayURL(e.URL), "status", e.StatusCode, "bytes", e.ContentLength, "duration", e.Duration, "worker", e.Worker)
+			}
+		case URLSkipped:
+			args := []any{}
+			if e.URL != nil {
+				args = append(args, "url", displayURL(e.URL))
+			}
+			if e.Referrer != nil {
+				args = append(args, "referrer", displayURL(e.Referrer))
+			}
+			args = append(args, "reason", e.Reason)
+			if e.Err != nil {
+				args = append(args, "error", e.Err.Error())
+			}
+			if e.Reason == SkipURLLimit || e.Reason == SkipMalformed {
+				l.Warn("skipping url", args...)
+			} else {
+				l.Debug("skipping url", args...)
+			}
+		case TrapDetected:
+			l.Warn("no longer expanding crawl trap", "pattern", e.Pattern, "referrer", displayURL(e.Referrer), "error", e.Err.Error())
+		case ErrorOccurred:
+			args := []any{"error", e.Err.Error()}
+			if fetchErr, ok := e.Err.(*FetchError); ok {
+				args = append(args, "url", displayURL(fetchErr.URL))
+				if fetchErr.Referrer != nil {
+					args = append(args, "referrer", displayURL(fetchErr.Referrer))
+				}
+				if fetchErr.StatusCode != 0 {
+					args = append(args, "status", fetchErr.StatusCode)
+				}
+			}
+			l.Warn("fetch failed", args...)
+		}
+	}
+}
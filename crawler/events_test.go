@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriber(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/a"></a><a href="/a"></a><a href="http://elsewhere.com/"></a><a href="/missing"></a><a href="/nofollow"></a></body></html>`)
+	})
+	mux.HandleFunc("/nofollow", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><meta name="robots" content="nofollow"></head><body><a href="/hidden"></a></body></html>`)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/a/b/c/d"></a></body></html>`)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	counts := map[string]int{}
+	skipped := map[SkipReason][]string{}
+	c := New(1, srv.Client(),
+		WithURLLimits(URLLimits{MaxPathSegments: 3}),
+		WithLogger(newTestLogger(io.Discard)),
+		WithSubscriber(func(e Event) {
+			counts[fmt.Sprintf("%T", e)]++
+			if skip, ok := e.(URLSkipped); ok {
+				skipped[skip.Reason] = append(skipped[skip.Reason], skip.URL.Path)
+			}
+		}),
+	)
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	require.Equal(t, map[string]int{
+		"crawler.URLEnqueued":    4,
+		"crawler.FetchStarted":   4,
+		"crawler.FetchCompleted": 4,
+		"crawler.PageParsed":     3,
+		"crawler.ErrorOccurred":  1,
+		"crawler.URLSkipped":     4,
+	}, counts)
+	require.Equal(t, map[SkipReason][]string{
+		SkipDuplicate:  {"/a"},
+		SkipOutOfScope: {"/"},
+		SkipURLLimit:   {"/a/b/c/d"},
+		SkipNoFollow:   {"/hidden"},
+	}, skipped)
+}
+
+func TestLogEvents(t *testing.T) {
+	link, _ := url.Parse("http://test.com/a/b/c/d")
+	referrer, _ := url.Parse("http://test.com/a")
+	limitErr := errors.Wrap(ErrURLLimit, "more than 3 path segments")
+
+	tests := []struct {
+		title    string
+		event    Event
+		expected string
+	}{
+		{
+			title:    "url limit",
+			event:    URLSkipped{URL: link, Referrer: referrer, Reason: SkipURLLimit, Err: limitErr},
+			expected: `level=WARN msg="skipping url" url=http://test.com/a/b/c/d referrer=http://test.com/a reason="url limit" error="more than 3 path segments: URL exceeds limit"` + "\n",
+		},
+		{
+			title:    "duplicate",
+			event:    URLSkipped{URL: link, Referrer: referrer, Reason: SkipDuplicate},
+			expected: `level=DEBUG msg="skipping url" url=http://test.com/a/b/c/d referrer=http://test.com/a reason=duplicate` + "\n",
+		},
+		{
+			title:    "seed",
+			event:    URLSkipped{URL: link, Reason: SkipNormalizer},
+			expected: `level=DEBUG msg="skipping url" url=http://test.com/a/b/c/d reason=normalizer` + "\n",
+		},
+		{
+			title:    "trap",
+			event:    TrapDetected{Pattern: "test.com/**/b", Referrer: referrer, Err: ErrCrawlTrap},
+			expected: `level=WARN msg="no longer expanding crawl trap" pattern=test.com/**/b referrer=http://test.com/a error="likely crawl trap"` + "\n",
+		},
+		{
+			title:    "error",
+			event:    ErrorOccurred{Err: &FetchError{URL: link, Referrer: referrer, StatusCode: 404, Err: ErrHttpStatusCode}},
+			expected: `level=WARN msg="fetch failed" error="received HTTP error status code (linked from http://test.com/a)" url=http://test.com/a/b/c/d referrer=http://test.com/a status=404` + "\n",
+		},
+		{
+			title:    "fetch",
+			event:    FetchCompleted{URL: link, Worker: 2, StatusCode: 200, ContentLength: 10, Duration: time.Second},
+			expected: `level=DEBUG msg="fetched page" url=http://test.com/a/b/c/d status=200 bytes=10 duration=1s worker=2` + "\n",
+		},
+		{
+			title: "page",
+			event: PageParsed{Page: &Page{URL: link}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			logEvents(newTestLogger(out))(tt.event)
+			require.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+// newTestLogger returns a logger writing every level to w, without timestamps
+func newTestLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
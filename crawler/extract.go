@@ -0,0 +1,165 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+)
+
+var ErrExtractionRule = errors.New("invalid extraction rule")
+
+// maxRegexpMatches is the number of matches of an ExtractionRule's Regexp recorded per page, so that a pattern matching
+// far more than intended doesn't swamp the output
+const maxRegexpMatches = 100
+
+// ExtractionRule extracts a named field from each page crawled, recorded on Page.Fields, with either a CSS selector or
+// a regular expression
+type ExtractionRule struct {
+	Field    string
+	Selector string // a CSS selector, e.g. "h1" or "meta[property='og:image']"
+	Attr     string // the attribute of each matching element to extract, or its text if empty
+	// Regexp is matched against the page's HTML body instead of using Selector, extracting each match or, if it has
+	// capture groups, the first group, e.g. `SKU-[0-9]{6}` or `data-build="([^"]+)"`
+	Regexp  string
+	Pattern string // restricts the rule to pages whose path and query match, as for WithPatternBudget
+}
+
+// extractor is a compiled ExtractionRule
+type extractor struct {
+	rule    ExtractionRule
+	sel     cascadia.Sel   // nil if the rule has a Regexp
+	re      *regexp.Regexp // nil if the rule has a Selector
+	pattern *regexp.Regexp // nil if the rule applies to every page
+}
+
+// Validate returns an error wrapping ErrExtractionRule if the rule has no field, an invalid selector or regexp, or both
+// a selector and a regexp
+func (r ExtractionRule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+func (r ExtractionRule) compile() (extractor, error) {
+	e := extractor{rule: r}
+	switch {
+	case r.Field == "" && r.Regexp != "":
+		return e, errors.Wrapf(ErrExtractionRule, "regexp %q has no field", r.Regexp)
+	case r.Field == "":
+		return e, errors.Wrapf(ErrExtractionRule, "selector %q has no field", r.Selector)
+	case r.Regexp != "" && r.Selector != "":
+		return e, errors.Wrapf(ErrExtractionRule, "field %s has both a selector and a regexp", r.Field)
+	case r.Regexp != "":
+		re, err := regexp.Compile(r.Regexp)
+		if err != nil {
+			return e, errors.Wrapf(ErrExtractionRule, "field %s has regexp %q: %s", r.Field, r.Regexp, err)
+		}
+		e.re = re
+	default:
+		sel, err := cascadia.Parse(r.Selector)
+		if err != nil {
+			return e, errors.Wrapf(ErrExtractionRule, "field %s has selector %q: %s", r.Field, r.Selector, err)
+		}
+		e.sel = sel
+	}
+	if r.Pattern != "" {
+		e.pattern = globRegexp(r.Pattern)
+	}
+	return e, nil
+}
+
+// WithExtractionRules records the values matched by each rule on every page. Crawl returns an error wrapping
+// ErrExtractionRule if any of the rules are invalid.
+func WithExtractionRules(rules ...ExtractionRule) Option {
+	return func(c *crawler) {
+		for _, rule := range rules {
+			e, err := rule.compile()
+			if err != nil {
+				if c.extractionErr == nil {
+					c.extractionErr = err
+				}
+				continue
+			}
+			c.extractors = append(c.extractors, e)
+		}
+	}
+}
+
+// extract returns the values matched by each extractor applying to a page in its HTML body, keyed by field. Fields
+// with no matches are omitted.
+func extract(u *url.URL, body []byte, extractors []extractor) map[string][]string {
+	applicable := []extractor{}
+	for _, e := range extractors {
+		if e.pattern == nil || e.pattern.MatchString(u.RequestURI()) {
+			applicable = append(applicable, e)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	fields := map[string][]string{}
+	var doc *html.Node
+	for _, e := range applicable {
+		if e.re != nil {
+			for _, match := range e.re.FindAllSubmatch(body, maxRegexpMatches) {
+				value := match[0]
+				if len(match) > 1 {
+					value = match[1]
+				}
+				if value := strings.TrimSpace(string(value)); value != "" {
+					fields[e.rule.Field] = append(fields[e.rule.Field], value)
+				}
+			}
+			continue
+		}
+
+		if doc == nil {
+			var err error
+			if doc, err = html.Parse(bytes.NewReader(body)); err != nil {
+				return fields
+			}
+		}
+		for _, node := range cascadia.QueryAll(doc, e.sel) {
+			value := nodeText(node)
+			if e.rule.Attr != "" {
+				value = nodeAttr(node, e.rule.Attr)
+			}
+			if value != "" {
+				fields[e.rule.Field] = append(fields[e.rule.Field], value)
+			}
+		}
+	}
+	return fields
+}
+
+// nodeText returns the text within an element, with whitespace collapsed
+func nodeText(n *html.Node) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+			text.WriteByte(' ')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(text.String()), " ")
+}
+
+// nodeAttr returns the value of an element's attribute, or an empty string if it isn't set
+func nodeAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
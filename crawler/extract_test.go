@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	body := []byte(`<html><head><meta property="og:image" content=" /hero.png "></head><body>
+		<h1>Current <em>account</em></h1>
+		<ul class="price"><li>£0</li><li> £5 </li></ul>
+		<p class="empty"></p>
+		<p>SKU-123456, SKU-654321</p>
+		<footer data-build=" 2018.03.1 "></footer>
+	</body></html>`)
+
+	c := New(1, nil, WithExtractionRules(
+		ExtractionRule{Field: "heading", Selector: "h1"},
+		ExtractionRule{Field: "image", Selector: "meta[property='og:image']", Attr: "content"},
+		ExtractionRule{Field: "price", Selector: ".price li"},
+		ExtractionRule{Field: "empty", Selector: ".empty"},
+		ExtractionRule{Field: "missing", Selector: "table"},
+		ExtractionRule{Field: "author", Selector: "h1", Pattern: "/blog/*"},
+		ExtractionRule{Field: "sku", Regexp: `SKU-[0-9]{6}`},
+		ExtractionRule{Field: "build", Regexp: `data-build="([^"]+)"`},
+		ExtractionRule{Field: "version", Regexp: `v[0-9]+\.[0-9]+`},
+	)).(*crawler)
+	require.NoError(t, c.extractionErr)
+
+	require.Equal(t, map[string][]string{
+		"heading": {"Current account"},
+		"image":   {"/hero.png"},
+		"price":   {"£0", "£5"},
+		"sku":     {"SKU-123456", "SKU-654321"},
+		"build":   {"2018.03.1"},
+	}, extract(&url.URL{Path: "/accounts"}, body, c.extractors))
+	require.Equal(t, []string{"Current account"}, extract(&url.URL{Path: "/blog/new"}, body, c.extractors)["author"])
+}
+
+func TestExtractionRuleInvalid(t *testing.T) {
+	c := New(1, nil, WithExtractionRules(ExtractionRule{Field: "broken", Selector: "h1["}))
+	err := c.Crawl("http://test.com/", &bytes.Buffer{})
+	require.Equal(t, ErrExtractionRule, errors.Cause(err))
+	require.Contains(t, err.Error(), "field broken")
+
+	for _, rule := range []ExtractionRule{
+		{Field: "broken", Regexp: "SKU-("},
+		{Field: "broken", Selector: "h1", Regexp: "SKU"},
+		{Regexp: "SKU"},
+	} {
+		require.Equal(t, ErrExtractionRule, errors.Cause(rule.Validate()), rule)
+	}
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrLocalFilesUnsupported = errors.New("crawling local files requires an *http.Client")
+	ErrFileURLHost           = errors.New("file URLs must be local")
+)
+
+// WithLocalFiles crawls file:// URLs as well as http and https ones, serving them from the directory root as a static
+// web server would, so that a site's build output can be link checked before it's deployed. Paths are relative to
+// root, so with root "public", file:///about/ is public/about/index.html and root relative links resolve as they
+// would once deployed. Use "/" to crawl file:// URLs of the whole filesystem. The http client must be an *http.Client.
+func WithLocalFiles(root string) Option {
+	return func(c *crawler) {
+		c.fileRoot = root
+		c.linkOpts = append(c.linkOpts, linkextract.WithSchemes("http", "https", "file"))
+	}
+}
+
+// fileTransport serves file:// URLs from a directory, passing other requests to another transport. Directories are
+// served by their index.html, and missing files respond 404 Not Found.
+type fileTransport struct {
+	root     string
+	fallback http.RoundTripper
+}
+
+func (t *fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "file" {
+		return t.fallback.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if req.URL.Host != "" && req.URL.Host != "localhost" {
+		return nil, errors.Wrapf(ErrFileURLHost, "%s", req.URL.Host)
+	}
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return fileResponse(req, http.StatusMethodNotAllowed, nil), nil
+	}
+
+	urlPath := path.Clean("/" + req.URL.Path)
+	name := filepath.Join(t.root, filepath.FromSlash(urlPath))
+	info, err := os.Stat(name)
+	if err == nil && info.IsDir() {
+		if !strings.HasSuffix(req.URL.Path, "/") {
+			// as a web server would, so that the index's relative links resolve against the directory
+			location := *req.URL
+			location.Path = urlPath + "/"
+			resp := fileResponse(req, http.StatusMovedPermanently, nil)
+			resp.Header.Set("Location", location.String())
+			return resp, nil
+		}
+		name = filepath.Join(name, "index.html")
+		info, err = os.Stat(name)
+	}
+	if os.IsNotExist(err) || (err == nil && info.IsDir()) {
+		return fileResponse(req, http.StatusNotFound, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		sniff := make([]byte, 512)
+		n, _ := io.ReadFull(f, sniff)
+		contentType = http.DetectContentType(sniff[:n])
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	resp := fileResponse(req, http.StatusOK, f)
+	resp.ContentLength = info.Size()
+	resp.Header.Set("Content-Type", contentType)
+	resp.Header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	resp.Header.Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+	if req.Method == http.MethodHead {
+		f.Close()
+		resp.Body = http.NoBody
+	}
+	return resp, nil
+}
+
+// fileResponse returns a response to a request for a file, with an empty body if body is nil
+func fileResponse(req *http.Request, status int, body io.ReadCloser) *http.Response {
+	contentLength := int64(-1)
+	if body == nil {
+		body, contentLength = io.NopCloser(bytes.NewReader(nil)), 0
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          body,
+		ContentLength: contentLength,
+		Request:       req,
+	}
+}
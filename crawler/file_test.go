@@ -0,0 +1,79 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlLocalFiles(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"index.html":          `<a href="/about">about</a><a href="docs/intro.html">intro</a><a href="https://example.com/">external</a>`,
+		"about/index.html":    `<a href="../missing.html">missing</a><a href="team.html">team</a>`,
+		"about/team.html":     `<title>Team</title>`,
+		"docs/intro.html":     `<a href="/">home</a><a href="/empty/">empty</a>`,
+		"empty/.gitkeep":      ``,
+		"docs/unlinked.html":  ``,
+		"docs/image-data.bin": ``,
+	}
+	for name, content := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0o755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	report := &Report{}
+	c := New(1, &http.Client{}, WithLocalFiles(root), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl("file:///", &bytes.Buffer{}))
+
+	pages := map[string]PageRecord{}
+	for _, page := range report.Pages {
+		pages[page.URL] = page
+	}
+	require.Len(t, pages, 4)
+	require.Contains(t, pages, "file:///")
+	require.Equal(t, "file:///about/", pages["file:///about"].RedirectedTo)
+	require.Equal(t, "Team", pages["file:///about/team.html"].Title)
+	require.Contains(t, pages, "file:///docs/intro.html")
+
+	broken := map[string]int{}
+	for _, record := range report.BrokenLinks() {
+		broken[record.URL] = record.StatusCode
+	}
+	require.Equal(t, map[string]int{"file:///missing.html": http.StatusNotFound, "file:///empty/": http.StatusNotFound}, broken)
+}
+
+func TestFileTransport(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "page"), []byte("<!DOCTYPE html><p>hi</p>"), 0o644))
+	client := &http.Client{Transport: &fileTransport{root: root, fallback: http.DefaultTransport}}
+
+	t.Run("sniffs content type", func(t *testing.T) {
+		resp, err := client.Get("file:///page")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "<!DOCTYPE html><p>hi</p>", string(body))
+	})
+
+	t.Run("stays within root", func(t *testing.T) {
+		resp, err := client.Get("file:///../../../etc/passwd")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("remote host", func(t *testing.T) {
+		_, err := client.Get("file://example.com/page")
+		require.ErrorIs(t, err, ErrFileURLHost)
+	})
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// WithResponseFilter calls filter with each successful response, skipping pages for which it returns false rather than
+// parsing and writing them. The response's body has already been read, and is replaced with a copy the filter may
+// read. Filters are called in the order they were added, and an error returned by any of them aborts the crawl.
+func WithResponseFilter(filter func(*http.Response) (process bool, err error)) Option {
+	return func(c *crawler) {
+		c.responseFilters = append(c.responseFilters, filter)
+	}
+}
+
+// filterResponse reports whether every response filter accepts a response with the given body
+func (c *crawler) filterResponse(resp *http.Response, body []byte) (bool, error) {
+	for _, filter := range c.responseFilters {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		process, err := filter(resp)
+		if err != nil {
+			return false, errors.Wrap(err, "response filter")
+		}
+		if !process {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// WithLinkFilter calls filter with each link extracted from a page, including its pagination links, only following
+// those for which it returns true. Every filter added must accept a link for it to be followed.
+func WithLinkFilter(filter func(parent *Page, link *url.URL) bool) Option {
+	return func(c *crawler) {
+		c.linkFilters = append(c.linkFilters, filter)
+	}
+}
+
+// followLink reports whether every link filter accepts a link from parent
+func (c *crawler) followLink(parent *Page, link *url.URL) bool {
+	for _, filter := range c.linkFilters {
+		if !filter(parent, link) {
+			return false
+		}
+	}
+	return true
+}
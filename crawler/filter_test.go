@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseFilter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/cached"></a><a href="/placeholder"></a></body></html>`)
+	})
+	mux.HandleFunc("/cached", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache", "HIT")
+		fmt.Fprint(w, `<html><body><a href="/hidden"></a></body></html>`)
+	})
+	mux.HandleFunc("/placeholder", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>coming soon</body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	summary := &Summary{}
+	skipped := []string{}
+	c := New(2, srv.Client(),
+		WithSummary(summary),
+		WithResponseFilter(func(resp *http.Response) (bool, error) {
+			return resp.Header.Get("X-Cache") != "HIT", nil
+		}),
+		WithResponseFilter(func(resp *http.Response) (bool, error) {
+			body, err := ioutil.ReadAll(resp.Body)
+			return !strings.Contains(string(body), "coming soon"), err
+		}),
+		WithSubscriber(func(e Event) {
+			if skip, ok := e.(URLSkipped); ok && skip.Reason == SkipResponseFilter {
+				skipped = append(skipped, skip.URL.Path)
+			}
+		}),
+	)
+	require.NoError(t, c.Crawl(srv.URL+"/", &out))
+
+	require.Equal(t, 1, strings.Count(out.String(), "URL:\n"))
+	require.Equal(t, 1, summary.Pages)
+	require.Equal(t, 2, summary.Skipped)
+	require.ElementsMatch(t, []string{"/cached", "/placeholder"}, skipped)
+
+	filterErr := errors.New("filter failed")
+	c = New(2, srv.Client(), WithResponseFilter(func(resp *http.Response) (bool, error) {
+		return false, filterErr
+	}))
+	require.Equal(t, filterErr, errors.Cause(c.Crawl(srv.URL+"/", io.Discard)))
+}
+
+func TestLinkFilter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><link rel="next" href="/tags/2"></head><body><a href="/a"></a><a href="/tags/go"></a></body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	parents := []string{}
+	c := New(2, srv.Client(), WithLinkFilter(func(parent *Page, link *url.URL) bool {
+		parents = append(parents, parent.URL.Path)
+		return !strings.HasPrefix(link.Path, "/tags/")
+	}))
+	require.NoError(t, c.Crawl(srv.URL+"/", &out))
+
+	require.Equal(t, 2, strings.Count(out.String(), "URL:\n"))
+	require.NotContains(t, out.String(), "URL:\n\t"+srv.URL+"/tags/")
+	require.ElementsMatch(t, []string{"/", "/", "/", "/a", "/a", "/a"}, parents)
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// WithPageFormat writes each page as format renders it rather than with Page.Marshal, to the writer given to Crawl,
+// CrawlAll or CrawlWithResult. An error returned by format aborts the crawl.
+func WithPageFormat(format func(*Page) ([]byte, error)) Option {
+	return func(c *crawler) {
+		c.pageFormat = format
+	}
+}
+
+// TemplateFuncs are the functions available to templates parsed with ParseTemplate, in addition to text/template's
+// builtins
+var TemplateFuncs = template.FuncMap{
+	"display": displayURL,
+	"join":    strings.Join,
+	"joinURLs": func(urls []*url.URL, sep string) string {
+		displayed := make([]string, 0, len(urls))
+		for _, u := range urls {
+			displayed = append(displayed, displayURL(u))
+		}
+		return strings.Join(displayed, sep)
+	},
+}
+
+// ParseTemplate parses text as a text/template with TemplateFuncs, to be executed with a *Page, e.g.
+// "{{.URL}} {{.StatusCode}} {{len .Links}}"
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("page").Funcs(TemplateFuncs).Parse(text)
+}
+
+// TemplateFormat returns a page format for WithPageFormat executing tmpl with each page, followed by a newline if the
+// output doesn't already end in one
+func TemplateFormat(tmpl *template.Template) func(*Page) ([]byte, error) {
+	return func(p *Page) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, p); err != nil {
+			return nil, errors.Wrapf(err, "formatting %s", p.URL)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// formatPage renders a page for the crawl's output
+func (c *crawler) formatPage(p *Page) ([]byte, error) {
+	if c.pageFormat == nil {
+		return p.Marshal(), nil
+	}
+	return c.pageFormat(p)
+}
@@ -0,0 +1,47 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateFormat(t *testing.T) {
+	page := &Page{
+		URL:        &url.URL{Scheme: "http", Host: "xn--mnchen-3ya.de", Path: "/"},
+		StatusCode: 200,
+		Title:      "München",
+		Fields:     map[string][]string{"heading": {"Willkommen"}},
+		Links: []*url.URL{
+			{Scheme: "http", Host: "xn--mnchen-3ya.de", Path: "/a"},
+			{Scheme: "http", Host: "xn--mnchen-3ya.de", Path: "/b"},
+		},
+	}
+
+	t.Run("fields and funcs", func(t *testing.T) {
+		tmpl, err := ParseTemplate(`{{display .URL}} {{.StatusCode}} {{.Title}} {{join .Fields.heading ","}} {{len .Links}} {{joinURLs .Links " "}}`)
+		require.NoError(t, err)
+
+		out, err := TemplateFormat(tmpl)(page)
+		require.NoError(t, err)
+		require.Equal(t, "http://münchen.de/ 200 München Willkommen 2 http://münchen.de/a http://münchen.de/b\n", string(out))
+	})
+
+	t.Run("trailing newline kept", func(t *testing.T) {
+		tmpl, err := ParseTemplate("{{.StatusCode}}\n")
+		require.NoError(t, err)
+
+		out, err := TemplateFormat(tmpl)(page)
+		require.NoError(t, err)
+		require.Equal(t, "200\n", string(out))
+	})
+
+	t.Run("execution error", func(t *testing.T) {
+		tmpl, err := ParseTemplate("{{.Missing}}")
+		require.NoError(t, err)
+
+		_, err = TemplateFormat(tmpl)(page)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,99 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"golang.org/x/net/html"
+)
+
+// The reasons a form is insecure
+const (
+	InsecureFormHTTP        = "http"         // the form submits over plain http
+	InsecureFormCrossOrigin = "cross-origin" // the form submits to a different origin from the page's
+)
+
+// Form is the endpoint a form of a page submits to
+type Form struct {
+	Method string   // upper cased, GET if the form doesn't set it
+	Action *url.URL // the URL the form submits to, the page's own if it has no action
+}
+
+// submission returns the URL requested by submitting a GET form with no fields, whose query replaces the action's
+func (f Form) submission() *url.URL {
+	u := *f.Action
+	u.RawQuery, u.Fragment = "", ""
+	return &u
+}
+
+// WithFormDiscovery records the endpoints each page's forms submit to on Page.Forms, and if followGET is set, crawls
+// those of GET forms in scope as if they were linked to, without any of the forms' fields, so that e.g. search and
+// filter pages are found
+func WithFormDiscovery(followGET bool) Option {
+	return func(c *crawler) {
+		c.forms = true
+		c.followForms = followGET
+	}
+}
+
+// InsecureForm is a form which submits over plain http or to another origin
+type InsecureForm struct {
+	Action *url.URL // the URL the form submits to, the page's own if it has no action
+	Method string   // upper cased, GET if the form doesn't set it
+	Reason string   // InsecureFormHTTP or InsecureFormCrossOrigin
+}
+
+// WithInsecureFormDetection records the forms of each page which submit over plain http, or to a different origin from
+// the page's, on Page.InsecureForms
+func WithInsecureFormDetection() Option {
+	return func(c *crawler) {
+		c.insecureForms = true
+	}
+}
+
+// findForms returns the endpoints of the forms of a page, once each
+func findForms(page *Page) []Form {
+	var forms []Form
+	seen := map[string]bool{}
+	for _, form := range page.forms {
+		key := form.Method + " " + form.Action.String()
+		if !seen[key] {
+			seen[key] = true
+			forms = append(forms, form)
+		}
+	}
+	return forms
+}
+
+// findInsecureForms returns the forms of a page which submit over plain http or to another origin
+func findInsecureForms(page *Page) []InsecureForm {
+	base := page.base()
+
+	var forms []InsecureForm
+	for _, form := range page.forms {
+		switch {
+		case form.Action.Scheme == "http":
+			forms = append(forms, InsecureForm{Action: form.Action, Method: form.Method, Reason: InsecureFormHTTP})
+		case form.Action.Scheme != base.Scheme || !strings.EqualFold(form.Action.Host, base.Host):
+			forms = append(forms, InsecureForm{Action: form.Action, Method: form.Method, Reason: InsecureFormCrossOrigin})
+		}
+	}
+	return forms
+}
+
+// collectForm records a form of a page, with its action resolved by links against the page's base href
+func collectForm(page *Page, links *linkextract.Extractor, tag html.Token) {
+	if tag.Data != "form" {
+		return
+	}
+	action, err := links.Resolve(attrVal(tag, "action"))
+	if err != nil || action == nil {
+		return // malformed, or e.g. a javascript: action
+	}
+	method := strings.ToUpper(strings.TrimSpace(attrVal(tag, "method")))
+	if method == "" {
+		method = "GET"
+	}
+	page.forms = append(page.forms, Form{Method: method, Action: action})
+}
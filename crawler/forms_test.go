@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindInsecureForms(t *testing.T) {
+	body := []byte(`<html><body>
+		<form action="/search"></form>
+		<form action="http://monzo.com/login" method="post"></form>
+		<form action="https://payments.example.com/pay" method="Post"></form>
+		<form action="javascript:void(0)"></form>
+		<form></form>
+	</body></html>`)
+
+	describe := func(forms []InsecureForm) []string {
+		described := []string{}
+		for _, form := range forms {
+			described = append(described, form.Reason+" "+form.Method+" "+form.Action.String())
+		}
+		return described
+	}
+
+	t.Run("https", func(t *testing.T) {
+		page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/"}}
+		require.Equal(t, []string{
+			"http POST http://monzo.com/login",
+			"cross-origin POST https://payments.example.com/pay",
+		}, describe(findInsecureForms(parsed(page, body))))
+	})
+
+	t.Run("http", func(t *testing.T) {
+		page := &Page{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/"}}
+		require.Equal(t, []string{
+			"http GET http://monzo.com/search",
+			"http POST http://monzo.com/login",
+			"cross-origin POST https://payments.example.com/pay",
+			"http GET http://monzo.com/",
+		}, describe(findInsecureForms(parsed(page, body))))
+	})
+
+	t.Run("base href", func(t *testing.T) {
+		page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/"}}
+		forms := findInsecureForms(parsed(page, []byte(`<html><head><base href="http://legacy.monzo.com/"></head><form action="login"></form></html>`)))
+		require.Equal(t, []string{"http GET http://legacy.monzo.com/login"}, describe(forms))
+	})
+}
+
+func TestFindForms(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/help/"}}
+	body := []byte(`<html><body>
+		<form action="/search?q=card"></form>
+		<form action="contact" method="post"></form>
+		<form action="/search?q=card" method="get"></form>
+		<form action="javascript:void(0)"></form>
+		<form></form>
+	</body></html>`)
+
+	described := []string{}
+	for _, form := range findForms(parsed(page, body)) {
+		described = append(described, form.Method+" "+form.Action.String())
+	}
+	require.Equal(t, []string{
+		"GET https://monzo.com/search?q=card",
+		"POST https://monzo.com/help/contact",
+		"GET https://monzo.com/help/",
+	}, described)
+}
+
+func TestFormDiscovery(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":       {Body: `<html><body><form action="/search?q=card"></form><form method="post" action="/subscribe"></form></body></html>`},
+		"/search": {Body: `<html><body><form action="/search"></form></body></html>`},
+	})
+	defer srv.Close()
+
+	for _, followGET := range []bool{false, true} {
+		report := &Report{}
+		out := &bytes.Buffer{}
+		c := New(1, srv.Client(), WithReport(report), WithFormDiscovery(followGET), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URL+"/", out))
+
+		require.Contains(t, out.String(), "Forms:\n\tGET "+srv.URL+"/search?q=card\n\tPOST "+srv.URL+"/subscribe\n")
+		if !followGET {
+			require.Len(t, report.Pages, 1, "forms shouldn't be crawled unless following GET forms")
+			continue
+		}
+		// the search form is crawled without its query, and the subscribe form not at all
+		require.Equal(t, []FormEndpoint{
+			{FormRecord: FormRecord{Method: "GET", Action: srv.URL + "/search"}, Pages: 1, Examples: []string{srv.URL + "/search"}},
+			{FormRecord: FormRecord{Method: "GET", Action: srv.URL + "/search?q=card"}, Pages: 1, Examples: []string{srv.URL + "/"}},
+			{FormRecord: FormRecord{Method: "POST", Action: srv.URL + "/subscribe"}, Pages: 1, Examples: []string{srv.URL + "/"}},
+		}, report.FormEndpoints())
+	}
+}
+
+func TestInsecureFormDetection(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/": {Body: `<html><body><form method="post" action="https://example.com/subscribe"></form></body></html>`},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	out := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithReport(report), WithInsecureFormDetection(), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", out))
+
+	require.Contains(t, out.String(), "InsecureForms:\n\tcross-origin: POST https://example.com/subscribe\n")
+	require.Equal(t, []InsecureFormRecord{
+		{Page: srv.URL + "/", Action: "https://example.com/subscribe", Method: "POST", Reason: InsecureFormCrossOrigin},
+	}, report.InsecureForms())
+}
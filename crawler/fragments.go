@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrBrokenFragment = errors.New("broken fragment")
+
+// WithFragmentValidation checks that the fragment of each link to a page in scope, e.g. "#install" in
+// "/docs/setup#install", names an element on the page, by its id or an anchor's name, and reports those which don't as
+// errors of class ErrorClassFragment, so that they're counted as broken links. Links to pages which aren't crawled,
+// aren't HTML or can't be fetched aren't checked.
+func WithFragmentValidation() Option {
+	return func(c *crawler) {
+		c.fragments = true
+	}
+}
+
+// fragmentLink is a link of a page with a fragment
+type fragmentLink struct {
+	target   *url.URL // without the fragment
+	fragment string   // unescaped
+}
+
+// pageAnchors are the fragments a page crawled has elements for
+type pageAnchors struct {
+	statusCode int
+	names      map[string]bool // nil if the page isn't an HTML document
+}
+
+// fragmentRef is a link with a fragment to a page which hasn't been crawled yet
+type fragmentRef struct {
+	fragmentLink
+	page *url.URL // the page linking to the target
+}
+
+// checkFragments records the anchors of a page crawled, checking the links waiting for it, and checks the page's links
+// with fragments to pages already crawled, holding back the rest until their targets are crawled
+func (s *session) checkFragments(page *Page) {
+	anchors := &pageAnchors{statusCode: page.StatusCode, names: page.anchors}
+	for _, u := range []*url.URL{page.URL, page.RedirectedTo} {
+		if u == nil {
+			continue
+		}
+		key := s.cacheKey(u)
+		s.anchors[key] = anchors
+		for _, ref := range s.fragmentRefs[key] {
+			s.checkFragment(ref, anchors)
+		}
+		delete(s.fragmentRefs, key)
+	}
+
+	for _, link := range page.fragmentLinks {
+		target := s.normalize(link.target)
+		if target == nil || !s.inScope(target) {
+			continue
+		}
+		ref := fragmentRef{fragmentLink: fragmentLink{target: target, fragment: link.fragment}, page: page.URL}
+		if anchors, ok := s.anchors[s.cacheKey(target)]; ok {
+			s.checkFragment(ref, anchors)
+		} else {
+			s.fragmentRefs[s.cacheKey(target)] = append(s.fragmentRefs[s.cacheKey(target)], ref)
+		}
+	}
+}
+
+// checkFragment reports a link whose fragment doesn't name any element of its target. An empty fragment or "top"
+// scrolls to the top of any page, as browsers do.
+func (s *session) checkFragment(ref fragmentRef, anchors *pageAnchors) {
+	if anchors.names == nil || ref.fragment == "" || anchors.names[ref.fragment] || strings.EqualFold(ref.fragment, "top") {
+		return
+	}
+	u := *ref.target
+	u.Fragment = ref.fragment
+	err := &FetchError{URL: &u, Referrer: ref.page, StatusCode: anchors.statusCode, Err: errors.Wrapf(ErrBrokenFragment, "%s has no element with the id or name %q", ref.target, ref.fragment)}
+	s.events.publish(ErrorOccurred{Err: err})
+	s.summary.Errors++
+	s.summary.host(&u).Errors++ // its status is counted with the page's
+}
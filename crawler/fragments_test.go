@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFragmentValidation(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/": {Body: `<html><body>
+			<h2 id="intro">Intro</h2>
+			<a href="#intro">Intro</a>
+			<a href="#outro">Outro</a>
+			<a href="#top">Top</a>
+			<a href="/docs#install">Install</a>
+			<a href="/docs#uninstall">Uninstall</a>
+			<a href="/docs#caf%C3%A9">Café</a>
+			<a href="/docs.pdf#page=2">PDF</a>
+			<a href="https://example.com/#missing">Elsewhere</a>
+		</body></html>`},
+		"/docs": {Body: `<html><body>
+			<section id="install"><a href="/#intro">Back</a><a href="/#missing">Missing</a></section>
+			<a name="café"></a>
+		</body></html>`},
+		"/docs.pdf": {Body: `%PDF-1.4`},
+	})
+	defer srv.Close()
+
+	var broken []string
+	c := New(1, srv.Client(), WithFragmentValidation(), WithLogger(newTestLogger(io.Discard)), WithSubscriber(func(e Event) {
+		if occurred, ok := e.(ErrorOccurred); ok {
+			fetchErr := occurred.Err.(*FetchError)
+			require.Equal(t, ErrBrokenFragment, errors.Cause(fetchErr))
+			require.Equal(t, ErrorClassFragment, errorClass(fetchErr))
+			broken = append(broken, fetchErr.URL.String()+" from "+fetchErr.Referrer.String())
+		}
+	}))
+	result, err := c.CrawlWithResult(context.Background(), []string{srv.URL + "/"}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	require.ElementsMatch(t, []string{
+		srv.URL + "/#outro from " + srv.URL + "/",
+		srv.URL + "/docs#uninstall from " + srv.URL + "/",
+		srv.URL + "/#missing from " + srv.URL + "/docs",
+	}, broken)
+	require.Equal(t, 3, result.Errors)
+}
@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"container/heap"
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Scorer scores a URL as it's enqueued, see WithPriority. It's given the number of links followed from a seed to reach
+// the URL and the page linking to it, which mustn't be modified, or nil for the seeds. URLs with higher scores are
+// crawled first.
+type Scorer func(u *url.URL, depth int, referrer *Page) int
+
+// ShorterPathsFirst is a Scorer crawling URLs with fewer path segments first, e.g. a site's sections before the
+// articles within them
+func ShorterPathsFirst(u *url.URL, depth int, referrer *Page) int {
+	path := strings.Trim(u.EscapedPath(), "/")
+	if path == "" {
+		return 0
+	}
+	return -strings.Count(path, "/") - 1
+}
+
+// ShallowestFirst is a Scorer crawling URLs fewer links from a seed first, i.e. breadth first
+func ShallowestFirst(u *url.URL, depth int, referrer *Page) int {
+	return -depth
+}
+
+// WithPriority hands the URLs waiting to be crawled to the workers highest scoring first, and in the order they were
+// found for equal scores, rather than in no particular order. The page budget set by WithMaxPages is then spent as
+// URLs are fetched rather than as they're found, so that a budgeted crawl fetches the highest scoring of the URLs it
+// knows of at each point, with those left once it's spent skipped as SkipOutscored. Pattern budgets are still spent
+// as URLs are found.
+func WithPriority(scorer Scorer) Option {
+	return func(c *crawler) {
+		c.scorer = scorer
+	}
+}
+
+// frontierURL is a URL waiting in a frontier
+type frontierURL struct {
+	url          *url.URL
+	score        int
+	seq          int // the order it was pushed in, breaking ties
+	ignoreBudget bool
+	index        int // its index in the heap
+}
+
+// frontierHeap is a heap of URLs, highest scoring and then earliest pushed first
+type frontierHeap []*frontierURL
+
+func (h frontierHeap) Len() int { return len(h) }
+
+func (h frontierHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h frontierHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *frontierHeap) Push(x any) {
+	u := x.(*frontierURL)
+	u.index = len(*h)
+	*h = append(*h, u)
+}
+
+func (h *frontierHeap) Pop() any {
+	old := *h
+	u := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return u
+}
+
+// pageBudget is the page budget shared by a crawl's frontiers, spent as URLs are handed to the workers
+type pageBudget struct {
+	mu    sync.Mutex
+	max   int // zero meaning unlimited
+	spent int
+}
+
+// spend records a URL being handed to a worker, returning false without recording it if the budget is exhausted and
+// the URL doesn't ignore it
+func (b *pageBudget) spend(ignoreBudget bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !ignoreBudget && b.max > 0 && b.spent >= b.max {
+		return false
+	}
+	b.spent++
+	return true
+}
+
+// refund returns what spend recorded for a URL which wasn't handed to a worker after all
+func (b *pageBudget) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent--
+}
+
+// frontier holds the URLs waiting for a worker, handing it the highest scoring whenever it's free, see WithPriority
+type frontier struct {
+	mu     sync.Mutex
+	urls   frontierHeap
+	pushed int
+	closed bool
+	notify chan struct{} // signalled when URLs are pushed or the frontier is closed
+	budget *pageBudget
+}
+
+func newFrontier(budget *pageBudget) *frontier {
+	return &frontier{notify: make(chan struct{}, 1), budget: budget}
+}
+
+// push adds URLs to the frontier together, so that the worker is handed the highest scoring of them rather than
+// whichever was pushed first
+func (f *frontier) push(urls ...*frontierURL) {
+	f.mu.Lock()
+	for _, u := range urls {
+		u.seq = f.pushed
+		f.pushed++
+		heap.Push(&f.urls, u)
+	}
+	f.mu.Unlock()
+	f.signal()
+}
+
+func (f *frontier) signal() {
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// close records that no more URLs will be pushed, once every URL enqueued has been handled
+func (f *frontier) close() {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	f.signal()
+}
+
+// peek returns the highest scoring URL, or nil if there are none, and whether the frontier is closed
+func (f *frontier) peek() (*frontierURL, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.urls) == 0 {
+		return nil, f.closed
+	}
+	return f.urls[0], f.closed
+}
+
+// remove removes a URL, which may no longer be the highest scoring
+func (f *frontier) remove(u *frontierURL) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	heap.Remove(&f.urls, u.index)
+}
+
+// feed hands the frontier's URLs to a worker over out until it's closed, when out is closed, sending those the page
+// budget has no room for to outscored instead
+func (f *frontier) feed(ctx context.Context, out chan<- *url.URL, outscored chan<- *url.URL) {
+	for {
+		next, closed := f.peek()
+		if next == nil {
+			if closed {
+				close(out)
+				return
+			}
+			select {
+			case <-f.notify:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		if !f.budget.spend(next.ignoreBudget) {
+			f.remove(next)
+			select {
+			case outscored <- next.url:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		select {
+		case out <- next.url:
+			f.remove(next)
+		case <-f.notify:
+			// a higher scoring URL may have been pushed while waiting for the worker
+			f.budget.refund()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
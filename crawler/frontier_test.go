@@ -0,0 +1,101 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScorers(t *testing.T) {
+	score := func(scorer Scorer, rawURL string, depth int) int {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return scorer(u, depth, nil)
+	}
+	require.Equal(t, 0, score(ShorterPathsFirst, "http://monzo.com", 0))
+	require.Equal(t, 0, score(ShorterPathsFirst, "http://monzo.com/?page=2", 3))
+	require.Equal(t, -1, score(ShorterPathsFirst, "http://monzo.com/blog/", 1))
+	require.Equal(t, -3, score(ShorterPathsFirst, "http://monzo.com/blog/2023/savings", 1))
+	require.Equal(t, -2, score(ShallowestFirst, "http://monzo.com/blog/", 2))
+}
+
+func TestFrontier(t *testing.T) {
+	f := newFrontier(&pageBudget{})
+	push := func(rawURL string, score int) {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		f.push(&frontierURL{url: u, score: score})
+	}
+	push("http://monzo.com/b", -1)
+	push("http://monzo.com/a", 5)
+	push("http://monzo.com/c", -1)
+	push("http://monzo.com/d", 2)
+	f.close()
+
+	out := make(chan *url.URL)
+	go f.feed(context.Background(), out, nil)
+	order := []string{}
+	for u := range out {
+		order = append(order, u.Path)
+	}
+	require.Equal(t, []string{"/a", "/d", "/b", "/c"}, order)
+}
+
+// prioritySite is a site whose pages are crawled in a different order by path length than by discovery
+var prioritySite = crawltest.Site{
+	"/":      {Body: `<a href="/a/b/c">C</a><a href="/z">Z</a><a href="/x/y">Y</a><a href="/x">X</a>`},
+	"/z":     {Body: `<a href="/z/1">1</a>`},
+	"/x":     {Body: `<a href="/">Home</a>`},
+	"/x/y":   {Body: `Y`},
+	"/z/1":   {Body: `1`},
+	"/a/b/c": {Body: `C`},
+}
+
+func TestWithPriority(t *testing.T) {
+	srv := crawltest.NewServer(prioritySite)
+	defer srv.Close()
+
+	order := []string{}
+	c := New(1, srv.Client(), WithPriority(ShorterPathsFirst), WithLogger(newTestLogger(io.Discard)), WithSubscriber(func(e Event) {
+		if started, ok := e.(FetchStarted); ok {
+			order = append(order, started.URL.Path)
+		}
+	}))
+	result, err := c.CrawlWithResult(context.Background(), []string{srv.URL + "/"}, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, 6, result.Pages)
+	require.Equal(t, []string{"/", "/z", "/x", "/x/y", "/z/1", "/a/b/c"}, order)
+}
+
+func TestWithPriorityMaxPages(t *testing.T) {
+	srv := crawltest.NewServer(prioritySite)
+	defer srv.Close()
+
+	crawled, outscored := []string{}, []string{}
+	var progress Progress
+	c := New(1, srv.Client(), WithPriority(ShorterPathsFirst), WithMaxPages(3), WithLogger(newTestLogger(io.Discard)),
+		WithProgress(time.Hour, func(p Progress) { progress = p }),
+		WithSubscriber(func(e Event) {
+			switch e := e.(type) {
+			case FetchStarted:
+				crawled = append(crawled, e.URL.Path)
+			case URLSkipped:
+				if e.Reason == SkipOutscored {
+					outscored = append(outscored, e.URL.Path)
+				}
+			}
+		}))
+	result, err := c.CrawlWithResult(context.Background(), []string{srv.URL + "/"}, io.Discard)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"/", "/z", "/x"}, crawled, "the budget should be spent on the shortest paths")
+	require.ElementsMatch(t, []string{"/x/y", "/z/1", "/a/b/c"}, outscored)
+	require.Equal(t, SkipMaxPages, result.Limit)
+	require.Equal(t, 3, result.Summary.Limited)
+	require.Equal(t, 0, progress.Queued)
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// WithHostAliases treats each alias, e.g. "example.com" or a CDN hostname, as the same site as host when deciding
+// whether a link is in scope and whether it has already been crawled
+func WithHostAliases(host string, aliases ...string) Option {
+	return func(c *crawler) {
+		if c.hostAliases == nil {
+			c.hostAliases = map[string]string{}
+		}
+		for _, alias := range aliases {
+			c.hostAliases[asciiHost(alias)] = asciiHost(host)
+		}
+	}
+}
+
+// canonicalHost returns the punycode form of the host which a hostname is an alias of, or of the hostname itself
+func (c *crawler) canonicalHost(hostname string) string {
+	hostname = asciiHost(hostname)
+	if host, ok := c.hostAliases[hostname]; ok {
+		return host
+	}
+	return hostname
+}
+
+// cacheKey returns the key identifying a URL when deduplicating, which is the same for URLs differing only by host
+// alias or by the Unicode and punycode forms of an internationalized domain name
+func (c *crawler) cacheKey(u *url.URL) string {
+	return c.canonicalURL(u).String()
+}
+
+// canonicalURL returns u with its host replaced by its canonical host, see canonicalHost
+func (c *crawler) canonicalURL(u *url.URL) *url.URL {
+	host := c.canonicalHost(u.Hostname())
+	if host == u.Hostname() {
+		return u
+	}
+
+	canonical := *u
+	canonical.Host = host
+	if port := u.Port(); port != "" {
+		canonical.Host += ":" + port
+	}
+	return &canonical
+}
+
+// asciiHost returns the lower case punycode form of an internationalized domain name, e.g. "bücher.example" becomes
+// "xn--bcher-kva.example". Hostnames which aren't valid IDNs are just lower cased.
+func asciiHost(hostname string) string {
+	if ascii, err := idna.Lookup.ToASCII(hostname); err == nil {
+		return ascii
+	}
+	return strings.ToLower(hostname)
+}
+
+// unicodeHost returns the Unicode form of a hostname for display, e.g. "xn--bcher-kva.example" becomes
+// "bücher.example"
+func unicodeHost(hostname string) string {
+	if unicode, err := idna.Display.ToUnicode(hostname); err == nil {
+		return unicode
+	}
+	return hostname
+}
+
+// displayURL formats a URL for display, showing an internationalized domain name in its Unicode form
+func displayURL(u *url.URL) string {
+	ascii, unicode := asciiHost(u.Hostname()), unicodeHost(u.Hostname())
+	if ascii == unicode {
+		return u.String()
+	}
+	if port := u.Port(); port != "" {
+		ascii += ":" + port
+		unicode += ":" + port
+	}
+
+	// url.URL percent encodes non-ASCII hosts, so format the punycode form and substitute the Unicode form back in
+	display := *u
+	display.Host = ascii
+	return strings.Replace(display.String(), "//"+ascii, "//"+unicode, 1)
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheKey(t *testing.T) {
+	c := New(1, nil, WithHostAliases("www.google.com", "google.com", "CDN.google.net")).(*crawler)
+
+	tests := []struct {
+		title, rawURL, expected string
+	}{
+		{"canonical", "http://www.google.com/test", "http://www.google.com/test"},
+		{"alias", "http://google.com/test?q=1", "http://www.google.com/test?q=1"},
+		{"alias with port", "http://google.com:8080/test", "http://www.google.com:8080/test"},
+		{"case insensitive alias", "https://cdn.google.net/test", "https://www.google.com/test"},
+		{"unrelated", "http://www.test.com/test", "http://www.test.com/test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			u, err := url.Parse(tt.rawURL)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, c.cacheKey(u))
+		})
+	}
+}
+
+func TestInternationalizedHosts(t *testing.T) {
+	c := New(1, nil).(*crawler)
+
+	unicode, err := url.Parse("http://Bücher.example/päth")
+	require.NoError(t, err)
+	punycode, err := url.Parse("http://xn--bcher-kva.example/päth")
+	require.NoError(t, err)
+
+	require.Equal(t, "xn--bcher-kva.example", c.canonicalHost(unicode.Hostname()))
+	require.Equal(t, c.cacheKey(punycode), c.cacheKey(unicode))
+
+	require.Equal(t, "http://bücher.example/p%C3%A4th", displayURL(punycode))
+	withPort, err := url.Parse("http://xn--bcher-kva.example:8080")
+	require.NoError(t, err)
+	require.Equal(t, "http://bücher.example:8080", displayURL(withPort))
+
+	ascii, err := url.Parse("http://www.google.com/test")
+	require.NoError(t, err)
+	require.Equal(t, "http://www.google.com/test", displayURL(ascii))
+}
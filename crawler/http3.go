@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+var ErrHTTP3Unsupported = errors.New("HTTP/3 requires an *http.Client")
+
+// http3HandshakeTimeout is how long to wait for a QUIC handshake before falling back to the client's own transport
+const http3HandshakeTimeout = 3 * time.Second
+
+// WithHTTP3 makes requests over HTTP/3 where a host supports it, falling back to the http client's own transport, e.g.
+// HTTP/2 or HTTP/1.1, for hosts which don't, with a warning. The protocol each page was fetched over is recorded on
+// Page.Protocol and counted in the Summary, to verify a site's HTTP/3 deployment. The http client must be an
+// *http.Client.
+func WithHTTP3() Option {
+	return func(c *crawler) {
+		c.http3 = true
+	}
+}
+
+// http3Transport makes requests over HTTP/3, falling back to another transport for hosts which can't be reached over
+// QUIC, or for plain http URLs
+type http3Transport struct {
+	h3       *http3.Transport
+	fallback http.RoundTripper
+	logger   *slog.Logger
+
+	mu            sync.Mutex
+	fallbackHosts map[string]bool
+}
+
+// newHTTP3Transport returns an HTTP/3 transport sharing fallback's TLS configuration, if it's an *http.Transport
+func newHTTP3Transport(fallback http.RoundTripper, logger *slog.Logger) *http3Transport {
+	h3 := &http3.Transport{QUICConfig: &quic.Config{HandshakeIdleTimeout: http3HandshakeTimeout}}
+	if t, ok := fallback.(*http.Transport); ok && t.TLSClientConfig != nil {
+		h3.TLSClientConfig = t.TLSClientConfig.Clone()
+	}
+	return &http3Transport{
+		h3:            h3,
+		fallback:      fallback,
+		logger:        logger,
+		fallbackHosts: map[string]bool{},
+	}
+}
+
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a request with a body can't be retried over the fallback once sent
+	if req.URL.Scheme != "https" || t.fellBack(req.URL.Host) || (req.Body != nil && req.Body != http.NoBody) {
+		return t.fallback.RoundTrip(req)
+	}
+
+	resp, err := t.h3.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+	if req.Context().Err() != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	if !t.fallbackHosts[req.URL.Host] {
+		t.fallbackHosts[req.URL.Host] = true
+		t.logger.Warn("HTTP/3 unavailable, falling back", "host", req.URL.Host, "error", err.Error())
+	}
+	t.mu.Unlock()
+	return t.fallback.RoundTrip(req)
+}
+
+// fellBack reports whether requests to a host are made over the fallback transport
+func (t *http3Transport) fellBack(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fallbackHosts[host]
+}
+
+// Close closes the QUIC connections of the transport
+func (t *http3Transport) Close() error {
+	return t.h3.Close()
+}
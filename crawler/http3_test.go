@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTP3(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/about"></a></body></html>`)
+		case "/about":
+			fmt.Fprint(w, `<html><body></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	t.Run("http3", func(t *testing.T) {
+		srv := httptest.NewTLSServer(handler)
+		defer srv.Close()
+
+		// serve HTTP/3 on the UDP port matching the TLS server's TCP port, as a CDN would
+		conn, err := net.ListenPacket("udp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		h3 := &http3.Server{Handler: handler, TLSConfig: http3.ConfigureTLSConfig(srv.TLS)}
+		go h3.Serve(conn)
+		defer h3.Close()
+
+		var out bytes.Buffer
+		summary := &Summary{}
+		c := New(1, srv.Client(), WithHTTP3(), WithSummary(summary), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URL+"/", &out))
+
+		require.Contains(t, out.String(), "Protocol:\n\tHTTP/3.0\n")
+		require.Equal(t, map[string]int{"HTTP/3.0": 2}, summary.Protocols)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		srv := httptest.NewUnstartedServer(handler)
+		srv.EnableHTTP2 = true
+		srv.StartTLS()
+		defer srv.Close()
+
+		var logs bytes.Buffer
+		summary := &Summary{}
+		c := New(1, srv.Client(), WithHTTP3(), WithSummary(summary), WithLogger(newTestLogger(&logs)))
+		require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+		require.Equal(t, map[string]int{"HTTP/2.0": 2}, summary.Protocols)
+		require.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("HTTP/3 unavailable, falling back")))
+	})
+
+	t.Run("plain http", func(t *testing.T) {
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+
+		summary := &Summary{}
+		c := New(1, srv.Client(), WithHTTP3(), WithSummary(summary))
+		require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+		require.Equal(t, map[string]int{"HTTP/1.1": 2}, summary.Protocols)
+	})
+
+	t.Run("unsupported client", func(t *testing.T) {
+		c := New(1, NewMockhttpClient(nil), WithHTTP3())
+		require.Equal(t, ErrHTTP3Unsupported, errors.Cause(c.Crawl("https://monzo.com/", &bytes.Buffer{})))
+	})
+}
@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"golang.org/x/net/html"
+)
+
+// Image is an <img> element of a page
+type Image struct {
+	URL    *url.URL
+	Alt    string
+	HasAlt bool   // set if the image has an alt attribute, which is empty for decorative images
+	Width  string // the declared width attribute without whitespace, e.g. "120", empty if it has none
+	Height string // the declared height attribute, empty if it has none
+}
+
+// WithImageInventory records the source, alt text and declared width and height of each of a page's images, in the
+// order they're on the page, on Page.Images, see Report.ImagesWithoutDimensions
+func WithImageInventory() Option {
+	return func(c *crawler) {
+		c.images = true
+	}
+}
+
+// collectImage records an <img> element with a source to be fetched
+func collectImage(page *Page, links *linkextract.Extractor, tag html.Token) {
+	src := attrVal(tag, "src")
+	if tag.Data != "img" || strings.TrimSpace(src) == "" {
+		return
+	}
+	u, err := links.Resolve(src)
+	if err != nil || u == nil {
+		return
+	}
+	page.images = append(page.images, Image{
+		URL:    u,
+		Alt:    strings.Join(strings.Fields(attrVal(tag, "alt")), " "),
+		HasAlt: hasAttr(tag, "alt"),
+		Width:  strings.Join(strings.Fields(attrVal(tag, "width")), ""),
+		Height: strings.Join(strings.Fields(attrVal(tag, "height")), ""),
+	})
+}
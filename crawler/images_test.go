@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageInventory(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/help/"}}
+	parsePage(page, bytes.NewBufferString(`<html><body>
+		<img src="/logo.png" alt=" Monzo
+			logo " width="120" height=" 40 ">
+		<img src="divider.png" alt="">
+		<img src="/hero.jpg" width="100%">
+		<img src="">
+		<img alt="No source">
+		<img src="data:image/png;base64,iVBORw0KGgo=" alt="Inline">
+	</body></html>`))
+
+	image := func(path string) *url.URL {
+		return &url.URL{Scheme: "https", Host: "monzo.com", Path: path}
+	}
+	require.Equal(t, []Image{
+		{URL: image("/logo.png"), Alt: "Monzo logo", HasAlt: true, Width: "120", Height: "40"},
+		{URL: image("/help/divider.png"), HasAlt: true},
+		{URL: image("/hero.jpg"), Width: "100%"},
+	}, page.images)
+
+	t.Run("crawl", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":      {Body: `<img src="/logo.png" width="120" height="40"><img src="/hero.jpg" alt="Hero"><a href="/about">About</a>`},
+			"/about": {Body: `<img src="/hero.jpg"><img src="/hero.jpg"><img src="/team.jpg" height="300">`},
+		})
+		defer srv.Close()
+
+		for _, enabled := range []bool{false, true} {
+			report := &Report{}
+			out := &bytes.Buffer{}
+			opts := []Option{WithReport(report), WithLogger(newTestLogger(io.Discard))}
+			if enabled {
+				opts = append(opts, WithImageInventory())
+			}
+			require.NoError(t, New(1, srv.Client(), opts...).Crawl(srv.URL+"/", out))
+
+			if !enabled {
+				require.NotContains(t, out.String(), "Images:")
+				require.Empty(t, report.ImagesWithoutDimensions())
+				continue
+			}
+			require.Contains(t, out.String(), "Images:\n\t"+srv.URL+"/logo.png: width=120 height=40\n\t"+srv.URL+`/hero.jpg: alt="Hero"`+"\n")
+			require.Equal(t, []ImageUsage{
+				{URL: srv.URL + "/hero.jpg", Pages: 2, Examples: []string{srv.URL + "/", srv.URL + "/about"}},
+				{URL: srv.URL + "/team.jpg", Pages: 1, Examples: []string{srv.URL + "/about"}},
+			}, report.ImagesWithoutDimensions())
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package crawler
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// WithJitter makes each worker wait a random delay of between min and max before each request, on top of any other
+// limits on the crawl's pace, so that its traffic doesn't form the perfectly regular patterns which trip WAF heuristics
+func WithJitter(min, max time.Duration) Option {
+	return func(c *crawler) {
+		if max < min {
+			max = min
+		}
+		c.jitterMin, c.jitterMax = min, max
+	}
+}
+
+// jitter waits a random delay within the bounds given to WithJitter, reporting false if ctx is done first
+func (c *crawler) jitter(ctx context.Context) bool {
+	if c.jitterMax <= 0 {
+		return ctx.Err() == nil
+	}
+	delay := c.jitterMin + time.Duration(rand.Int63n(int64(c.jitterMax-c.jitterMin)+1))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
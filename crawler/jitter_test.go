@@ -0,0 +1,41 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJitter(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		c := New(1, nil).(*crawler)
+		start := time.Now()
+		require.True(t, c.jitter(context.Background()))
+		require.True(t, time.Since(start) < 10*time.Millisecond)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		c := New(1, nil, WithJitter(5*time.Millisecond, 15*time.Millisecond)).(*crawler)
+		for i := 0; i < 5; i++ {
+			start := time.Now()
+			require.True(t, c.jitter(context.Background()))
+			elapsed := time.Since(start)
+			require.True(t, elapsed >= 5*time.Millisecond, "waited %s", elapsed)
+			require.True(t, elapsed < 100*time.Millisecond, "waited %s", elapsed)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		c := New(1, nil, WithJitter(time.Hour, time.Hour)).(*crawler)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.False(t, c.jitter(ctx))
+	})
+
+	t.Run("max below min", func(t *testing.T) {
+		c := New(1, nil, WithJitter(time.Second, time.Millisecond)).(*crawler)
+		require.Equal(t, time.Second, c.jitterMax)
+	})
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords maps language codes to common words which are rarely used in the other listed languages
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "with", "you", "this", "are", "was", "for"},
+	"fr": {"le", "les", "et", "des", "est", "une", "pour", "dans", "qui", "pas", "sur", "avec", "vous"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "sich", "auf", "für", "ein", "eine", "wir"},
+	"es": {"el", "los", "las", "y", "por", "del", "está", "pero", "como", "muy", "también"},
+	"it": {"il", "di", "che", "sono", "gli", "della", "questo", "anche", "nel", "è"},
+	"pt": {"os", "do", "da", "em", "um", "não", "uma", "são", "mais", "pelo", "com"},
+	"nl": {"het", "een", "van", "dat", "niet", "op", "zijn", "voor", "ook", "maar", "wij"},
+}
+
+// minLanguageEvidence is the number of stopwords which must be seen before a language is guessed
+const minLanguageEvidence = 5
+
+var stopwordLanguages = func() map[string]string {
+	m := map[string]string{}
+	for lang, words := range stopwords {
+		for _, word := range words {
+			m[word] = lang
+		}
+	}
+	return m
+}()
+
+// languageDetector guesses the language of a text by counting the stopwords of each language found in it
+type languageDetector struct {
+	counts map[string]int
+}
+
+func (d *languageDetector) addText(text string) {
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if lang, ok := stopwordLanguages[strings.ToLower(word)]; ok {
+			if d.counts == nil {
+				d.counts = map[string]int{}
+			}
+			d.counts[lang]++
+		}
+	}
+}
+
+// detect returns the most likely language, or an empty string if there's too little evidence or no clear winner
+func (d *languageDetector) detect() string {
+	best, bestCount, total, tied := "", 0, 0, false
+	for lang, count := range d.counts {
+		total += count
+		switch {
+		case count > bestCount:
+			best, bestCount, tied = lang, count, false
+		case count == bestCount:
+			tied = true
+		}
+	}
+	if total < minLanguageEvidence || tied {
+		return ""
+	}
+	return best
+}
+
+// normalizeLanguage lower cases a language tag and replaces underscores, e.g. "en_GB" becomes "en-gb"
+func normalizeLanguage(lang string) string {
+	return strings.Replace(strings.ToLower(strings.TrimSpace(lang)), "_", "-", -1)
+}
@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageDetector(t *testing.T) {
+	tests := []struct {
+		title, text, expected string
+	}{
+		{
+			"empty",
+			"",
+			"",
+		},
+		{
+			"too little evidence",
+			"the cat",
+			"",
+		},
+		{
+			"english",
+			"The quick brown fox jumps over the lazy dog and that is all there is to this story.",
+			"en",
+		},
+		{
+			"german",
+			"Der Hund und die Katze sind nicht mit uns auf der Straße, das ist eine Schande.",
+			"de",
+		},
+		{
+			"spanish",
+			"El perro y los gatos están en la casa, pero también hay muy pocos pájaros por aquí.",
+			"es",
+		},
+		{
+			"tied",
+			"the and of der die das",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			var d languageDetector
+			d.addText(tt.text)
+			require.Equal(t, tt.expected, d.detect())
+		})
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	require.Equal(t, "en-gb", normalizeLanguage(" en_GB "))
+	require.Equal(t, "fr", normalizeLanguage("FR"))
+	require.Equal(t, "", normalizeLanguage(""))
+}
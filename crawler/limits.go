@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrURLLimit = errors.New("URL exceeds limit")
+
+// URLLimits caps the shape of URLs which are crawled, guarding against pathological generated URLs flooding the
+// crawl. Zero values are unlimited.
+type URLLimits struct {
+	MaxLength       int // the length of the whole URL
+	MaxPathSegments int // the number of non-empty path segments
+	MaxQueryParams  int // the number of query parameters
+}
+
+// WithURLLimits skips, and reports, links exceeding any of the given limits
+func WithURLLimits(l URLLimits) Option {
+	return func(c *crawler) {
+		c.urlLimits = l
+	}
+}
+
+// check returns an error describing the first limit a URL exceeds, if any
+func (l URLLimits) check(u *url.URL) error {
+	if n := len(u.String()); l.MaxLength > 0 && n > l.MaxLength {
+		return errors.Wrapf(ErrURLLimit, "length %d is greater than %d", n, l.MaxLength)
+	}
+
+	if l.MaxPathSegments > 0 {
+		segments := 0
+		for _, segment := range strings.Split(u.EscapedPath(), "/") {
+			if segment != "" {
+				segments++
+			}
+		}
+		if segments > l.MaxPathSegments {
+			return errors.Wrapf(ErrURLLimit, "%d path segments is greater than %d", segments, l.MaxPathSegments)
+		}
+	}
+
+	if l.MaxQueryParams > 0 {
+		params := 0
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			if param != "" {
+				params++
+			}
+		}
+		if params > l.MaxQueryParams {
+			return errors.Wrapf(ErrURLLimit, "%d query parameters is greater than %d", params, l.MaxQueryParams)
+		}
+	}
+
+	return nil
+}
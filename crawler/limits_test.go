@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLLimits(t *testing.T) {
+	limits := URLLimits{MaxLength: 40, MaxPathSegments: 3, MaxQueryParams: 2}
+
+	tests := []struct {
+		title, rawURL string
+		exceeded      bool
+	}{
+		{"within limits", "http://www.google.com/a/b/c?d=1&e=2", false},
+		{"unlimited", "http://www.google.com", false},
+		{"too long", "http://www.google.com/abcdefghijklmnopqrstuvwxyz", true},
+		{"too many path segments", "http://www.google.com/a/b/c/d", true},
+		{"empty path segments ignored", "http://www.google.com//a//b/", false},
+		{"too many query params", "http://www.google.com?a=1&b=2&c=3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			u, err := url.Parse(tt.rawURL)
+			require.NoError(t, err)
+
+			err = limits.check(u)
+			if tt.exceeded {
+				require.Equal(t, ErrURLLimit, errors.Cause(err))
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, URLLimits{}.check(u))
+		})
+	}
+}
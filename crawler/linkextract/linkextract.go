@@ -0,0 +1,227 @@
+// Package linkextract extracts the links from HTML documents, resolving them against the document's URL
+package linkextract
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Link is a link found in a document
+type Link struct {
+	URL     *url.URL // resolved against the document's URL, or its base href if WithBaseHref is given
+	Element string   // the element the link was found on, e.g. "a"
+	Attr    string   // the attribute the link was the value of, e.g. "href"
+	Rel     []string // the lower cased values of the element's rel attribute
+}
+
+// LinkError describes a link which couldn't be parsed
+type LinkError struct {
+	Raw     string // the attribute's value
+	Element string
+	Attr    string
+	Err     error
+}
+
+func (e *LinkError) Error() string {
+	return fmt.Sprintf("malformed %s %s %q: %s", e.Element, e.Attr, e.Raw, e.Err)
+}
+
+// Result is the links extracted from a document
+type Result struct {
+	Links     []Link
+	Malformed []*LinkError
+}
+
+// Elements maps each element links are extracted from to the attributes holding them, e.g. "a" to "href"
+type Elements map[string][]string
+
+// DefaultElements are the elements links are extracted from unless WithElements is given
+var DefaultElements = Elements{"a": {"href"}}
+
+// Option configures an Extractor
+type Option func(*options)
+
+type options struct {
+	elements     Elements
+	schemes      map[string]bool
+	baseHref     bool
+	keepFragment bool
+}
+
+// WithElements extracts links from the given elements and attributes instead of DefaultElements. Names are matched
+// case insensitively, as HTML's are.
+func WithElements(elements Elements) Option {
+	return func(o *options) {
+		o.elements = Elements{}
+		for element, attrs := range elements {
+			WithElement(element, attrs...)(o)
+		}
+	}
+}
+
+// WithElement extracts links from the given attributes of an element, in addition to the other elements configured
+func WithElement(element string, attrs ...string) Option {
+	element = strings.ToLower(element) // once, as options are applied by every worker at once
+	return func(o *options) {
+		for _, attr := range attrs {
+			o.elements[element] = append(o.elements[element], strings.ToLower(attr))
+		}
+	}
+}
+
+// WithSchemes extracts links with the given schemes rather than only http and https ones
+func WithSchemes(schemes ...string) Option {
+	return func(o *options) {
+		o.schemes = map[string]bool{}
+		for _, scheme := range schemes {
+			o.schemes[strings.ToLower(scheme)] = true
+		}
+	}
+}
+
+// WithBaseHref resolves links found after a <base href> element against its URL, as browsers do, rather than the
+// document's
+func WithBaseHref() Option {
+	return func(o *options) {
+		o.baseHref = true
+	}
+}
+
+// WithKeepFragment keeps the fragments of links, which are otherwise stripped as they refer to the same document
+func WithKeepFragment() Option {
+	return func(o *options) {
+		o.keepFragment = true
+	}
+}
+
+// Extractor extracts the links of a single document token by token, for callers tokenizing the document themselves
+type Extractor struct {
+	base *url.URL
+	opts options
+}
+
+// New returns an extractor for the document at base
+func New(base *url.URL, opts ...Option) *Extractor {
+	o := options{
+		elements: Elements{},
+		schemes:  map[string]bool{"http": true, "https": true},
+	}
+	for element, attrs := range DefaultElements {
+		o.elements[element] = append([]string{}, attrs...)
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Extractor{base: base, opts: o}
+}
+
+// Token returns the links of a token, and any of them which are malformed. Only start and self closing tags have
+// links, so other tokens are ignored.
+func (e *Extractor) Token(t html.Token) ([]Link, []*LinkError) {
+	if t.Type != html.StartTagToken && t.Type != html.SelfClosingTagToken {
+		return nil, nil
+	}
+	// the tokenizer lower cases names when it can, but not those of foreign elements, e.g. in SVG
+	t.Data = strings.ToLower(t.Data)
+
+	if e.opts.baseHref && t.Data == "base" {
+		e.setBase(t)
+		return nil, nil
+	}
+
+	attrs, ok := e.opts.elements[t.Data]
+	if !ok {
+		return nil, nil
+	}
+
+	var rel []string
+	if v, ok := attrVal(t, "rel"); ok {
+		rel = strings.Fields(strings.ToLower(v))
+	}
+
+	var links []Link
+	var malformed []*LinkError
+	for _, attr := range attrs {
+		// browsers use the first of any duplicate attributes, ignoring the rest
+		raw, ok := attrVal(t, attr)
+		if !ok {
+			continue
+		}
+		u, err := e.Resolve(raw)
+		if err != nil {
+			malformed = append(malformed, &LinkError{Raw: raw, Element: t.Data, Attr: attr, Err: err})
+			continue
+		}
+		if u != nil {
+			links = append(links, Link{URL: u, Element: t.Data, Attr: attr, Rel: rel})
+		}
+	}
+	return links, malformed
+}
+
+// Resolve resolves a link against the document's URL and strips its fragment unless WithKeepFragment is given. It
+// returns nil for links whose scheme isn't extracted, and an error if the link is malformed. Leading and trailing
+// whitespace, and tabs and newlines anywhere, are removed first, as browsers do.
+func (e *Extractor) Resolve(rawURL string) (*url.URL, error) {
+	u, err := e.base.Parse(cleanURL(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	if !e.opts.schemes[u.Scheme] {
+		return nil, nil
+	}
+	if !e.opts.keepFragment {
+		u.Fragment = "" // strip anchors to avoid crawling the same page twice...
+	}
+	return u, nil
+}
+
+// setBase resolves links found after a base element against its href, if it's valid
+func (e *Extractor) setBase(t html.Token) {
+	if href, ok := attrVal(t, "href"); ok {
+		if base, err := e.base.Parse(cleanURL(href)); err == nil {
+			e.base = base
+		}
+	}
+}
+
+// cleanURL strips the whitespace browsers ignore in URLs
+func cleanURL(rawURL string) string {
+	rawURL = strings.Trim(rawURL, " \t\n\f\r")
+	return strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(rawURL)
+}
+
+// Extract reads a document at base, returning its links
+func Extract(r io.Reader, base *url.URL, opts ...Option) (*Result, error) {
+	e := New(base, opts...)
+	result := &Result{}
+
+	t := html.NewTokenizer(r)
+	for {
+		switch t.Next() {
+		case html.ErrorToken:
+			if err := t.Err(); err != io.EOF {
+				return result, err
+			}
+			return result, nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			links, malformed := e.Token(t.Token())
+			result.Links = append(result.Links, links...)
+			result.Malformed = append(result.Malformed, malformed...)
+		}
+	}
+}
+
+// attrVal returns the value of the first of a tag's attributes with the given name, matched case insensitively
+func attrVal(t html.Token, key string) (string, bool) {
+	for _, attr := range t.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
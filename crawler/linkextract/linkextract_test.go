@@ -0,0 +1,213 @@
+package linkextract
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestExtract(t *testing.T) {
+	base, err := url.Parse("http://www.test.com/blog/post")
+	require.NoError(t, err)
+
+	doc := `<html><head><base href="/docs/"><link rel="stylesheet" href="/style.css"></head><body>
+		<a href="one#section" rel="Next Nofollow">one</a>
+		<a href="mailto:test@test.com">mail</a>
+		<a href="http://[::1">broken</a>
+		<img src="/logo.png">
+		<p>not a link</p>
+	</body></html>`
+
+	urls := func(links []Link) []string {
+		out := []string{}
+		for _, link := range links {
+			out = append(out, link.URL.String())
+		}
+		return out
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		result, err := Extract(strings.NewReader(doc), base)
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://www.test.com/blog/one"}, urls(result.Links))
+		require.Equal(t, "a", result.Links[0].Element)
+		require.Equal(t, "href", result.Links[0].Attr)
+		require.Equal(t, []string{"next", "nofollow"}, result.Links[0].Rel)
+
+		require.Len(t, result.Malformed, 1)
+		require.Equal(t, "http://[::1", result.Malformed[0].Raw)
+		require.Contains(t, result.Malformed[0].Error(), `malformed a href "http://[::1"`)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		result, err := Extract(strings.NewReader(doc), base,
+			WithElement("IMG", "SRC"), WithSchemes("http", "mailto"), WithBaseHref(), WithKeepFragment())
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"http://www.test.com/docs/one#section",
+			"mailto:test@test.com",
+			"http://www.test.com/logo.png",
+		}, urls(result.Links))
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		readErr := errors.New("connection reset")
+		result, err := Extract(io.MultiReader(strings.NewReader(`<a href="/one">`), &errReader{readErr}), base)
+		require.Equal(t, readErr, err)
+		require.Equal(t, []string{"http://www.test.com/one"}, urls(result.Links))
+	})
+}
+
+func TestExtractMessyHTML(t *testing.T) {
+	base, err := url.Parse("http://www.test.com/")
+	require.NoError(t, err)
+
+	tests := []struct {
+		title    string
+		html     string
+		opts     []Option
+		expected []string
+	}{
+		{"upper case tags and attributes", `<A HREF="/one">one</A>`, nil, []string{"http://www.test.com/one"}},
+		{"foreign elements", `<svg><A HREF="/one"></A></svg>`, nil, []string{"http://www.test.com/one"}},
+		{"duplicate attributes", `<a href="/first" href="/second">`, nil, []string{"http://www.test.com/first"}},
+		{"end tag attributes", `<a>text</a href="/one">`, nil, []string{}},
+		{"unquoted attributes", `<a href=/one>one</a>`, nil, []string{"http://www.test.com/one"}},
+		{"whitespace", "<a href=\"\n\t /one\n\t/two \">", nil, []string{"http://www.test.com/one/two"}},
+		{"self closing", `<a href="/one"/>`, nil, []string{"http://www.test.com/one"}},
+		{"unterminated", `<a href="/one">one<a href="/two`, nil, []string{"http://www.test.com/one"}},
+		{"comments", `<!-- <a href="/one"> -->`, nil, []string{}},
+		{"scripts", `<script>document.write('<a href="/one">')</script>`, nil, []string{}},
+		{"no href", `<a name="top">`, nil, []string{}},
+		{"javascript", `<a href="javascript:void(0)">`, nil, []string{}},
+		{
+			"configured elements",
+			`<a href="/one"><iframe SRC="/two"></iframe><area href="/three">`,
+			[]Option{WithElements(Elements{"IFRAME": {"src"}, "area": {"HREF"}})},
+			[]string{"http://www.test.com/two", "http://www.test.com/three"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			result, err := Extract(strings.NewReader(tt.html), base, tt.opts...)
+			require.NoError(t, err)
+			urls := []string{}
+			for _, link := range result.Links {
+				urls = append(urls, link.URL.String())
+			}
+			require.Equal(t, tt.expected, urls)
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	base, err := url.Parse("http://www.test.com/")
+	require.NoError(t, err)
+	e := New(base)
+
+	links, _ := e.Token(html.Token{Type: html.EndTagToken, Data: "a", Attr: []html.Attribute{{Key: "href", Val: "/one"}}})
+	require.Empty(t, links)
+
+	links, _ = e.Token(html.Token{Type: html.StartTagToken, Data: "A", Attr: []html.Attribute{{Key: "HREF", Val: "/one"}}})
+	require.Len(t, links, 1)
+	require.Equal(t, "a", links[0].Element)
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestResolve(t *testing.T) {
+	dummyURL, err := url.Parse("http://www.google.com/one/two")
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		tests := []struct {
+			title, rawURL, expected string
+		}{
+			{
+				"absolute",
+				"http://www.test.com",
+				"http://www.test.com",
+			},
+			{
+				"relative",
+				"test",
+				"http://www.google.com/one/test",
+			},
+			{
+				"relative parent",
+				"../../test",
+				"http://www.google.com/test",
+			},
+			{
+				"root",
+				"/test",
+				"http://www.google.com/test",
+			},
+			{
+				"anchor",
+				"#test",
+				"http://www.google.com/one/two",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				result, err := New(dummyURL).Resolve(tt.rawURL)
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, result.String())
+			})
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := []struct {
+			title, rawURL string
+		}{
+			{
+				"mailto",
+				"mailto:test@test.com",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				result, err := New(dummyURL).Resolve(tt.rawURL)
+				require.NoError(t, err)
+				require.Nil(t, result)
+			})
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		tests := []struct {
+			title, rawURL string
+		}{
+			{"unclosed ipv6 host", "http://[::1"},
+			{"invalid escape", "/%zz"},
+			{"space in host", "http://www.te st.com/"},
+			{"control character", "/one\x7f"},
+			{"invalid port", "http://www.test.com:port/"},
+			{"missing scheme", "://www.test.com"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				result, err := New(dummyURL).Resolve(tt.rawURL)
+				require.Error(t, err)
+				require.Nil(t, result)
+			})
+		}
+	})
+}
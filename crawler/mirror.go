@@ -0,0 +1,182 @@
+package crawler
+
+import (
+	"bytes"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+)
+
+// mirrorAssets are the elements whose links are followed when mirroring, in addition to those otherwise extracted, so
+// that the mirror includes each page's images, scripts and stylesheets
+var mirrorAssets = linkextract.Elements{
+	"img":    {"src"},
+	"script": {"src"},
+	"link":   {"href"},
+	"source": {"src"},
+	"iframe": {"src"},
+}
+
+// WithMirror saves every page and asset crawled in scope beneath dir, in a directory per host, and once the crawl is
+// over rewrites the links between them to relative paths, producing a copy of the site which can be browsed offline.
+// Links to pages which weren't saved, e.g. out of scope ones, are left as they are. Images, scripts and stylesheets
+// are crawled as well as links when mirroring.
+func WithMirror(dir string) Option {
+	return func(c *crawler) {
+		c.mirrorDir = dir
+		for element, attrs := range mirrorAssets {
+			c.linkOpts = append(c.linkOpts, linkextract.WithElement(element, attrs...))
+		}
+	}
+}
+
+// mirror holds the state of mirroring a single crawl
+type mirror struct {
+	dir      string
+	linkOpts []linkextract.Option
+	files    map[string]string // the file each page was saved to, by the URLs it was requested and found at
+	html     []mirroredPage    // the HTML pages saved, whose links are rewritten once the crawl is over
+}
+
+// mirroredPage is an HTML page saved to the mirror
+type mirroredPage struct {
+	url  *url.URL // the URL the page was found at, which its links are relative to
+	file string
+}
+
+func newMirror(dir string, linkOpts []linkextract.Option) *mirror {
+	return &mirror{
+		dir:      dir,
+		linkOpts: linkOpts,
+		files:    map[string]string{},
+	}
+}
+
+// save writes a page's body to the mirror, if it was fetched successfully
+func (m *mirror) save(page *Page) error {
+	if page.StatusCode < 200 || page.StatusCode >= 300 || page.body == nil {
+		return nil
+	}
+	found := page.base()
+	mediaType, _, _ := mime.ParseMediaType(page.contentType)
+	isHTML := mediaType == "text/html" || mediaType == "application/xhtml+xml"
+
+	file := filepath.Join(m.dir, mirrorPath(found, isHTML))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return errors.Wrapf(err, "mirroring %s", displayURL(found))
+	}
+	if err := os.WriteFile(file, page.body, 0o644); err != nil {
+		return errors.Wrapf(err, "mirroring %s", displayURL(found))
+	}
+
+	m.files[mirrorKey(page.URL)] = file
+	m.files[mirrorKey(found)] = file
+	if isHTML {
+		m.html = append(m.html, mirroredPage{url: found, file: file})
+	}
+	return nil
+}
+
+// rewriteLinks rewrites the links of each HTML page saved to others saved as relative paths between their files
+func (m *mirror) rewriteLinks() error {
+	for _, page := range m.html {
+		body, err := os.ReadFile(page.file)
+		if err != nil {
+			return errors.Wrapf(err, "rewriting links of %s", displayURL(page.url))
+		}
+		if err := os.WriteFile(page.file, m.rewrite(page, body), 0o644); err != nil {
+			return errors.Wrapf(err, "rewriting links of %s", displayURL(page.url))
+		}
+	}
+	m.html = nil
+	return nil
+}
+
+// rewrite returns a page's body with the links to other pages saved rewritten, leaving the rest of it untouched
+func (m *mirror) rewrite(page mirroredPage, body []byte) []byte {
+	var out bytes.Buffer
+	links := linkextract.New(page.url, m.linkOpts...)
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// the tokenizer stops at the first error, so keep whatever it didn't get to
+			out.Write(body[len(body)-len(z.Buffered()):])
+			return out.Bytes()
+		}
+		raw := z.Raw()
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			out.Write(raw)
+			continue
+		}
+
+		t := z.Token()
+		found, _ := links.Token(t)
+		rewritten := false
+		for _, link := range found {
+			file, ok := m.files[mirrorKey(link.URL)]
+			if !ok {
+				continue
+			}
+			rel, err := filepath.Rel(filepath.Dir(page.file), file)
+			if err != nil {
+				continue
+			}
+			for i := range t.Attr {
+				if strings.ToLower(t.Attr[i].Key) != link.Attr {
+					continue
+				}
+				value := filepath.ToSlash(rel)
+				if j := strings.Index(t.Attr[i].Val, "#"); j >= 0 {
+					value += t.Attr[i].Val[j:]
+				}
+				t.Attr[i].Val = value
+				rewritten = true
+				break // browsers use the first of any duplicate attributes
+			}
+		}
+		if rewritten {
+			out.WriteString(t.String())
+		} else {
+			out.Write(raw)
+		}
+	}
+}
+
+// mirrorKey returns the key of a URL in the files saved to a mirror
+func mirrorKey(u *url.URL) string {
+	withoutFragment := *u
+	withoutFragment.Fragment = ""
+	return withoutFragment.String()
+}
+
+// mirrorPath returns the path, relative to the mirror's directory, a page is saved to: its host followed by its path,
+// with index.html for directories, and .html for HTML pages without an extension so that they open in a browser and
+// don't clash with directories of the same name. A query is kept in the file's name after an @, as wget does.
+func mirrorPath(u *url.URL, isHTML bool) string {
+	p := path.Clean("/" + u.Path) // no escaping the host's directory
+	if strings.HasSuffix(u.Path, "/") || p == "/" {
+		p = path.Join(p, "index.html")
+	}
+	ext := path.Ext(p)
+	if isHTML && ext == "" {
+		ext = ".html"
+	} else {
+		p = strings.TrimSuffix(p, ext)
+	}
+	if u.RawQuery != "" {
+		p += "@" + strings.NewReplacer("/", "%2F", "\\", "%5C").Replace(u.RawQuery)
+	}
+	host := u.Host
+	if host == "" {
+		host = "localhost"
+	}
+	return filepath.Join(strings.NewReplacer(":", "_").Replace(host), filepath.FromSlash(p+ext))
+}
@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlMirror(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":           {Body: `<html><head><link rel="stylesheet" href="/style.css"></head><body><img src="logo.png"><a href="/about">About</a> <a href="/docs/#intro">Docs</a> <a href="/old">Old</a> <a href="http://example.invalid/">Elsewhere</a></body></html>`},
+		"/about":      {Title: "About", Links: []string{"/"}},
+		"/docs/":      {Links: []string{"../about", "guide?page=2"}},
+		"/docs/guide": {Title: "Guide"},
+		"/old":        {RedirectTo: "/about"},
+		"/style.css":  {Body: "body { color: red }", Header: http.Header{"Content-Type": {"text/css"}}},
+		"/logo.png":   {Body: "PNG", Header: http.Header{"Content-Type": {"image/png"}}},
+	})
+	defer srv.Close()
+
+	dir := t.TempDir()
+	c := New(2, srv.Client(), WithMirror(dir), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URLFor("/"), &bytes.Buffer{}))
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	site := filepath.Join(dir, strings.Replace(u.Host, ":", "_", 1))
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(site, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	require.Equal(t, "body { color: red }", read("style.css"))
+	require.Equal(t, "PNG", read("logo.png"))
+	require.Equal(t, `<html><head><link rel="stylesheet" href="style.css"></head><body><img src="logo.png"><a href="about.html">About</a> <a href="docs/index.html#intro">Docs</a> <a href="about.html">Old</a> <a href="http://example.invalid/">Elsewhere</a></body></html>`, read("index.html"))
+	require.Contains(t, read("about.html"), `<a href="index.html">`)
+	require.Contains(t, read("docs/index.html"), `<a href="../about.html">`)
+	require.Contains(t, read("docs/index.html"), `<a href="guide@page=2.html">`)
+	require.Contains(t, read("docs/guide@page=2.html"), "<title>Guide</title>")
+}
+
+func TestMirrorPath(t *testing.T) {
+	for _, tt := range []struct {
+		url    string
+		isHTML bool
+		path   string
+	}{
+		{"http://monzo.com", true, "monzo.com/index.html"},
+		{"http://monzo.com/", true, "monzo.com/index.html"},
+		{"http://monzo.com/blog/", true, "monzo.com/blog/index.html"},
+		{"http://monzo.com/blog", true, "monzo.com/blog.html"},
+		{"http://monzo.com/blog.php", true, "monzo.com/blog.php"},
+		{"http://monzo.com/blog?page=2", true, "monzo.com/blog@page=2.html"},
+		{"http://monzo.com/feed", false, "monzo.com/feed"},
+		{"http://monzo.com/app.js?v=1", false, "monzo.com/app@v=1.js"},
+		{"http://monzo.com/search?q=a/b", true, "monzo.com/search@q=a%2Fb.html"},
+		{"http://localhost:8080/../../etc/passwd", false, "localhost_8080/etc/passwd"},
+	} {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+			require.Equal(t, filepath.FromSlash(tt.path), mirrorPath(u, tt.isHTML))
+		})
+	}
+}
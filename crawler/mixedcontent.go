@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"net/url"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+)
+
+// mixedContentElements are the elements whose URLs a browser loads along with the page, so which are mixed content if
+// they're http:// URLs of an https:// page
+var mixedContentElements = linkextract.Elements{
+	"img":    {"src"},
+	"script": {"src"},
+	"iframe": {"src"},
+	"link":   {"href"},
+	"source": {"src"},
+	"video":  {"src", "poster"},
+	"audio":  {"src"},
+	"track":  {"src"},
+	"object": {"data"},
+	"embed":  {"src"},
+}
+
+// mixedContentRels are the rels of link elements which load a resource, rather than only pointing to another page
+var mixedContentRels = map[string]bool{
+	"stylesheet":       true,
+	"icon":             true,
+	"apple-touch-icon": true,
+	"manifest":         true,
+	"preload":          true,
+	"modulepreload":    true,
+	"prefetch":         true,
+}
+
+// MixedContent is an http:// URL loaded by an https:// page, e.g. an image or script, which browsers block or warn
+// about
+type MixedContent struct {
+	Element string // e.g. "img"
+	URL     *url.URL
+}
+
+// WithMixedContentDetection records the http:// images, scripts, iframes, stylesheets and other assets referenced by
+// each https:// page on Page.MixedContent
+func WithMixedContentDetection() Option {
+	return func(c *crawler) {
+		c.mixedContent = true
+	}
+}
+
+// findMixedContent returns the http:// assets of a page, if the page was fetched over https
+func findMixedContent(page *Page) []MixedContent {
+	if page.base().Scheme != "https" {
+		return nil
+	}
+
+	var mixed []MixedContent
+	seen := map[string]bool{}
+	for _, link := range page.assets {
+		if _, ok := mixedContentElements[link.Element]; !ok || link.URL.Scheme != "http" || (link.Element == "link" && !hasResourceRel(link.Rel)) {
+			continue
+		}
+		if key := link.Element + " " + link.URL.String(); !seen[key] {
+			seen[key] = true
+			mixed = append(mixed, MixedContent{Element: link.Element, URL: link.URL})
+		}
+	}
+	return mixed
+}
+
+// hasResourceRel reports whether any of a link element's rels load a resource
+func hasResourceRel(rels []string) bool {
+	for _, rel := range rels {
+		if mixedContentRels[rel] {
+			return true
+		}
+	}
+	return false
+}
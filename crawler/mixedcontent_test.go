@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindMixedContent(t *testing.T) {
+	body := []byte(`<html><head>
+		<link rel="stylesheet" href="http://cdn.monzo.com/style.css">
+		<link rel="canonical" href="http://monzo.com/">
+		<script src="http://cdn.monzo.com/app.js"></script>
+		<script src="//cdn.monzo.com/protocol-relative.js"></script>
+	</head><body>
+		<a href="http://example.com/">not loaded</a>
+		<img src="/relative.png">
+		<img src="http://cdn.monzo.com/logo.png">
+		<img src="http://cdn.monzo.com/logo.png">
+		<iframe src="http://example.com/embed"></iframe>
+		<video src="https://cdn.monzo.com/video.mp4" poster="http://cdn.monzo.com/poster.jpg"></video>
+	</body></html>`)
+
+	t.Run("https", func(t *testing.T) {
+		page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/"}}
+		mixed := []string{}
+		for _, m := range findMixedContent(parsed(page, body)) {
+			mixed = append(mixed, m.Element+" "+m.URL.String())
+		}
+		require.Equal(t, []string{
+			"link http://cdn.monzo.com/style.css",
+			"script http://cdn.monzo.com/app.js",
+			"img http://cdn.monzo.com/logo.png",
+			"iframe http://example.com/embed",
+			"video http://cdn.monzo.com/poster.jpg",
+		}, mixed)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		page := &Page{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/"}}
+		require.Empty(t, findMixedContent(parsed(page, body)))
+	})
+
+	t.Run("redirected to https", func(t *testing.T) {
+		page := &Page{
+			URL:          &url.URL{Scheme: "http", Host: "monzo.com", Path: "/"},
+			RedirectedTo: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/"},
+		}
+		require.Len(t, findMixedContent(parsed(page, body)), 5)
+	})
+}
+
+func TestMixedContentDetection(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><img src="http://cdn.monzo.com/logo.png"></body></html>`)
+	}))
+	defer srv.Close()
+
+	report := &Report{}
+	out := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithReport(report), WithMixedContentDetection(), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", out))
+
+	require.Contains(t, out.String(), "MixedContent:\n\timg: http://cdn.monzo.com/logo.png\n")
+	require.Equal(t, []MixedContentRecord{
+		{Page: srv.URL + "/", Element: "img", URL: "http://cdn.monzo.com/logo.png"},
+	}, report.MixedContent())
+}
@@ -0,0 +1,57 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+)
+
+// gate blocks workers from starting new fetches while a crawler is paused
+type gate struct {
+	mu     sync.Mutex
+	paused chan struct{} // open while paused, closed on resume
+}
+
+func (g *gate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused == nil {
+		g.paused = make(chan struct{})
+	}
+}
+
+func (g *gate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused != nil {
+		close(g.paused)
+		g.paused = nil
+	}
+}
+
+// wait blocks until the gate is open, returning false if ctx is done first
+func (g *gate) wait(ctx context.Context) bool {
+	g.mu.Lock()
+	paused := g.paused
+	g.mu.Unlock()
+
+	if paused != nil {
+		select {
+		case <-paused:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// Pause stops workers starting new fetches, without losing any crawl state. Fetches already in flight complete.
+func (c *crawler) Pause() {
+	c.gate.pause()
+}
+
+// Resume lets workers start fetching again after a Pause
+func (c *crawler) Resume() {
+	c.gate.resume()
+}
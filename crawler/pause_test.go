@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseResume(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `<html><body><a href="/a"></a></body></html>`)
+	}))
+	defer srv.Close()
+
+	c := New(1, srv.Client())
+	c.Pause()
+
+	done := make(chan error)
+	go func() {
+		done <- c.Crawl(srv.URL+"/", &bytes.Buffer{})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("crawl completed while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+	c.Resume()
+	require.NoError(t, <-done)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestPauseCancelled(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `<html><body><a href="/a"></a></body></html>`)
+	}))
+	defer srv.Close()
+
+	before := workerGoroutines() // left by other tests
+	c := New(2, srv.Client())
+	c.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- c.CrawlAll(ctx, []string{srv.URL + "/"}, &bytes.Buffer{})
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("paused crawl wasn't cancelled")
+	}
+	require.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	require.Eventually(t, func() bool {
+		return workerGoroutines() == before
+	}, 5*time.Second, 10*time.Millisecond, "the workers and the goroutines merging their results should exit")
+}
+
+// workerGoroutines returns the number of goroutines fetching pages or merging the results of the workers
+func workerGoroutines() int {
+	stacks := make([]byte, 1<<20)
+	stacks = stacks[:runtime.Stack(stacks, true)]
+	return bytes.Count(stacks, []byte("(*crawler).getPages")) + bytes.Count(stacks, []byte("crawler.merge"))
+}
@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Politeness limits the load a crawl puts on each origin, by default each host. Zero values disable the corresponding
+// limit.
+type Politeness struct {
+	Delay         time.Duration // the minimum time between the start of requests to an origin
+	MaxConcurrent int           // the maximum number of requests to an origin in flight at once
+	// GroupByIP treats hosts resolving to any of the same IPs as one origin, so that many virtual hosts on one server,
+	// or one host behind several IPs, share the limits rather than each having their own
+	GroupByIP bool
+	// Hosts overrides the limits for particular hosts, e.g. to slow down for a fragile legacy subdomain while crawling a
+	// CDN faster. Each host is an origin of its own, even if grouping by IP.
+	Hosts map[string]HostLimits
+}
+
+// HostLimits overrides Politeness's limits for a host. Zero values fall back to Politeness's, except that setting
+// either QPS or Delay replaces its Delay.
+type HostLimits struct {
+	QPS           float64       // the maximum number of requests started per second
+	Delay         time.Duration // the minimum time between the start of requests, the longer of this and 1/QPS applies
+	MaxConcurrent int           // the maximum number of requests in flight at once
+}
+
+// WithPoliteness applies the limits to every request, across every crawl the crawler runs at once
+func WithPoliteness(p Politeness) Option {
+	return func(c *crawler) {
+		c.politeness = newPoliteness(p, net.DefaultResolver.LookupIPAddr)
+	}
+}
+
+// politeness tracks the requests made to each origin, to hold new ones back until they're within the limits
+type politeness struct {
+	limits  Politeness
+	resolve func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	overrides map[string]HostLimits // by lower case host name
+
+	mu    sync.Mutex
+	hosts map[string]*origin // by host name
+	ips   map[string]*origin // by IP, if grouping by IP
+}
+
+// origin is the state of the requests to a host, or group of hosts
+type origin struct {
+	slots chan struct{} // holds a token per request in flight, nil if unlimited
+	delay time.Duration // the minimum time between the start of requests
+
+	mu   sync.Mutex
+	next time.Time // the earliest a request may start
+}
+
+func newPoliteness(limits Politeness, resolve func(context.Context, string) ([]net.IPAddr, error)) *politeness {
+	p := &politeness{
+		limits:    limits,
+		resolve:   resolve,
+		overrides: map[string]HostLimits{},
+		hosts:     map[string]*origin{},
+		ips:       map[string]*origin{},
+	}
+	for host, override := range limits.Hosts {
+		p.overrides[strings.ToLower(host)] = override
+	}
+	return p
+}
+
+// newOrigin returns an origin with Politeness's limits, or a host's overrides of them
+func (p *politeness) newOrigin(override *HostLimits) *origin {
+	delay, maxConcurrent := p.limits.Delay, p.limits.MaxConcurrent
+	if override != nil {
+		if override.QPS > 0 || override.Delay > 0 {
+			delay = override.Delay
+			if interval := time.Duration(float64(time.Second) / override.QPS); override.QPS > 0 && interval > delay {
+				delay = interval
+			}
+		}
+		if override.MaxConcurrent > 0 {
+			maxConcurrent = override.MaxConcurrent
+		}
+	}
+
+	o := &origin{delay: delay}
+	if maxConcurrent > 0 {
+		o.slots = make(chan struct{}, maxConcurrent)
+	}
+	return o
+}
+
+// acquire waits until a request for u is within the limits, returning a func to call once the request is over, or
+// false if ctx is done first. A nil politeness has no limits.
+func (p *politeness) acquire(ctx context.Context, u *url.URL) (release func(), ok bool) {
+	if p == nil {
+		return func() {}, ctx.Err() == nil
+	}
+	o := p.origin(ctx, u.Hostname())
+
+	release = func() {}
+	if o.slots != nil {
+		select {
+		case o.slots <- struct{}{}:
+			release = func() {
+				<-o.slots
+			}
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+
+	if o.delay > 0 {
+		o.mu.Lock()
+		start := time.Now()
+		if o.next.After(start) {
+			start = o.next
+		}
+		o.next = start.Add(o.delay)
+		o.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(start))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, false
+		}
+	}
+	return release, true
+}
+
+// origin returns the origin a host belongs to, resolving it the first time it's seen if grouping by IP. Hosts which
+// can't be resolved, or whose limits are overridden, are origins of their own.
+func (p *politeness) origin(ctx context.Context, host string) *origin {
+	override, overridden := p.overrides[strings.ToLower(host)]
+	p.mu.Lock()
+	if o, ok := p.hosts[host]; ok || overridden || !p.limits.GroupByIP {
+		if !ok {
+			var limits *HostLimits
+			if overridden {
+				limits = &override
+			}
+			o = p.newOrigin(limits)
+			p.hosts[host] = o
+		}
+		p.mu.Unlock()
+		return o
+	}
+	p.mu.Unlock()
+
+	// resolve outside the lock, so a slow lookup doesn't hold up requests to other hosts
+	addrs, _ := p.resolve(ctx, host)
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	sort.Strings(ips)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if o, ok := p.hosts[host]; ok {
+		return o // resolved by another worker meanwhile
+	}
+	var o *origin
+	for _, ip := range ips {
+		if o = p.ips[ip]; o != nil {
+			break
+		}
+	}
+	if o == nil {
+		o = p.newOrigin(nil)
+	}
+	for _, ip := range ips {
+		if _, ok := p.ips[ip]; !ok {
+			p.ips[ip] = o
+		}
+	}
+	p.hosts[host] = o
+	return o
+}
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoliteness(t *testing.T) {
+	resolve := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "a.test", "b.test":
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.1")}}, nil
+		case "c.test":
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.2")}, {IP: net.ParseIP("10.0.0.1")}}, nil
+		case "d.test":
+			return []net.IPAddr{{IP: net.ParseIP("10.0.0.3")}}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	u := func(host string) *url.URL {
+		return &url.URL{Scheme: "http", Host: host, Path: "/"}
+	}
+	// blocked reports whether a request to host is held back
+	blocked := func(p *politeness, host string) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		release, ok := p.acquire(ctx, u(host))
+		if ok {
+			release()
+		}
+		return !ok
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		var p *politeness
+		release, ok := p.acquire(context.Background(), u("a.test"))
+		require.True(t, ok)
+		release()
+	})
+
+	t.Run("max concurrent per host", func(t *testing.T) {
+		p := newPoliteness(Politeness{MaxConcurrent: 1}, resolve)
+		release, ok := p.acquire(context.Background(), u("a.test"))
+		require.True(t, ok)
+
+		require.True(t, blocked(p, "a.test"))
+		require.False(t, blocked(p, "b.test"), "hosts sharing an IP should have their own limits unless grouped by IP")
+
+		release()
+		require.False(t, blocked(p, "a.test"))
+	})
+
+	t.Run("max concurrent per IP", func(t *testing.T) {
+		p := newPoliteness(Politeness{MaxConcurrent: 1, GroupByIP: true}, resolve)
+		release, ok := p.acquire(context.Background(), u("a.test"))
+		require.True(t, ok)
+
+		require.True(t, blocked(p, "b.test"), "hosts sharing an IP should share its limits")
+		require.True(t, blocked(p, "c.test"), "hosts sharing any IP should share its limits")
+		require.False(t, blocked(p, "d.test"))
+		require.False(t, blocked(p, "unresolvable.test"))
+
+		release()
+		require.False(t, blocked(p, "b.test"))
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		p := newPoliteness(Politeness{Delay: 30 * time.Millisecond, GroupByIP: true}, resolve)
+		start := time.Now()
+		for _, host := range []string{"a.test", "b.test", "a.test"} {
+			release, ok := p.acquire(context.Background(), u(host))
+			require.True(t, ok)
+			release()
+		}
+		elapsed := time.Since(start)
+		require.True(t, elapsed >= 60*time.Millisecond, "waited %s", elapsed)
+
+		start = time.Now()
+		release, ok := p.acquire(context.Background(), u("d.test"))
+		require.True(t, ok)
+		release()
+		require.True(t, time.Since(start) < 20*time.Millisecond, "requests to other IPs shouldn't wait")
+	})
+
+	t.Run("host overrides", func(t *testing.T) {
+		p := newPoliteness(Politeness{MaxConcurrent: 1, Delay: time.Hour, GroupByIP: true, Hosts: map[string]HostLimits{
+			"B.test": {MaxConcurrent: 2, QPS: 50},
+			"d.test": {Delay: 10 * time.Millisecond},
+		}}, resolve)
+		release, ok := p.acquire(context.Background(), u("a.test"))
+		require.True(t, ok)
+		defer release()
+
+		start := time.Now()
+		for i := 0; i < 2; i++ {
+			release, ok := p.acquire(context.Background(), u("b.test"))
+			require.True(t, ok, "overridden hosts shouldn't share the limits of their IP")
+			defer release()
+		}
+		elapsed := time.Since(start)
+		require.True(t, elapsed >= 20*time.Millisecond && elapsed < time.Second, "waited %s", elapsed)
+		require.True(t, blocked(p, "b.test"))
+
+		release, ok = p.acquire(context.Background(), u("d.test"))
+		require.True(t, ok)
+		require.True(t, blocked(p, "d.test"), "limits which aren't overridden should apply")
+		release()
+		require.False(t, blocked(p, "d.test"))
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		p := newPoliteness(Politeness{Delay: time.Hour}, resolve)
+		release, ok := p.acquire(context.Background(), u("a.test"))
+		require.True(t, ok)
+		release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, ok = p.acquire(ctx, u("a.test"))
+		require.False(t, ok)
+	})
+}
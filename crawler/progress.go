@@ -0,0 +1,165 @@
+package crawler
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// maxRecentErrors is the number of errors kept for Progress.RecentErrors
+const maxRecentErrors = 10
+
+// Progress is a snapshot of a crawl in progress
+type Progress struct {
+	Elapsed        time.Duration
+	Crawled        int              // pages fetched so far
+	Queued         int              // URLs scheduled but not yet fetched
+	Errors         int              // non-fatal errors so far
+	Discovered     int              // URLs scheduled so far, whether fetched or not
+	DiscoveryRate  float64          // URLs scheduled per second since the previous snapshot
+	CompletionRate float64          // URLs fetched, successfully or not, per second since the previous snapshot
+	Hosts          map[string]int   // pages fetched per host
+	Bytes          int64            // body bytes downloaded so far
+	HostBytes      map[string]int64 // body bytes downloaded per host
+	ErrorClasses   map[string]int   // non-fatal errors per class, one of the ErrorClass constants
+	RecentErrors   []error          // the most recent non-fatal errors, oldest first
+}
+
+// ETA estimates the time remaining in the crawl. earliest assumes no more URLs are discovered, and latest that they
+// continue to be discovered at the current rate. While URLs are discovered at least as fast as they're fetched the
+// frontier isn't shrinking, so there is no latest estimate and bounded is false.
+func (p Progress) ETA() (earliest, latest time.Duration, bounded bool) {
+	if p.CompletionRate == 0 {
+		return 0, 0, false
+	}
+	earliest = seconds(float64(p.Queued) / p.CompletionRate)
+
+	drain := p.CompletionRate - p.DiscoveryRate
+	if drain <= 0 {
+		return earliest, 0, false
+	}
+	return earliest, seconds(float64(p.Queued) / drain), true
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// WithProgress calls report with a snapshot of the crawl's progress every interval, and once more when it finishes.
+// report is called from the crawl loop, so should return promptly.
+func WithProgress(interval time.Duration, report func(Progress)) Option {
+	return func(c *crawler) {
+		c.progressInterval = interval
+		c.progress = report
+	}
+}
+
+// progressTracker accumulates the progress of a single crawl
+type progressTracker struct {
+	mu         sync.Mutex // as fetches are recorded by the workers while the crawl takes snapshots
+	start      time.Time
+	crawled    int
+	queued     int
+	discovered int
+	errors     int
+	hosts      map[string]int
+	bytes      int64
+	hostBytes  map[string]int64
+	classes    map[string]int // errors per class
+	recent     []error
+	last       Progress // the previous snapshot, from which rates are measured
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{
+		start:     time.Now(),
+		hosts:     map[string]int{},
+		hostBytes: map[string]int64{},
+		classes:   map[string]int{},
+	}
+}
+
+// record is subscribed to a crawl's events, updating the progress accordingly
+func (t *progressTracker) record(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch e := e.(type) {
+	case URLEnqueued:
+		t.enqueued(1)
+	case FetchCompleted:
+		t.bytes += e.ContentLength
+		t.hostBytes[unicodeHost(e.URL.Hostname())] += e.ContentLength
+	case PageParsed:
+		t.fetched(e.Page.URL)
+	case URLSkipped:
+		switch e.Reason {
+		case SkipResponseFilter:
+			t.fetched(e.URL)
+		case SkipOutscored:
+			t.queued-- // it was enqueued, but won't be fetched
+		}
+	case ErrorOccurred:
+		t.failed(e.Err)
+	}
+}
+
+func (t *progressTracker) enqueued(n int) {
+	t.queued += n
+	t.discovered += n
+}
+
+func (t *progressTracker) fetched(u *url.URL) {
+	t.queued--
+	t.crawled++
+	t.hosts[unicodeHost(u.Hostname())]++
+}
+
+func (t *progressTracker) failed(err error) {
+	t.queued--
+	t.errors++
+	t.classes[errorClass(err)]++
+	if t.recent = append(t.recent, err); len(t.recent) > maxRecentErrors {
+		t.recent = t.recent[1:]
+	}
+}
+
+func (t *progressTracker) snapshot() Progress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	elapsed := time.Since(t.start)
+	window := (elapsed - t.last.Elapsed).Seconds()
+	discoveryRate, completionRate := t.last.DiscoveryRate, t.last.CompletionRate
+	if window > 0 {
+		discoveryRate = float64(t.discovered-t.last.Discovered) / window
+		completionRate = float64(t.crawled+t.errors-t.last.Crawled-t.last.Errors) / window
+	}
+
+	hosts := make(map[string]int, len(t.hosts))
+	for host, n := range t.hosts {
+		hosts[host] = n
+	}
+	hostBytes := make(map[string]int64, len(t.hostBytes))
+	for host, n := range t.hostBytes {
+		hostBytes[host] = n
+	}
+	classes := make(map[string]int, len(t.classes))
+	for class, n := range t.classes {
+		classes[class] = n
+	}
+
+	t.last = Progress{
+		Elapsed:        elapsed,
+		Crawled:        t.crawled,
+		Queued:         t.queued,
+		Errors:         t.errors,
+		Discovered:     t.discovered,
+		DiscoveryRate:  discoveryRate,
+		CompletionRate: completionRate,
+		Hosts:          hosts,
+		Bytes:          t.bytes,
+		HostBytes:      hostBytes,
+		ErrorClasses:   classes,
+		RecentErrors:   append([]error{}, t.recent...),
+	}
+	return t.last
+}
@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	home, a := `<html><body><a href="/a"></a><a href="/missing"></a></body></html>`, `<html><body></body></html>`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, home)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, a)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	snapshots := []Progress{}
+	log := &bytes.Buffer{}
+	c := New(1, srv.Client(),
+		WithProgress(time.Hour, func(p Progress) { snapshots = append(snapshots, p) }),
+		WithLogger(newTestLogger(log)),
+	)
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	require.Len(t, snapshots, 1)
+	final := snapshots[0]
+	require.Equal(t, 2, final.Crawled)
+	require.Equal(t, 0, final.Queued)
+	require.Equal(t, 1, final.Errors)
+	require.Equal(t, map[string]int{"127.0.0.1": 2}, final.Hosts)
+	require.Equal(t, int64(len(home)+len(a)), final.Bytes)
+	require.Equal(t, map[string]int64{"127.0.0.1": int64(len(home) + len(a))}, final.HostBytes)
+	require.Len(t, final.RecentErrors, 1)
+	require.Equal(t, ErrHttpStatusCode, errors.Cause(final.RecentErrors[0]))
+	require.Contains(t, log.String(), "/missing")
+}
+
+func TestProgressTracker(t *testing.T) {
+	tracker := newProgressTracker()
+	tracker.enqueued(maxRecentErrors + 2)
+	tracker.fetched(&url.URL{Host: "xn--bcher-kva.example"})
+	for i := 0; i <= maxRecentErrors; i++ {
+		tracker.failed(fmt.Errorf("error %d", i))
+	}
+
+	p := tracker.snapshot()
+	require.Equal(t, 1, p.Crawled)
+	require.Equal(t, 0, p.Queued)
+	require.Equal(t, maxRecentErrors+2, p.Discovered)
+	require.Equal(t, maxRecentErrors+1, p.Errors)
+	require.Equal(t, map[string]int{ErrorClassOther: maxRecentErrors + 1}, p.ErrorClasses)
+	require.Equal(t, map[string]int{"bücher.example": 1}, p.Hosts)
+	require.Len(t, p.RecentErrors, maxRecentErrors)
+	require.EqualError(t, p.RecentErrors[0], "error 1")
+}
+
+func TestProgressETA(t *testing.T) {
+	tests := []struct {
+		title    string
+		progress Progress
+		earliest time.Duration
+		latest   time.Duration
+		bounded  bool
+	}{
+		{
+			title:    "nothing fetched yet",
+			progress: Progress{Queued: 1, DiscoveryRate: 1},
+		},
+		{
+			title:    "frontier shrinking",
+			progress: Progress{Queued: 50, DiscoveryRate: 5, CompletionRate: 10},
+			earliest: 5 * time.Second,
+			latest:   10 * time.Second,
+			bounded:  true,
+		},
+		{
+			title:    "frontier growing",
+			progress: Progress{Queued: 200, DiscoveryRate: 15, CompletionRate: 10},
+			earliest: 20 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			earliest, latest, bounded := tt.progress.ETA()
+			require.Equal(t, tt.earliest, earliest)
+			require.Equal(t, tt.latest, latest)
+			require.Equal(t, tt.bounded, bounded)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrRedirectPolicyUnsupported = errors.New("redirect policy requires an *http.Client")
+	ErrRedirectLoop              = errors.New("redirect loop")
+	ErrRedirectChain             = errors.New("too many redirects")
+)
+
+// defaultMaxRedirects matches the limit net/http applies when a client has no CheckRedirect
+const defaultMaxRedirects = 10
+
+// RedirectPolicy configures which redirects are followed. A redirect which isn't followed is not an error, instead the
+// redirect response itself is crawled, recording its status code and Location on the Page. Redirect loops and chains
+// longer than MaxRedirects are errors, see RedirectError.
+type RedirectPolicy struct {
+	MaxRedirects    *int // the number of redirects followed per page, nil meaning net/http's default of 10, 0 that none are
+	FollowCrossHost bool // follow redirects to a different host from the one requested
+	EnforceScope    bool // only follow redirects to in scope URLs, as if the target were a link
+}
+
+// WithRedirectPolicy makes the crawler decide which redirects to follow rather than leaving it to the http client,
+// which must be an *http.Client
+func WithRedirectPolicy(p RedirectPolicy) Option {
+	return func(c *crawler) {
+		c.redirectPolicy = &p
+	}
+}
+
+// RedirectError is the error fetching a page whose redirects looped or went on for too long, which are no longer
+// followed once detected
+type RedirectError struct {
+	Chain []*url.URL // the URLs requested in order, ending with the redirect target which wasn't requested
+	Err   error      // ErrRedirectLoop or ErrRedirectChain
+}
+
+func (e *RedirectError) Error() string {
+	chain := make([]string, 0, len(e.Chain))
+	for _, u := range e.Chain {
+		chain = append(chain, displayURL(u))
+	}
+	return e.Err.Error() + ": " + strings.Join(chain, " -> ")
+}
+
+// Cause returns the underlying error so that errors.Cause can see through a RedirectError
+func (e *RedirectError) Cause() error {
+	return e.Err
+}
+
+// checkRedirectChain returns a CheckRedirect function stopping redirect loops and chains of more than maxRedirects
+// with a RedirectError, then deferring to next, if set, for any other redirect
+func checkRedirectChain(maxRedirects int, next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		chain := make([]*url.URL, 0, len(via)+1)
+		for _, prev := range via {
+			chain = append(chain, prev.URL)
+		}
+		chain = append(chain, req.URL)
+
+		for _, prev := range via {
+			if prev.URL.String() == req.URL.String() {
+				return &RedirectError{Chain: chain, Err: ErrRedirectLoop}
+			}
+		}
+		if len(via) > maxRedirects {
+			return &RedirectError{Chain: chain, Err: ErrRedirectChain}
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		return nil
+	}
+}
+
+// checkRedirect returns a CheckRedirect function implementing the policy for a crawl with the given scope
+func (p *RedirectPolicy) checkRedirect(inScope func(*url.URL) bool) func(*http.Request, []*http.Request) error {
+	maxRedirects := defaultMaxRedirects
+	if p.MaxRedirects != nil {
+		maxRedirects = *p.MaxRedirects
+	}
+	if maxRedirects == 0 {
+		return func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return checkRedirectChain(maxRedirects, func(req *http.Request, via []*http.Request) error {
+		if !p.FollowCrossHost && req.URL.Hostname() != via[0].URL.Hostname() {
+			return http.ErrUseLastResponse
+		}
+		if p.EnforceScope && !inScope(req.URL) {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	})
+}
+
+// crawlClient returns the http client to use for a crawl with the given scope, applying the redirect policy if set and
+// serving local files if enabled, and a func to release its resources once the crawl is over. Without a policy, an
+// *http.Client still has redirect loops and long chains stopped by checkRedirectChain, before its own CheckRedirect is
+// consulted.
+func (c *crawler) crawlClient(inScope func(*url.URL) bool) (httpClient, func(), error) {
+	client, ok := c.httpClient.(*http.Client)
+	if !ok {
+		if c.redirectPolicy != nil {
+			return nil, nil, ErrRedirectPolicyUnsupported
+		}
+		if c.http3 {
+			return nil, nil, ErrHTTP3Unsupported
+		}
+		if c.fileRoot != "" {
+			return nil, nil, ErrLocalFilesUnsupported
+		}
+		return c.httpClient, func() {}, nil
+	}
+
+	withChecks := *client
+	if c.redirectPolicy != nil {
+		withChecks.CheckRedirect = c.redirectPolicy.checkRedirect(inScope)
+	} else {
+		withChecks.CheckRedirect = checkRedirectChain(defaultMaxRedirects, client.CheckRedirect)
+	}
+
+	release := func() {}
+	if c.http3 {
+		fallback := client.Transport
+		if fallback == nil {
+			fallback = http.DefaultTransport
+		}
+		h3 := newHTTP3Transport(fallback, c.logger)
+		withChecks.Transport = h3
+		release = func() {
+			h3.Close()
+		}
+	}
+	if c.fileRoot != "" {
+		fallback := withChecks.Transport
+		if fallback == nil {
+			fallback = http.DefaultTransport
+		}
+		withChecks.Transport = &fileTransport{root: c.fileRoot, fallback: fallback}
+	}
+	return &withChecks, release, nil
+}
+
+// redirectError returns the RedirectError an *http.Client's Do failed with, if any
+func redirectError(err error) (*RedirectError, bool) {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return nil, false
+	}
+	redirectErr, ok := urlErr.Err.(*RedirectError)
+	return redirectErr, ok
+}
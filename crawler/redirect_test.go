@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	newRequest := func(rawURL string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		return req
+	}
+	inScope := func(u *url.URL) bool {
+		return u.Hostname() != "www.out-of-scope.com"
+	}
+	maxRedirects := func(n int) *int {
+		return &n
+	}
+
+	tests := []struct {
+		title    string
+		policy   RedirectPolicy
+		target   string
+		via      int
+		expected error
+	}{
+		{"same host", RedirectPolicy{}, "http://www.google.com/b", 1, nil},
+		{"max redirects followed", RedirectPolicy{MaxRedirects: maxRedirects(2)}, "http://www.google.com/b", 2, nil},
+		{"default max redirects", RedirectPolicy{}, "http://www.google.com/b", 11, ErrRedirectChain},
+		{"max redirects", RedirectPolicy{MaxRedirects: maxRedirects(2)}, "http://www.google.com/b", 3, ErrRedirectChain},
+		{"no redirects", RedirectPolicy{MaxRedirects: maxRedirects(0), FollowCrossHost: true}, "http://www.google.com/b", 1, http.ErrUseLastResponse},
+		{"loop", RedirectPolicy{}, "http://www.google.com/a", 1, ErrRedirectLoop},
+		{"cross host", RedirectPolicy{}, "http://www.test.com", 1, http.ErrUseLastResponse},
+		{"cross host followed", RedirectPolicy{FollowCrossHost: true}, "http://www.test.com", 1, nil},
+		{"out of scope", RedirectPolicy{FollowCrossHost: true}, "http://www.out-of-scope.com", 1, nil},
+		{"out of scope enforced", RedirectPolicy{FollowCrossHost: true, EnforceScope: true}, "http://www.out-of-scope.com", 1, http.ErrUseLastResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			via := []*http.Request{}
+			for i := 0; i < tt.via; i++ {
+				via = append(via, newRequest("http://www.google.com/a"))
+			}
+			require.Equal(t, tt.expected, errors.Cause(tt.policy.checkRedirect(inScope)(newRequest(tt.target), via)))
+		})
+	}
+}
+
+func TestCrawlRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.RedirectHandler("/one", http.StatusFound))
+	mux.Handle("/one", http.RedirectHandler("/two", http.StatusMovedPermanently))
+	mux.HandleFunc("/two", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body></body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("followed", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, New(1, srv.Client()).Crawl(srv.URL+"/", &out))
+		require.Contains(t, out.String(), "RedirectedTo:\n\t"+srv.URL+"/two\nStatus:\n\t200\n")
+	})
+
+	t.Run("max redirects", func(t *testing.T) {
+		maxRedirects := 1
+		report := &Report{}
+		c := New(1, srv.Client(), WithRedirectPolicy(RedirectPolicy{MaxRedirects: &maxRedirects}), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+		require.Empty(t, report.Pages)
+		require.Len(t, report.Errors, 1)
+		require.Equal(t, ErrorClassRedirect, report.Errors[0].Class)
+		require.Equal(t, []string{srv.URL + "/", srv.URL + "/one", srv.URL + "/two"}, report.Errors[0].Chain)
+		require.Equal(t, "too many redirects: "+srv.URL+"/ -> "+srv.URL+"/one -> "+srv.URL+"/two", report.Errors[0].Error)
+	})
+
+	t.Run("no redirects", func(t *testing.T) {
+		maxRedirects := 0
+		var out bytes.Buffer
+		report := &Report{}
+		c := New(1, srv.Client(), WithRedirectPolicy(RedirectPolicy{MaxRedirects: &maxRedirects}), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URL+"/", &out))
+		require.Contains(t, out.String(), "URL:\n\t"+srv.URL+"/\nLocation:\n\t"+srv.URL+"/one\nStatus:\n\t302\n")
+		require.Empty(t, report.Errors)
+	})
+
+	t.Run("unsupported client", func(t *testing.T) {
+		c := New(1, NewMockhttpClient(nil), WithRedirectPolicy(RedirectPolicy{}))
+		require.Equal(t, ErrRedirectPolicyUnsupported, errors.Cause(c.Crawl(srv.URL+"/", &bytes.Buffer{})))
+	})
+}
+
+func TestCrawlRedirectLoops(t *testing.T) {
+	site := crawltest.Site{
+		"/":     {Links: []string{"/loop", "/long"}},
+		"/loop": {RedirectTo: "/back"},
+		"/back": {RedirectTo: "/loop"},
+	}
+	for i := 0; i < 12; i++ {
+		site[fmt.Sprintf("/long%s", strings.Repeat("g", i))] = crawltest.Page{RedirectTo: fmt.Sprintf("/long%s", strings.Repeat("g", i+1))}
+	}
+	srv := crawltest.NewServer(site)
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		title string
+		opts  []Option
+	}{
+		{"default client", nil},
+		{"redirect policy", []Option{WithRedirectPolicy(RedirectPolicy{FollowCrossHost: true})}},
+	} {
+		t.Run(tt.title, func(t *testing.T) {
+			report := &Report{}
+			c := New(1, srv.Client(), append(tt.opts, WithReport(report), WithLogger(newTestLogger(io.Discard)))...)
+			require.NoError(t, c.Crawl(srv.URLFor("/"), &bytes.Buffer{}))
+
+			require.Len(t, report.Errors, 2)
+			errs := map[string]ErrorRecord{}
+			for _, record := range report.Errors {
+				require.Equal(t, ErrorClassRedirect, record.Class)
+				require.Equal(t, srv.URLFor("/"), record.Referrer)
+				errs[record.URL] = record
+			}
+			require.Equal(t, []string{srv.URLFor("/loop"), srv.URLFor("/back"), srv.URLFor("/loop")}, errs[srv.URLFor("/loop")].Chain)
+			require.Contains(t, errs[srv.URLFor("/loop")].Error, "redirect loop: ")
+			require.Len(t, errs[srv.URLFor("/long")].Chain, defaultMaxRedirects+2)
+			require.Contains(t, errs[srv.URLFor("/long")].Error, "too many redirects: ")
+		})
+	}
+}
@@ -0,0 +1,876 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report collects the pages crawled and non-fatal errors of a crawl, for rendering once Crawl has returned
+type Report struct {
+	Summary Summary
+	Pages   []PageRecord // in the order they were crawled, including those not written to the output
+	Errors  []ErrorRecord
+	Sitemap []string // the URLs listed by the seeds' sitemaps, recorded with WithSitemapComparison
+	// LinkedFrom lists the in scope pages linking to each URL, in the order they were crawled
+	LinkedFrom map[string][]string
+	// External lists the out of scope URLs linked to, in the order they were found, see ExternalLinks
+	External []string
+	external map[string]bool
+}
+
+// PageRecord describes a page crawled
+type PageRecord struct {
+	URL           string
+	Referrer      string
+	StatusCode    int
+	ContentLength int64
+	ContentHash   string
+	LastModified  time.Time // zero if the response had no Last-Modified header
+	FetchDuration time.Duration
+	Title         string
+	Description   string
+	RedirectedTo  string // set if the request was redirected
+	Location      string // set if the page is a redirect which wasn't followed
+	Refresh       string // set if the page has a meta refresh redirect
+	AMP           string // set if the page links to an AMP version
+	Alternates    []string
+	Soft404       string // set if the page looks like an error page despite its status
+	OffsiteHops   int    // the number of links followed out of scope to reach the page, see WithOffsiteDepth
+	MixedContent  []MixedContentRecord
+	Forms         []FormRecord
+	InsecureForms []InsecureFormRecord
+	Cookies       []Cookie
+	Subresources  []SubresourceRecord
+	ThirdParty    []ThirdPartyRecord
+	Trackers      []TrackerRecord
+	Contacts      []Contact
+	Accessibility []AccessibilityIssue
+	AnchorTexts   []AnchorTextRecord
+	Images        []ImageRecord
+}
+
+// ImageRecord describes an image on a page
+type ImageRecord struct {
+	URL    string
+	Alt    string
+	HasAlt bool
+	Width  string
+	Height string
+}
+
+// ImageUsage describes the pages crawled which have an image
+type ImageUsage struct {
+	URL      string
+	Pages    int      // the number of pages with the image
+	Examples []string // the first few pages crawled with the image
+}
+
+// AnchorTextRecord describes the text of a link on a page
+type AnchorTextRecord struct {
+	URL   string
+	Text  string
+	Title string
+}
+
+// AnchorTextUsage describes the texts of the links to a URL from the pages crawled
+type AnchorTextUsage struct {
+	URL   string
+	Links int // the number of links to the URL
+	Pages int // the number of pages linking to the URL
+	Texts []AnchorTextCount
+}
+
+// AnchorTextCount is the number of links to a URL with one text
+type AnchorTextCount struct {
+	Text  string // empty for links without any text
+	Links int
+}
+
+// ThirdPartyRecord describes a URL on another registrable domain which a page links to or loads
+type ThirdPartyRecord struct {
+	Element string
+	URL     string
+}
+
+// DomainUsage describes the references of the pages crawled to a third party's host name
+type DomainUsage struct {
+	Domain     string
+	Pages      int      // the number of pages referencing the domain
+	References int      // the number of distinct references to the domain, counted per page
+	Elements   []string // the elements referencing the domain, e.g. "a" and "script", in alphabetical order
+	Examples   []string // the first few pages crawled referencing the domain
+}
+
+// ExternalLink describes an out of scope URL which the pages crawled link to
+type ExternalLink struct {
+	URL        string
+	StatusCode int      // zero unless it was crawled, see WithOffsiteDepth
+	Pages      int      // the number of in scope pages linking to it
+	Examples   []string // the first few pages crawled linking to it
+}
+
+// TrackerRecord describes an analytics or tracking script which a page loads
+type TrackerRecord struct {
+	Name string
+	URL  string
+}
+
+// TrackerUsage describes the pages crawled which load an analytics or tracking service's scripts
+type TrackerUsage struct {
+	Name     string
+	Pages    int      // the number of pages loading the tracker
+	Scripts  []string // the URLs of the tracker's scripts loaded, in the order first seen
+	Examples []string // the first few pages crawled loading the tracker
+}
+
+// AccessibilityRecord counts the accessibility issues of a page
+type AccessibilityRecord struct {
+	Page        string
+	MissingAlt  int // the number of images without alt text
+	EmptyLinks  int // the number of links without any text to name them
+	MissingLang bool
+}
+
+// issues returns the number of issues of the page
+func (r AccessibilityRecord) issues() int {
+	n := r.MissingAlt + r.EmptyLinks
+	if r.MissingLang {
+		n++
+	}
+	return n
+}
+
+// FormRecord describes the endpoint one of a page's forms submits to
+type FormRecord struct {
+	Method string
+	Action string
+}
+
+// FormEndpoint describes the pages crawled with forms submitting to an endpoint
+type FormEndpoint struct {
+	FormRecord
+	Pages    int      // the number of pages with forms submitting to the endpoint
+	Examples []string // the first few pages crawled with forms submitting to the endpoint
+}
+
+// maxDomainExamples is the number of example pages kept for each domain of Report.ThirdPartyDomains, tracker of
+// Report.Trackers, endpoint of Report.FormEndpoints, link of Report.ExternalLinks and image of
+// Report.ImagesWithoutDimensions
+const maxDomainExamples = 3
+
+// SubresourceRecord describes a script or stylesheet a page loads from another origin
+type SubresourceRecord struct {
+	Element   string
+	URL       string
+	Integrity string
+	Status    string // SRIMissing, SRIPresent, SRIValid, SRIMismatch or SRIUnverified
+}
+
+// SubresourceUsage describes a script or stylesheet loaded from another origin by every page with the same integrity
+// status
+type SubresourceUsage struct {
+	SubresourceRecord
+	FirstSeen string // the first page crawled which loads it
+	Pages     int    // the number of pages which load it
+}
+
+// CookieRecord describes a cookie set by a host without some of its security attributes
+type CookieRecord struct {
+	Host      string
+	Name      string
+	FirstSeen string   // the first page crawled whose response set the cookie
+	Missing   []string // the attributes the cookie doesn't set, see Cookie.Missing
+}
+
+// InsecureFormRecord describes a form which submits over plain http or to another origin
+type InsecureFormRecord struct {
+	Page   string
+	Action string
+	Method string
+	Reason string // InsecureFormHTTP or InsecureFormCrossOrigin
+}
+
+// MixedContentRecord describes an http:// asset of an https:// page
+type MixedContentRecord struct {
+	Page    string
+	Element string
+	URL     string
+}
+
+// AlternateRecord describes a page whose AMP or alternate version is missing or broken
+type AlternateRecord struct {
+	Page      string
+	Alternate string
+	Rel       string      // "amphtml" or "alternate"
+	Error     ErrorRecord // the error fetching the alternate, e.g. a 404 if it's missing
+}
+
+// RedirectRecord describes a link to a URL which redirects, which could link straight to its destination instead
+type RedirectRecord struct {
+	Source      string // the page linking to Target
+	Target      string
+	Destination string // where Target's redirects finally lead, or its first redirect's target if they weren't followed
+}
+
+// DuplicateRecord is a title or description shared by several pages
+type DuplicateRecord struct {
+	Value string
+	URLs  []string // in the order they were crawled
+}
+
+// WithReport collects the pages and errors of each crawl in to r, which can be read once Crawl has returned. Crawls
+// running at once add to r as each of them finishes.
+func WithReport(r *Report) Option {
+	return func(c *crawler) {
+		c.report = r
+	}
+}
+
+// add adds the pages, errors and summary of another crawl to r
+func (r *Report) add(o *Report) {
+	r.Summary.add(&o.Summary)
+	r.Pages = append(r.Pages, o.Pages...)
+	r.Errors = append(r.Errors, o.Errors...)
+	r.Sitemap = append(r.Sitemap, o.Sitemap...)
+	for _, target := range o.External {
+		r.recordExternal(target)
+	}
+	for target, sources := range o.LinkedFrom {
+		if r.LinkedFrom == nil {
+			r.LinkedFrom = map[string][]string{}
+		}
+		r.LinkedFrom[target] = append(r.LinkedFrom[target], sources...)
+	}
+}
+
+// record is a subscriber adding crawled pages and non-fatal errors to the report
+func (r *Report) record(e Event) {
+	switch e := e.(type) {
+	case PageParsed:
+		page := PageRecord{
+			URL:           displayURL(e.Page.URL),
+			StatusCode:    e.Page.StatusCode,
+			ContentLength: e.Page.ContentLength,
+			ContentHash:   e.Page.ContentHash,
+			LastModified:  e.Page.LastModified,
+			FetchDuration: e.Page.FetchDuration,
+			Title:         e.Page.Title,
+			Description:   e.Page.Description,
+		}
+		if e.Page.Referrer != nil {
+			page.Referrer = displayURL(e.Page.Referrer)
+		}
+		if e.Page.RedirectedTo != nil {
+			page.RedirectedTo = displayURL(e.Page.RedirectedTo)
+		}
+		if e.Page.Location != nil {
+			page.Location = displayURL(e.Page.Location)
+		}
+		if e.Page.Refresh != nil {
+			page.Refresh = displayURL(e.Page.Refresh)
+		}
+		if e.Page.AMP != nil {
+			page.AMP = displayURL(e.Page.AMP)
+		}
+		page.Soft404 = e.Page.Soft404
+		page.OffsiteHops = e.Page.OffsiteHops
+		page.Cookies = e.Page.Cookies
+		page.Contacts = e.Page.Contacts
+		page.Accessibility = e.Page.Accessibility
+		for _, image := range e.Page.Images {
+			page.Images = append(page.Images, ImageRecord{URL: displayURL(image.URL), Alt: image.Alt, HasAlt: image.HasAlt, Width: image.Width, Height: image.Height})
+		}
+		for _, anchor := range e.Page.AnchorTexts {
+			page.AnchorTexts = append(page.AnchorTexts, AnchorTextRecord{URL: displayURL(anchor.URL), Text: anchor.Text, Title: anchor.Title})
+		}
+		for _, ref := range e.Page.ThirdParty {
+			page.ThirdParty = append(page.ThirdParty, ThirdPartyRecord{Element: ref.Element, URL: displayURL(ref.URL)})
+		}
+		for _, tracker := range e.Page.Trackers {
+			page.Trackers = append(page.Trackers, TrackerRecord{Name: tracker.Name, URL: displayURL(tracker.URL)})
+		}
+		for _, sub := range e.Page.Subresources {
+			page.Subresources = append(page.Subresources, SubresourceRecord{Element: sub.Element, URL: displayURL(sub.URL), Integrity: sub.Integrity, Status: sub.Status})
+		}
+		for _, form := range e.Page.Forms {
+			page.Forms = append(page.Forms, FormRecord{Method: form.Method, Action: displayURL(form.Action)})
+		}
+		for _, form := range e.Page.InsecureForms {
+			page.InsecureForms = append(page.InsecureForms, InsecureFormRecord{Page: page.URL, Action: displayURL(form.Action), Method: form.Method, Reason: form.Reason})
+		}
+		for _, mixed := range e.Page.MixedContent {
+			page.MixedContent = append(page.MixedContent, MixedContentRecord{Page: page.URL, Element: mixed.Element, URL: displayURL(mixed.URL)})
+		}
+		for _, alternate := range e.Page.Alternates {
+			page.Alternates = append(page.Alternates, displayURL(alternate))
+		}
+		r.Pages = append(r.Pages, page)
+		if e.Page.OffsiteHops == 0 {
+			r.recordLinks(page.URL, e.Page.Links)
+		}
+		if e.Page.OffsiteHops == 1 {
+			r.recordExternal(page.URL)
+		}
+	case URLSkipped:
+		if e.Reason == SkipOutOfScope {
+			r.recordExternal(displayURL(e.URL))
+		}
+	case ErrorOccurred:
+		r.Errors = append(r.Errors, newErrorRecord(e.Err))
+	}
+}
+
+// recordLinks records a page as linking to each of its links, once each
+func (r *Report) recordLinks(source string, links []*url.URL) {
+	if r.LinkedFrom == nil {
+		r.LinkedFrom = map[string][]string{}
+	}
+	seen := map[string]bool{}
+	for _, link := range links {
+		target := displayURL(link)
+		if !seen[target] {
+			seen[target] = true
+			r.LinkedFrom[target] = append(r.LinkedFrom[target], source)
+		}
+	}
+}
+
+// recordExternal records a URL as out of scope, once
+func (r *Report) recordExternal(target string) {
+	if r.external == nil {
+		r.external = map[string]bool{}
+	}
+	if !r.external[target] {
+		r.external[target] = true
+		r.External = append(r.External, target)
+	}
+}
+
+// ExternalLinks returns the out of scope URLs which the in scope pages crawled link to, e.g. those on other hosts, or
+// outside the seeds' paths with PathPrefix, those linked to by the most pages first
+func (r *Report) ExternalLinks() []ExternalLink {
+	statuses := map[string]int{}
+	for _, page := range r.Pages {
+		if page.OffsiteHops > 0 {
+			statuses[page.URL] = page.StatusCode
+		}
+	}
+
+	links := []ExternalLink{}
+	for _, target := range r.External {
+		sources := r.LinkedFrom[target]
+		if len(sources) == 0 {
+			continue // only linked to by pages which were out of scope themselves
+		}
+		link := ExternalLink{URL: target, StatusCode: statuses[target], Pages: len(sources)}
+		for _, source := range sources {
+			if len(link.Examples) == maxDomainExamples {
+				break
+			}
+			link.Examples = append(link.Examples, source)
+		}
+		links = append(links, link)
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].Pages > links[j].Pages
+	})
+	return links
+}
+
+// BrokenLinks returns the errors for pages which responded with an HTTP error status code or were soft 404s
+func (r *Report) BrokenLinks() []ErrorRecord {
+	broken := []ErrorRecord{}
+	for _, record := range r.Errors {
+		if record.Broken() {
+			broken = append(broken, record)
+		}
+	}
+	return broken
+}
+
+// BrokenAlternates returns the pages whose AMP or alternate versions couldn't be fetched. Alternates are only fetched
+// if they're linked to or WithFollowAlternates is given.
+func (r *Report) BrokenAlternates() []AlternateRecord {
+	errs := map[string]ErrorRecord{}
+	for _, record := range r.Errors {
+		if _, ok := errs[record.URL]; !ok {
+			errs[record.URL] = record
+		}
+	}
+
+	broken := []AlternateRecord{}
+	for _, page := range r.Pages {
+		if record, ok := errs[page.AMP]; ok && page.AMP != "" {
+			broken = append(broken, AlternateRecord{Page: page.URL, Alternate: page.AMP, Rel: "amphtml", Error: record})
+		}
+		for _, alternate := range page.Alternates {
+			if record, ok := errs[alternate]; ok {
+				broken = append(broken, AlternateRecord{Page: page.URL, Alternate: alternate, Rel: "alternate", Error: record})
+			}
+		}
+	}
+	return broken
+}
+
+// SlowestPages returns up to n pages, slowest first
+func (r *Report) SlowestPages(n int) []PageRecord {
+	pages := append([]PageRecord{}, r.Pages...)
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].FetchDuration > pages[j].FetchDuration
+	})
+	if len(pages) > n {
+		pages = pages[:n]
+	}
+	return pages
+}
+
+// LargestPages returns up to n pages, largest first
+func (r *Report) LargestPages(n int) []PageRecord {
+	pages := append([]PageRecord{}, r.Pages...)
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].ContentLength > pages[j].ContentLength
+	})
+	if len(pages) > n {
+		pages = pages[:n]
+	}
+	return pages
+}
+
+// DuplicateTitles returns the titles shared by more than one page, those shared by the most pages first. Duplicated
+// titles usually come from a templating bug, or from the same page being reachable at several URLs.
+func (r *Report) DuplicateTitles() []DuplicateRecord {
+	return r.duplicates(func(page PageRecord) string {
+		return page.Title
+	})
+}
+
+// DuplicateDescriptions returns the meta descriptions shared by more than one page, those shared by the most pages
+// first
+func (r *Report) DuplicateDescriptions() []DuplicateRecord {
+	return r.duplicates(func(page PageRecord) string {
+		return page.Description
+	})
+}
+
+// duplicates groups the successfully fetched pages by a value of theirs, returning the groups of more than one page
+func (r *Report) duplicates(value func(PageRecord) string) []DuplicateRecord {
+	groups := map[string]*DuplicateRecord{}
+	order := []*DuplicateRecord{}
+	for _, page := range r.Pages {
+		v := value(page)
+		if v == "" || page.StatusCode < 200 || page.StatusCode >= 300 || page.Soft404 != "" {
+			continue
+		}
+		group, ok := groups[v]
+		if !ok {
+			group = &DuplicateRecord{Value: v}
+			groups[v] = group
+			order = append(order, group)
+		}
+		group.URLs = append(group.URLs, page.URL)
+	}
+
+	duplicates := []DuplicateRecord{}
+	for _, group := range order {
+		if len(group.URLs) > 1 {
+			duplicates = append(duplicates, *group)
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return len(duplicates[i].URLs) > len(duplicates[j].URLs)
+	})
+	return duplicates
+}
+
+// Orphans returns the URLs listed in the sitemap which weren't reached by following links, so which no page crawled
+// links to, e.g. pages left behind by a redesign. A crawl stopped short, e.g. by WithMaxPages, leaves pages unreached
+// which may be linked to after all.
+func (r *Report) Orphans() []string {
+	crawled := map[string]bool{}
+	for _, page := range r.Pages {
+		crawled[page.URL] = true
+		if page.RedirectedTo != "" {
+			crawled[page.RedirectedTo] = true
+		}
+	}
+	for _, record := range r.Errors {
+		crawled[record.URL] = true
+	}
+
+	orphans := []string{}
+	listed := map[string]bool{}
+	for _, u := range r.Sitemap {
+		if !crawled[u] && !listed[u] {
+			orphans = append(orphans, u)
+		}
+		listed[u] = true
+	}
+	return orphans
+}
+
+// MissingFromSitemap returns the pages crawled successfully which the sitemap doesn't list, or none if no sitemap was
+// found
+func (r *Report) MissingFromSitemap() []PageRecord {
+	missing := []PageRecord{}
+	if len(r.Sitemap) == 0 {
+		return missing
+	}
+	listed := map[string]bool{}
+	for _, u := range r.Sitemap {
+		listed[u] = true
+	}
+	for _, page := range r.Pages {
+		if page.StatusCode < 200 || page.StatusCode >= 300 || page.Soft404 != "" || page.RedirectedTo != "" {
+			continue
+		}
+		if !listed[page.URL] {
+			missing = append(missing, page)
+		}
+	}
+	return missing
+}
+
+// InternalRedirects returns every link between in scope pages whose target redirects, in the order the targets were
+// crawled, so that the links can be updated to point straight at the destination. Meta refreshes count as redirects.
+func (r *Report) InternalRedirects() []RedirectRecord {
+	redirects := []RedirectRecord{}
+	for _, page := range r.Pages {
+		destination := page.RedirectedTo
+		if destination == "" {
+			destination = page.Location
+		}
+		if destination == "" {
+			destination = page.Refresh
+		}
+		if destination == "" || page.OffsiteHops > 0 {
+			continue
+		}
+		for _, source := range r.LinkedFrom[page.URL] {
+			redirects = append(redirects, RedirectRecord{Source: source, Target: page.URL, Destination: destination})
+		}
+	}
+	return redirects
+}
+
+// MixedContent returns the http:// assets of every https:// page crawled, recorded with WithMixedContentDetection
+func (r *Report) MixedContent() []MixedContentRecord {
+	mixed := []MixedContentRecord{}
+	for _, page := range r.Pages {
+		mixed = append(mixed, page.MixedContent...)
+	}
+	return mixed
+}
+
+// InsecureForms returns the forms of every page crawled which submit over plain http or to another origin, recorded
+// with WithInsecureFormDetection
+func (r *Report) InsecureForms() []InsecureFormRecord {
+	forms := []InsecureFormRecord{}
+	for _, page := range r.Pages {
+		forms = append(forms, page.InsecureForms...)
+	}
+	return forms
+}
+
+// CookieIssues returns the cookies set without the Secure, HttpOnly or SameSite attributes, once per host and cookie
+// name, grouped by host in the order the hosts were crawled, recorded with WithCookieAudit
+func (r *Report) CookieIssues() []CookieRecord {
+	issues := []CookieRecord{}
+	hosts := map[string]int{} // the order the hosts were first crawled in
+	seen := map[string]bool{}
+	for _, page := range r.Pages {
+		if len(page.Cookies) == 0 {
+			continue
+		}
+		rawURL := page.URL
+		if page.RedirectedTo != "" {
+			rawURL = page.RedirectedTo
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		if _, ok := hosts[u.Host]; !ok {
+			hosts[u.Host] = len(hosts)
+		}
+		for _, cookie := range page.Cookies {
+			missing := cookie.Missing()
+			key := u.Host + " " + cookie.Name
+			if len(missing) == 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			issues = append(issues, CookieRecord{Host: u.Host, Name: cookie.Name, FirstSeen: page.URL, Missing: missing})
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return hosts[issues[i].Host] < hosts[issues[j].Host]
+	})
+	return issues
+}
+
+// Subresources returns the scripts and stylesheets loaded from other origins by the pages crawled, once per URL and
+// integrity status, in the order they were first seen, recorded with WithSubresourceIntegrity
+func (r *Report) Subresources() []SubresourceUsage {
+	usages := []*SubresourceUsage{}
+	byKey := map[string]*SubresourceUsage{}
+	for _, page := range r.Pages {
+		seen := map[string]bool{}
+		for _, sub := range page.Subresources {
+			key := sub.URL + " " + sub.Status
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			usage, ok := byKey[key]
+			if !ok {
+				usage = &SubresourceUsage{SubresourceRecord: sub, FirstSeen: page.URL}
+				byKey[key] = usage
+				usages = append(usages, usage)
+			}
+			usage.Pages++
+		}
+	}
+
+	subresources := make([]SubresourceUsage, 0, len(usages))
+	for _, usage := range usages {
+		subresources = append(subresources, *usage)
+	}
+	return subresources
+}
+
+// ThirdPartyDomains returns the host names of other registrable domains the pages crawled link to or load from, those
+// referenced by the most pages first, recorded with WithThirdPartyInventory
+func (r *Report) ThirdPartyDomains() []DomainUsage {
+	usages := map[string]*DomainUsage{}
+	elements := map[string]map[string]bool{}
+	for _, page := range r.Pages {
+		referenced := map[string]bool{}
+		for _, ref := range page.ThirdParty {
+			u, err := url.Parse(ref.URL)
+			if err != nil {
+				continue
+			}
+			domain := strings.ToLower(u.Hostname())
+			usage, ok := usages[domain]
+			if !ok {
+				usage = &DomainUsage{Domain: domain}
+				usages[domain] = usage
+				elements[domain] = map[string]bool{}
+			}
+			usage.References++
+			elements[domain][ref.Element] = true
+			if !referenced[domain] {
+				referenced[domain] = true
+				usage.Pages++
+				if len(usage.Examples) < maxDomainExamples {
+					usage.Examples = append(usage.Examples, page.URL)
+				}
+			}
+		}
+	}
+
+	domains := make([]DomainUsage, 0, len(usages))
+	for domain, usage := range usages {
+		for element := range elements[domain] {
+			usage.Elements = append(usage.Elements, element)
+		}
+		sort.Strings(usage.Elements)
+		domains = append(domains, *usage)
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].Pages != domains[j].Pages {
+			return domains[i].Pages > domains[j].Pages
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+	return domains
+}
+
+// Trackers returns the analytics and tracking services whose scripts the pages crawled load, those loaded by the most
+// pages first, recorded with WithTrackerDetection. Which pages load which trackers is recorded on each PageRecord.
+func (r *Report) Trackers() []TrackerUsage {
+	usages := map[string]*TrackerUsage{}
+	scripts := map[string]map[string]bool{}
+	for _, page := range r.Pages {
+		loaded := map[string]bool{}
+		for _, tracker := range page.Trackers {
+			usage, ok := usages[tracker.Name]
+			if !ok {
+				usage = &TrackerUsage{Name: tracker.Name}
+				usages[tracker.Name] = usage
+				scripts[tracker.Name] = map[string]bool{}
+			}
+			if !scripts[tracker.Name][tracker.URL] {
+				scripts[tracker.Name][tracker.URL] = true
+				usage.Scripts = append(usage.Scripts, tracker.URL)
+			}
+			if !loaded[tracker.Name] {
+				loaded[tracker.Name] = true
+				usage.Pages++
+				if len(usage.Examples) < maxDomainExamples {
+					usage.Examples = append(usage.Examples, page.URL)
+				}
+			}
+		}
+	}
+
+	trackers := make([]TrackerUsage, 0, len(usages))
+	for _, usage := range usages {
+		trackers = append(trackers, *usage)
+	}
+	sort.Slice(trackers, func(i, j int) bool {
+		if trackers[i].Pages != trackers[j].Pages {
+			return trackers[i].Pages > trackers[j].Pages
+		}
+		return trackers[i].Name < trackers[j].Name
+	})
+	return trackers
+}
+
+// FormEndpoints returns the endpoints the forms of the pages crawled submit to, those of the most pages first,
+// recorded with WithFormDiscovery. Which pages have which forms is recorded on each PageRecord.
+func (r *Report) FormEndpoints() []FormEndpoint {
+	endpoints := map[FormRecord]*FormEndpoint{}
+	for _, page := range r.Pages {
+		for _, form := range page.Forms {
+			endpoint, ok := endpoints[form]
+			if !ok {
+				endpoint = &FormEndpoint{FormRecord: form}
+				endpoints[form] = endpoint
+			}
+			// a page's forms are recorded once each
+			endpoint.Pages++
+			if len(endpoint.Examples) < maxDomainExamples {
+				endpoint.Examples = append(endpoint.Examples, page.URL)
+			}
+		}
+	}
+
+	forms := make([]FormEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		forms = append(forms, *endpoint)
+	}
+	sort.Slice(forms, func(i, j int) bool {
+		if forms[i].Pages != forms[j].Pages {
+			return forms[i].Pages > forms[j].Pages
+		}
+		if forms[i].Action != forms[j].Action {
+			return forms[i].Action < forms[j].Action
+		}
+		return forms[i].Method < forms[j].Method
+	})
+	return forms
+}
+
+// AnchorTexts returns the texts of the links to each URL from the in scope pages crawled, recorded with WithAnchorText,
+// the URLs with the most links first and each URL's most used texts first. Which page has which links is recorded on
+// each PageRecord.
+func (r *Report) AnchorTexts() []AnchorTextUsage {
+	usages := map[string]*AnchorTextUsage{}
+	counts := map[string]map[string]int{}
+	order := []string{}
+	for _, page := range r.Pages {
+		if page.OffsiteHops > 0 {
+			continue
+		}
+		linked := map[string]bool{}
+		for _, anchor := range page.AnchorTexts {
+			usage, ok := usages[anchor.URL]
+			if !ok {
+				usage = &AnchorTextUsage{URL: anchor.URL}
+				usages[anchor.URL] = usage
+				counts[anchor.URL] = map[string]int{}
+				order = append(order, anchor.URL)
+			}
+			usage.Links++
+			if !linked[anchor.URL] {
+				linked[anchor.URL] = true
+				usage.Pages++
+			}
+			counts[anchor.URL][anchor.Text]++
+		}
+	}
+
+	records := make([]AnchorTextUsage, 0, len(order))
+	for _, target := range order {
+		usage := usages[target]
+		for text, links := range counts[target] {
+			usage.Texts = append(usage.Texts, AnchorTextCount{Text: text, Links: links})
+		}
+		sort.Slice(usage.Texts, func(i, j int) bool {
+			if usage.Texts[i].Links != usage.Texts[j].Links {
+				return usage.Texts[i].Links > usage.Texts[j].Links
+			}
+			return usage.Texts[i].Text < usage.Texts[j].Text
+		})
+		records = append(records, *usage)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Links > records[j].Links
+	})
+	return records
+}
+
+// ImagesWithoutDimensions returns the images of the pages crawled without a declared width or height, which shift the
+// layout of the page around them as they load, those on the most pages first, recorded with WithImageInventory. The
+// alt text and dimensions of every image of each page are recorded on its PageRecord.
+func (r *Report) ImagesWithoutDimensions() []ImageUsage {
+	usages := map[string]*ImageUsage{}
+	order := []string{}
+	for _, page := range r.Pages {
+		seen := map[string]bool{}
+		for _, image := range page.Images {
+			if (image.Width != "" && image.Height != "") || seen[image.URL] {
+				continue
+			}
+			seen[image.URL] = true
+			usage, ok := usages[image.URL]
+			if !ok {
+				usage = &ImageUsage{URL: image.URL}
+				usages[image.URL] = usage
+				order = append(order, image.URL)
+			}
+			usage.Pages++
+			if len(usage.Examples) < maxDomainExamples {
+				usage.Examples = append(usage.Examples, page.URL)
+			}
+		}
+	}
+
+	images := make([]ImageUsage, 0, len(order))
+	for _, image := range order {
+		images = append(images, *usages[image])
+	}
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].Pages > images[j].Pages
+	})
+	return images
+}
+
+// Accessibility returns the pages crawled with accessibility issues, those with the most first, recorded with
+// WithAccessibilityChecks. The images and links of each are recorded on its PageRecord.
+func (r *Report) Accessibility() []AccessibilityRecord {
+	records := []AccessibilityRecord{}
+	for _, page := range r.Pages {
+		record := AccessibilityRecord{Page: page.URL}
+		for _, issue := range page.Accessibility {
+			switch issue.Check {
+			case AccessibilityMissingAlt:
+				record.MissingAlt++
+			case AccessibilityEmptyLink:
+				record.EmptyLinks++
+			case AccessibilityMissingLang:
+				record.MissingLang = true
+			}
+		}
+		if record.issues() > 0 {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].issues() > records[j].issues()
+	})
+	return records
+}
@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":     {Title: "Home", Links: []string{"/slow", "/missing"}},
+		"/slow": {Title: "Slow", Latency: 20 * time.Millisecond},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	c := New(1, srv.Client(), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	require.Equal(t, 2, report.Summary.Pages)
+	require.Equal(t, 1, report.Summary.Errors)
+	require.Len(t, report.Pages, 2)
+
+	broken := report.BrokenLinks()
+	require.Len(t, broken, 1)
+	require.Equal(t, srv.URL+"/missing", broken[0].URL)
+	require.Equal(t, srv.URL+"/", broken[0].Referrer)
+
+	slowest := report.SlowestPages(1)
+	require.Len(t, slowest, 1)
+	require.Equal(t, srv.URL+"/slow", slowest[0].URL)
+	require.Equal(t, "Slow", slowest[0].Title)
+	require.Equal(t, srv.URL+"/", slowest[0].Referrer)
+
+	largest := report.LargestPages(5)
+	require.Len(t, largest, 2)
+	require.GreaterOrEqual(t, largest[0].ContentLength, largest[1].ContentLength)
+}
+
+func TestReportBrokenAlternates(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/": {Body: `<html><head>
+			<link rel="amphtml" href="/amp">
+			<link rel="alternate" media="only screen and (max-width: 640px)" href="/m">
+			<link rel="alternate" hreflang="fr" href="/fr">
+			<link rel="alternate" type="application/rss+xml" href="/feed">
+		</head></html>`},
+		"/m":  {},
+		"/fr": {Status: http.StatusInternalServerError},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	c := New(1, srv.Client(), WithReport(report), WithFollowAlternates(), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	require.Equal(t, srv.URL+"/amp", report.Pages[0].AMP)
+	require.Equal(t, []string{srv.URL + "/m", srv.URL + "/fr"}, report.Pages[0].Alternates)
+	require.Equal(t, 0, srv.Requests("/feed"))
+
+	broken := report.BrokenAlternates()
+	require.Len(t, broken, 2)
+	require.Equal(t, srv.URL+"/", broken[0].Page)
+	require.Equal(t, srv.URL+"/amp", broken[0].Alternate)
+	require.Equal(t, "amphtml", broken[0].Rel)
+	require.Equal(t, http.StatusNotFound, broken[0].Error.StatusCode)
+	require.Equal(t, srv.URL+"/fr", broken[1].Alternate)
+	require.Equal(t, "alternate", broken[1].Rel)
+	require.Equal(t, http.StatusInternalServerError, broken[1].Error.StatusCode)
+}
+
+func TestReportDuplicates(t *testing.T) {
+	report := &Report{Pages: []PageRecord{
+		{URL: "http://monzo.com/", StatusCode: 200, Title: "Monzo", Description: "Banking"},
+		{URL: "http://monzo.com/a", StatusCode: 200, Title: "Page", Description: "Banking"},
+		{URL: "http://monzo.com/b", StatusCode: 200, Title: "Page", Description: "Other"},
+		{URL: "http://monzo.com/c", StatusCode: 200, Title: "Page"},
+		{URL: "http://monzo.com/d", StatusCode: 200, Title: "Monzo"},
+		{URL: "http://monzo.com/e", StatusCode: 200, Title: "Monzo", Soft404: "body of 0 bytes"},
+		{URL: "http://monzo.com/f", StatusCode: 301, Title: "Monzo"},
+		{URL: "http://monzo.com/g", StatusCode: 200, Title: "Unique"},
+	}}
+
+	require.Equal(t, []DuplicateRecord{
+		{Value: "Page", URLs: []string{"http://monzo.com/a", "http://monzo.com/b", "http://monzo.com/c"}},
+		{Value: "Monzo", URLs: []string{"http://monzo.com/", "http://monzo.com/d"}},
+	}, report.DuplicateTitles())
+	require.Equal(t, []DuplicateRecord{
+		{Value: "Banking", URLs: []string{"http://monzo.com/", "http://monzo.com/a"}},
+	}, report.DuplicateDescriptions())
+}
+
+func TestReportInternalRedirects(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":      {Links: []string{"/old", "/about", "/old"}},
+		"/about": {Links: []string{"/old", "/moved"}},
+		"/old":   {RedirectTo: "/older"},
+		"/older": {RedirectTo: "/new"},
+		"/new":   {},
+		"/moved": {Body: `<html><head><meta http-equiv="refresh" content="0; url=/new"></head></html>`},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	c := New(1, srv.Client(), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	require.ElementsMatch(t, []RedirectRecord{
+		{Source: srv.URL + "/", Target: srv.URL + "/old", Destination: srv.URL + "/new"},
+		{Source: srv.URL + "/about", Target: srv.URL + "/old", Destination: srv.URL + "/new"},
+		{Source: srv.URL + "/about", Target: srv.URL + "/moved", Destination: srv.URL + "/new"},
+	}, report.InternalRedirects())
+}
+
+func TestReportExternalLinks(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/docs/":    {Links: []string{"/docs/api", "/blog", "http://other.test/"}},
+		"/docs/api": {Links: []string{"/blog", "/about", "/docs/"}},
+		"/blog":     {Links: []string{"/careers"}},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	c := New(1, srv.Client(), WithScopePolicy(PathPrefix{}), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/docs/", &bytes.Buffer{}))
+	require.Equal(t, 2, report.Summary.Pages)
+	require.Equal(t, []ExternalLink{
+		{URL: srv.URL + "/blog", Pages: 2, Examples: []string{srv.URL + "/docs/", srv.URL + "/docs/api"}},
+		{URL: "http://other.test/", Pages: 1, Examples: []string{srv.URL + "/docs/"}},
+		{URL: srv.URL + "/about", Pages: 1, Examples: []string{srv.URL + "/docs/api"}},
+	}, report.ExternalLinks())
+
+	// pages crawled out of scope have their status recorded, and their own links aren't external links
+	srv = crawltest.NewServer(crawltest.Site{
+		"/docs/": {Links: []string{"/blog"}},
+		"/blog":  {Links: []string{"/careers"}},
+	})
+	defer srv.Close()
+
+	report = &Report{}
+	c = New(1, srv.Client(), WithScopePolicy(PathPrefix{}), WithOffsiteDepth(1), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/docs/", &bytes.Buffer{}))
+	require.Equal(t, []ExternalLink{
+		{URL: srv.URL + "/blog", StatusCode: http.StatusOK, Pages: 1, Examples: []string{srv.URL + "/docs/"}},
+	}, report.ExternalLinks())
+}
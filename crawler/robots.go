@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// valuedRobotsDirectives are the robots directives which take a value after a colon, so aren't mistaken for a user
+// agent prefix, e.g. "unavailable_after: 25 Jun 2010 15:00:00 PST"
+var valuedRobotsDirectives = map[string]struct{}{
+	"unavailable_after": {},
+	"max-snippet":       {},
+	"max-image-preview": {},
+	"max-video-preview": {},
+}
+
+// applyRobotsDirectives sets NoIndex and NoFollow on a page from a comma separated list of robots directives, as
+// found in a robots meta tag or X-Robots-Tag header. Directives prefixed with a user agent, e.g. "googlebot: noindex",
+// are aimed at other crawlers and ignored.
+func applyRobotsDirectives(page *Page, directives string) {
+	if i := strings.Index(directives, ":"); i >= 0 {
+		prefix := strings.ToLower(strings.TrimSpace(directives[:i]))
+		if _, ok := valuedRobotsDirectives[prefix]; !ok && !strings.Contains(prefix, ",") {
+			return
+		}
+	}
+
+	for _, directive := range strings.Split(directives, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "noindex":
+			page.NoIndex = true
+		case "nofollow":
+			page.NoFollow = true
+		case "none":
+			page.NoIndex = true
+			page.NoFollow = true
+		}
+	}
+}
+
+// applyRobotsHeaders applies the directives of each X-Robots-Tag header of a response to a page
+func applyRobotsHeaders(page *Page, header http.Header) {
+	for _, directives := range header["X-Robots-Tag"] {
+		applyRobotsDirectives(page, directives)
+	}
+}
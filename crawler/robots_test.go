@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRobotsDirectives(t *testing.T) {
+	tests := []struct {
+		title, directives string
+		noIndex, noFollow bool
+	}{
+		{"empty", "", false, false},
+		{"all", "all", false, false},
+		{"noindex", "noindex", true, false},
+		{"nofollow", "NoFollow", false, true},
+		{"both", "noindex, nofollow", true, true},
+		{"none", "none", true, true},
+		{"valued directive", "unavailable_after: 25 Jun 2010 15:00:00 PST, noindex", true, false},
+		{"other user agent", "googlebot: noindex, nofollow", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			page := &Page{}
+			applyRobotsDirectives(page, tt.directives)
+			require.Equal(t, tt.noIndex, page.NoIndex)
+			require.Equal(t, tt.noFollow, page.NoFollow)
+		})
+	}
+}
+
+func TestApplyRobotsHeaders(t *testing.T) {
+	page := &Page{}
+	applyRobotsHeaders(page, http.Header{"X-Robots-Tag": []string{"googlebot: nofollow", "noindex"}})
+	require.True(t, page.NoIndex)
+	require.False(t, page.NoFollow)
+}
@@ -0,0 +1,166 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxRobotsTxtSize is the number of bytes of a robots.txt file parsed, the minimum RFC 9309 requires crawlers to
+// parse, with the rest ignored
+const maxRobotsTxtSize = 500 << 10
+
+// RobotsTxt is a parsed robots.txt file, see ParseRobotsTxt
+type RobotsTxt struct {
+	Sitemaps []string // the URLs of its Sitemap lines, which don't belong to any group, in order
+	groups   []robotsGroup
+}
+
+// robotsGroup is the rules of a robots.txt file for the user agents of one or more consecutive User-agent lines
+type robotsGroup struct {
+	agents []string // lower cased product tokens, or "*"
+	rules  []RobotsRule
+}
+
+// RobotsRule is an Allow or Disallow line of a robots.txt file
+type RobotsRule struct {
+	Allow   bool
+	Path    string // the path pattern, which may contain "*" wildcards and end with "$"
+	Line    int    // the line of the file the rule is on, counting from 1
+	pattern *regexp.Regexp
+}
+
+// String formats the rule as in a robots.txt file, e.g. "Disallow: /private/"
+func (r *RobotsRule) String() string {
+	if r.Allow {
+		return "Allow: " + r.Path
+	}
+	return "Disallow: " + r.Path
+}
+
+// ParseRobotsTxt parses a robots.txt file as RFC 9309 describes, ignoring lines it doesn't understand, so that it only
+// fails if r does
+func ParseRobotsTxt(r io.Reader) (*RobotsTxt, error) {
+	robots := &RobotsTxt{}
+	var group *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxRobotsTxtSize))
+	scanner.Buffer(make([]byte, 0, 4096), maxRobotsTxtSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		i := strings.Index(text, ":")
+		if i < 0 {
+			continue
+		}
+		key, value := strings.ToLower(strings.TrimSpace(text[:i])), strings.TrimSpace(text[i+1:])
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				robots.groups = append(robots.groups, robotsGroup{})
+				group = &robots.groups[len(robots.groups)-1]
+				inAgents = true
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			// an empty Disallow allows everything, as does the lack of any rule
+			if group == nil || value == "" {
+				continue
+			}
+			group.rules = append(group.rules, RobotsRule{Allow: key == "allow", Path: value, Line: line, pattern: robotsPattern(value)})
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+	}
+	return robots, scanner.Err()
+}
+
+// robotsPattern compiles a robots.txt path pattern, matched against the start of a URL's path and query
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+	expr := "^" + strings.Replace(regexp.QuoteMeta(path), `\*`, ".*", -1)
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// Test returns whether a crawler with the given User-Agent may fetch u, and the rule deciding it, or nil if no rule
+// matches u, in which case it's allowed. Of the rules matching u, the one with the longest path is used, and Allow
+// wins ties. The groups whose User-agent lines name the User-Agent's product token, e.g. "googlebot" for
+// "Googlebot/2.1", apply to it, or the groups for "*" if there are none.
+func (r *RobotsTxt) Test(userAgent string, u *url.URL) (bool, *RobotsRule) {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true, nil
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	var decisive *RobotsRule
+	for _, rule := range r.rules(userAgent) {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if decisive == nil || len(rule.Path) > len(decisive.Path) || (len(rule.Path) == len(decisive.Path) && rule.Allow) {
+			decisive = rule
+		}
+	}
+	return decisive == nil || decisive.Allow, decisive
+}
+
+// rules returns the rules of the groups applying to a User-Agent
+func (r *RobotsTxt) rules(userAgent string) []*RobotsRule {
+	token := ""
+	if fields := strings.Fields(userAgent); len(fields) > 0 {
+		token = strings.ToLower(strings.SplitN(fields[0], "/", 2)[0])
+	}
+
+	var named, wildcard []*RobotsRule
+	isNamed := false
+	for i := range r.groups {
+		group := &r.groups[i]
+		switch {
+		case group.names(token):
+			isNamed = true
+			for j := range group.rules {
+				named = append(named, &group.rules[j])
+			}
+		case group.names("*"):
+			for j := range group.rules {
+				wildcard = append(wildcard, &group.rules[j])
+			}
+		}
+	}
+	if isNamed {
+		return named
+	}
+	return wildcard
+}
+
+// names reports whether any of the group's User-agent lines is agent
+func (g *robotsGroup) names(agent string) bool {
+	for _, a := range g.agents {
+		if a == agent && agent != "" {
+			return true
+		}
+	}
+	return false
+}
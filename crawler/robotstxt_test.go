@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRobotsTxt(t *testing.T) {
+	robots, err := ParseRobotsTxt(strings.NewReader("\ufeff# robots.txt for monzo.com\n" + `User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Disallow: /search?
+Disallow:
+
+User-agent: Googlebot
+User-agent: bingbot
+Disallow: /beta # not for search engines yet
+Allow: /beta/launch
+
+user-agent: BadBot
+disallow: /
+
+Sitemap: https://monzo.com/sitemap.xml
+sitemap: /blog/sitemap.xml
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://monzo.com/sitemap.xml", "/blog/sitemap.xml"}, robots.Sitemaps)
+
+	for _, test := range []struct {
+		userAgent, url string
+		allowed        bool
+		rule           string
+	}{
+		{DefaultUserAgent, "http://monzo.com/", true, ""},
+		{DefaultUserAgent, "http://monzo.com/private/account", false, "Disallow: /private/"},
+		{DefaultUserAgent, "http://monzo.com/private/public/page", true, "Allow: /private/public"},
+		{DefaultUserAgent, "http://monzo.com/files/terms.pdf", false, "Disallow: /*.pdf$"},
+		{DefaultUserAgent, "http://monzo.com/files/terms.pdf?v=2", true, ""},
+		{DefaultUserAgent, "http://monzo.com/search?q=card", false, "Disallow: /search?"},
+		{DefaultUserAgent, "http://monzo.com/search", true, ""},
+		{"Googlebot/2.1 (+http://www.google.com/bot.html)", "http://monzo.com/private/account", true, ""},
+		{"Googlebot/2.1", "http://monzo.com/beta/features", false, "Disallow: /beta"},
+		{"bingbot", "http://monzo.com/beta/launch", true, "Allow: /beta/launch"},
+		{"BadBot/1.0", "http://monzo.com/", false, "Disallow: /"},
+		{"BadBot/1.0", "http://monzo.com/robots.txt", true, ""},
+		{"", "http://monzo.com/private/account", false, "Disallow: /private/"},
+	} {
+		u, err := url.Parse(test.url)
+		require.NoError(t, err)
+		allowed, rule := robots.Test(test.userAgent, u)
+		require.Equal(t, test.allowed, allowed, "%s %s", test.userAgent, test.url)
+		if test.rule == "" {
+			require.Nil(t, rule, "%s %s", test.userAgent, test.url)
+		} else {
+			require.NotNil(t, rule, "%s %s", test.userAgent, test.url)
+			require.Equal(t, test.rule, rule.String(), "%s %s", test.userAgent, test.url)
+		}
+	}
+}
+
+func TestRobotsTxtRuleLine(t *testing.T) {
+	robots, err := ParseRobotsTxt(strings.NewReader("User-agent: *\n\nDisallow: /private/\n"))
+	require.NoError(t, err)
+	_, rule := robots.Test(DefaultUserAgent, &url.URL{Path: "/private/a"})
+	require.Equal(t, 3, rule.Line)
+}
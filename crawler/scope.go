@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ScopePolicy decides which links are part of the site being crawled. A link is in scope if it's in scope of any of
+// the crawl's seeds. Both URLs have their host aliases resolved and hostnames in lower case punycode, and seeds
+// differing only in their query are considered once.
+type ScopePolicy interface {
+	InScope(seed, link *url.URL) bool
+}
+
+// SameHost is the default ScopePolicy, keeping a crawl to the hosts of its seeds
+type SameHost struct{}
+
+func (SameHost) InScope(seed, link *url.URL) bool {
+	return link.Hostname() == seed.Hostname()
+}
+
+// SameRegistrableDomain keeps a crawl to the registrable domains of its seeds, e.g. a seed of "www.example.co.uk"
+// includes "shop.example.co.uk" but not "example.com"
+type SameRegistrableDomain struct{}
+
+func (SameRegistrableDomain) InScope(seed, link *url.URL) bool {
+	return registrableDomain(link.Hostname()) == registrableDomain(seed.Hostname())
+}
+
+// registrableDomain returns the public suffix of a hostname plus one label, or the hostname itself if it has none,
+// e.g. an IP address or "localhost"
+func registrableDomain(hostname string) string {
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(hostname); err == nil {
+		return domain
+	}
+	return hostname
+}
+
+// PathPrefix keeps a crawl to the hosts of its seeds, and beneath the directory of each seed's path, e.g. a seed of
+// "example.com/docs/intro" includes "example.com/docs/api" but not "example.com/blog"
+type PathPrefix struct{}
+
+func (PathPrefix) InScope(seed, link *url.URL) bool {
+	if link.Hostname() != seed.Hostname() {
+		return false
+	}
+	dir := seed.EscapedPath()[:strings.LastIndex(seed.EscapedPath(), "/")+1]
+	return strings.HasPrefix(link.EscapedPath(), dir)
+}
+
+// Domains keeps a crawl to an explicit list of domains and their subdomains, regardless of its seeds, so that a site
+// spread across several domains can be crawled as one, e.g. Include of "example.com" and "example.shop" with Exclude of
+// "legacy.example.com"
+type Domains struct {
+	Include []string
+	Exclude []string // subdomains of included domains which are out of scope, along with their own subdomains
+}
+
+func (d Domains) InScope(seed, link *url.URL) bool {
+	return withinAny(link.Hostname(), d.Include) && !withinAny(link.Hostname(), d.Exclude)
+}
+
+// withinAny reports whether a hostname is any of the given domains or a subdomain of one
+func withinAny(hostname string, domains []string) bool {
+	for _, domain := range domains {
+		domain = asciiHost(strings.TrimPrefix(domain, "."))
+		if hostname == domain || strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithScopePolicy decides which links are in scope with p rather than SameHost
+func WithScopePolicy(p ScopePolicy) Option {
+	return func(c *crawler) {
+		c.scopePolicy = p
+	}
+}
+
+// WithOffsiteDepth follows links out of scope for up to n hops, e.g. 1 to record the status and title of each page
+// the site links to without crawling any further. Such pages have Page.OffsiteHops set.
+func WithOffsiteDepth(n int) Option {
+	return func(c *crawler) {
+		c.offsiteDepth = n
+	}
+}
+
+// scope returns a function reporting whether a link is in scope of any of the given seeds
+func (c *crawler) scope(seeds []*url.URL) func(*url.URL) bool {
+	policy := c.scopePolicy
+	if policy == nil {
+		policy = SameHost{}
+	}
+
+	scopeSeeds := []*url.URL{}
+	seen := map[string]struct{}{}
+	for _, seed := range seeds {
+		seed = c.canonicalURL(seed)
+		key := seed.Host + seed.EscapedPath()
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			scopeSeeds = append(scopeSeeds, seed)
+		}
+	}
+
+	return func(link *url.URL) bool {
+		link = c.canonicalURL(link)
+		for _, seed := range scopeSeeds {
+			if policy.InScope(seed, link) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopePolicies(t *testing.T) {
+	brand := Domains{Include: []string{"example.com", "example.shop", "example.net", "bücher.example"}, Exclude: []string{"legacy.example.com"}}
+
+	tests := []struct {
+		title    string
+		policy   ScopePolicy
+		seed     string
+		link     string
+		expected bool
+	}{
+		{"same host", SameHost{}, "http://www.example.com/", "http://www.example.com/a", true},
+		{"same host, other port", SameHost{}, "http://www.example.com/", "http://www.example.com:8080/a", true},
+		{"same host, subdomain", SameHost{}, "http://www.example.com/", "http://shop.example.com/", false},
+		{"registrable domain, subdomain", SameRegistrableDomain{}, "http://www.example.co.uk/", "http://shop.example.co.uk/", true},
+		{"registrable domain, apex", SameRegistrableDomain{}, "http://www.example.co.uk/", "http://example.co.uk/", true},
+		{"registrable domain, other domain", SameRegistrableDomain{}, "http://www.example.co.uk/", "http://other.co.uk/", false},
+		{"registrable domain, ip", SameRegistrableDomain{}, "http://127.0.0.1/", "http://127.0.0.1/a", true},
+		{"path prefix, beneath", PathPrefix{}, "http://example.com/docs/intro", "http://example.com/docs/api/v1", true},
+		{"path prefix, directory seed", PathPrefix{}, "http://example.com/docs/", "http://example.com/docs/api", true},
+		{"path prefix, outside", PathPrefix{}, "http://example.com/docs/intro", "http://example.com/blog", false},
+		{"path prefix, other host", PathPrefix{}, "http://example.com/docs/", "http://docs.example.com/docs/api", false},
+		{"domains, included", brand, "http://example.com/", "http://example.shop/a", true},
+		{"domains, subdomain", brand, "http://example.com/", "http://cdn.example.net/a", true},
+		{"domains, idn", brand, "http://example.com/", "http://xn--bcher-kva.example/", true},
+		{"domains, excluded", brand, "http://example.com/", "http://legacy.example.com/", false},
+		{"domains, excluded subdomain", brand, "http://example.com/", "http://a.legacy.example.com/", false},
+		{"domains, suffix of other domain", brand, "http://example.com/", "http://notexample.com/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			seed, err := url.Parse(tt.seed)
+			require.NoError(t, err)
+			link, err := url.Parse(tt.link)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, tt.policy.InScope(seed, link))
+		})
+	}
+}
+
+func TestScope(t *testing.T) {
+	parse := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u
+	}
+
+	c := New(1, nil, WithHostAliases("example.com", "www.example.com"), WithScopePolicy(PathPrefix{})).(*crawler)
+	inScope := c.scope([]*url.URL{parse("http://example.com/docs/?page=1"), parse("http://example.com/docs/?page=2"), parse("http://other.com/blog/")})
+
+	require.True(t, inScope(parse("http://WWW.example.com/docs/a")))
+	require.True(t, inScope(parse("http://other.com/blog/a")))
+	require.False(t, inScope(parse("http://other.com/docs/a")))
+	require.False(t, inScope(parse("http://example.com/blog/")))
+}
@@ -0,0 +1,94 @@
+package crawler
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// searchContext is the number of bytes of text either side of a match included in its context
+	searchContext = 40
+	// maxSearchMatches is the number of matches recorded per page
+	maxSearchMatches = 10
+)
+
+// SearchMatch is an occurrence of a search pattern in the text of a page
+type SearchMatch struct {
+	Pattern string // the pattern matched, as given to WithSearch
+	Context string // the match with the text surrounding it
+}
+
+// WithSearch searches the visible text of each page for the patterns, recording matches on Page.Matches. Use
+// regexp.QuoteMeta to search for a literal string.
+func WithSearch(patterns ...*regexp.Regexp) Option {
+	return func(c *crawler) {
+		c.searchPatterns = append(c.searchPatterns, patterns...)
+	}
+}
+
+// search returns up to maxSearchMatches matches of the patterns in the visible text of an HTML body
+func search(body []byte, patterns []*regexp.Regexp) []SearchMatch {
+	text := pageText(body)
+
+	matches := []SearchMatch{}
+	for _, pattern := range patterns {
+		for _, loc := range pattern.FindAllStringIndex(text, maxSearchMatches-len(matches)) {
+			matches = append(matches, SearchMatch{
+				Pattern: pattern.String(),
+				Context: matchContext(text, loc[0], loc[1]),
+			})
+		}
+		if len(matches) == maxSearchMatches {
+			break
+		}
+	}
+	return matches
+}
+
+// pageText returns the visible text of an HTML body, excluding scripts and styles, with whitespace collapsed
+func pageText(body []byte) string {
+	var text strings.Builder
+	inScript := false
+
+	t := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch t.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(text.String()), " ")
+		case html.TextToken:
+			if !inScript {
+				text.Write(t.Text())
+				text.WriteByte(' ')
+			}
+		case html.StartTagToken:
+			name, _ := t.TagName()
+			inScript = string(name) == "script" || string(name) == "style"
+		case html.EndTagToken:
+			inScript = false
+		}
+	}
+}
+
+// matchContext returns text[start:end] with up to searchContext bytes either side, extended to whole runes and
+// ellipsised where truncated
+func matchContext(text string, start, end int) string {
+	from, to := start-searchContext, end+searchContext
+	prefix, suffix := "…", "…"
+	if from <= 0 {
+		from, prefix = 0, ""
+	}
+	if to >= len(text) {
+		to, suffix = len(text), ""
+	}
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	return prefix + text[from:to] + suffix
+}
@@ -0,0 +1,36 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	body := []byte(`<html><head><title>Monzo</title><script>var product = "Mondo";</script></head><body>
+		<p>Mondo is now Monzo.</p><p>` + strings.Repeat("Filler text. ", 10) + `Ask about Mondo card.</p>
+	</body></html>`)
+
+	matches := search(body, []*regexp.Regexp{regexp.MustCompile(regexp.QuoteMeta("Mondo")), regexp.MustCompile(`[Cc]ards?\b`)})
+	require.Equal(t, []SearchMatch{
+		{Pattern: "Mondo", Context: "Monzo Mondo is now Monzo. Filler text. Filler text.…"},
+		{Pattern: "Mondo", Context: "…xt. Filler text. Filler text. Ask about Mondo card."},
+		{Pattern: `[Cc]ards?\b`, Context: "…ller text. Filler text. Ask about Mondo card."},
+	}, matches)
+
+	require.Empty(t, search(body, []*regexp.Regexp{regexp.MustCompile("product")}))
+}
+
+func TestSearchMaxMatches(t *testing.T) {
+	body := []byte(strings.Repeat("<p>match</p>", maxSearchMatches+5))
+	require.Len(t, search(body, []*regexp.Regexp{regexp.MustCompile("match"), regexp.MustCompile("atch")}), maxSearchMatches)
+}
+
+func TestMatchContext(t *testing.T) {
+	text := strings.Repeat("é", 30) + "needle" + strings.Repeat("ü", 30)
+	i := strings.Index(text, "needle")
+	context := matchContext(text, i, i+len("needle"))
+	require.Equal(t, "…"+strings.Repeat("é", 20)+"needle"+strings.Repeat("ü", 20)+"…", context)
+}
@@ -0,0 +1,98 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxSeedExpansion limits the number of seeds a single template can produce
+const maxSeedExpansion = 100000
+
+var ErrSeedTemplate = errors.New("invalid seed template")
+
+var (
+	seedTemplatePattern = regexp.MustCompile(`\{[^{}]*\}`)
+	seedRangePattern    = regexp.MustCompile(`^(-?\d+)\.\.(-?\d+)(?:\.\.(\d+))?$`)
+)
+
+// ExpandSeedTemplate generates seed URLs from a template containing zero or more brace expressions, each of which is
+// either a numeric range, e.g. "/products?page={1..500}", a stepped range, e.g. "{0..100..10}", or a list, e.g.
+// "/{en,fr,de}/". Zero padded range bounds, e.g. "{01..10}", produce zero padded values. Templates with several
+// expressions expand to every combination of their values.
+func ExpandSeedTemplate(tmpl string) ([]string, error) {
+	loc := seedTemplatePattern.FindStringIndex(tmpl)
+	if loc == nil {
+		return []string{tmpl}, nil
+	}
+
+	values, err := seedTemplateValues(tmpl[loc[0]+1 : loc[1]-1])
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q", tmpl[loc[0]:loc[1]])
+	}
+
+	suffixes, err := ExpandSeedTemplate(tmpl[loc[1]:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values)*len(suffixes) > maxSeedExpansion {
+		return nil, errors.Wrapf(ErrSeedTemplate, "%q expands to more than %d seeds", tmpl, maxSeedExpansion)
+	}
+
+	seeds := make([]string, 0, len(values)*len(suffixes))
+	for _, value := range values {
+		for _, suffix := range suffixes {
+			seeds = append(seeds, tmpl[:loc[0]]+value+suffix)
+		}
+	}
+	return seeds, nil
+}
+
+// seedTemplateValues returns the values of a single brace expression, excluding the braces
+func seedTemplateValues(expr string) ([]string, error) {
+	m := seedRangePattern.FindStringSubmatch(expr)
+	if m == nil {
+		if !strings.Contains(expr, ",") {
+			return nil, errors.Wrap(ErrSeedTemplate, "expected a range or a comma separated list")
+		}
+		return strings.Split(expr, ","), nil
+	}
+
+	start, _ := strconv.Atoi(m[1])
+	end, _ := strconv.Atoi(m[2])
+	step := 1
+	if m[3] != "" {
+		step, _ = strconv.Atoi(m[3])
+	}
+	if step == 0 {
+		return nil, errors.Wrap(ErrSeedTemplate, "range step must be greater than zero")
+	}
+	span := end - start
+	if span < 0 {
+		span = -span
+	}
+	if span/step >= maxSeedExpansion {
+		return nil, errors.Wrapf(ErrSeedTemplate, "range expands to more than %d values", maxSeedExpansion)
+	}
+
+	format := "%d"
+	if width := len(strings.TrimPrefix(m[1], "-")); width > 1 && strings.HasPrefix(strings.TrimPrefix(m[1], "-"), "0") {
+		format = "%0" + strconv.Itoa(width) + "d"
+	}
+
+	values := []string{}
+	if start <= end {
+		for i := start; i <= end; i += step {
+			values = append(values, fmt.Sprintf(format, i))
+		}
+	} else {
+		for i := start; i >= end; i -= step {
+			values = append(values, fmt.Sprintf(format, i))
+		}
+	}
+	return values, nil
+}
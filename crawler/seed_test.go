@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandSeedTemplate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		tests := []struct {
+			title, tmpl string
+			expected    []string
+		}{
+			{
+				"no template",
+				"http://www.google.com/products",
+				[]string{"http://www.google.com/products"},
+			},
+			{
+				"range",
+				"http://www.google.com/products?page={1..3}",
+				[]string{
+					"http://www.google.com/products?page=1",
+					"http://www.google.com/products?page=2",
+					"http://www.google.com/products?page=3",
+				},
+			},
+			{
+				"descending stepped range",
+				"http://www.google.com/{20..0..10}",
+				[]string{"http://www.google.com/20", "http://www.google.com/10", "http://www.google.com/0"},
+			},
+			{
+				"zero padded range",
+				"http://www.google.com/{08..10}",
+				[]string{"http://www.google.com/08", "http://www.google.com/09", "http://www.google.com/10"},
+			},
+			{
+				"list",
+				"http://{www,docs}.google.com",
+				[]string{"http://www.google.com", "http://docs.google.com"},
+			},
+			{
+				"combination",
+				"http://www.google.com/{en,fr}?page={1..2}",
+				[]string{
+					"http://www.google.com/en?page=1",
+					"http://www.google.com/en?page=2",
+					"http://www.google.com/fr?page=1",
+					"http://www.google.com/fr?page=2",
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				seeds, err := ExpandSeedTemplate(tt.tmpl)
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, seeds)
+			})
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := []struct {
+			title, tmpl string
+		}{
+			{"single value", "http://www.google.com/{1}"},
+			{"zero step", "http://www.google.com/{1..10..0}"},
+			{"too many seeds", "http://www.google.com/{1..1000}/{1..1000}"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.title, func(t *testing.T) {
+				_, err := ExpandSeedTemplate(tt.tmpl)
+				require.Equal(t, ErrSeedTemplate, errors.Cause(err))
+			})
+		}
+	})
+}
@@ -0,0 +1,389 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// session holds the state of a single crawl, so that a crawler can run any number of crawls, one after another or at
+// once. It's only used by the goroutine running the crawl, other than newURLs and frontiers, which the workers share.
+type session struct {
+	*crawler
+	ctx          context.Context
+	events       *eventBus
+	summary      *Summary
+	inScope      func(*url.URL) bool
+	outstanding  int                 // the number of URLs enqueued which haven't been handled yet
+	newURLs      []chan *url.URL     // one per worker if sharding by host, closed once every URL enqueued has been handled
+	cache        map[string]*url.URL // maps each discovered url to its first referrer
+	offsiteHops  map[string]int      // maps each out of scope url crawled to its distance from the site
+	enqueued     int
+	traps        *trapDetector
+	patternSpend map[string]int
+	limit        SkipReason               // the first budget which left a link uncrawled, see CrawlResult
+	mirror       *mirror                  // nil unless mirroring, see WithMirror
+	anchors      map[string]*pageAnchors  // the anchors of each page crawled, by cache key, if validating fragments
+	fragmentRefs map[string][]fragmentRef // the links with fragments to each page not yet crawled, by cache key
+	frontiers    []*frontier              // one per queue, feeding it, if scoring URLs, see WithPriority
+	pending      []*frontierURL           // the URLs enqueued but not yet pushed to the frontiers
+	outscored    chan *url.URL            // the URLs left in the frontiers once the page budget is spent
+	depths       map[string]int           // the number of links followed from a seed to each URL, by cache key
+}
+
+func newSession(ctx context.Context, c *crawler, events *eventBus, summary *Summary, inScope func(*url.URL) bool) *session {
+	s := &session{
+		crawler:      c,
+		ctx:          ctx,
+		events:       events,
+		summary:      summary,
+		inScope:      inScope,
+		newURLs:      newQueues(c),
+		cache:        map[string]*url.URL{},
+		offsiteHops:  map[string]int{},
+		traps:        newTrapDetector(c.trapLimits),
+		patternSpend: map[string]int{},
+		anchors:      map[string]*pageAnchors{},
+		fragmentRefs: map[string][]fragmentRef{},
+	}
+	if c.scorer != nil {
+		budget := &pageBudget{max: c.maxPages}
+		for range s.newURLs {
+			s.frontiers = append(s.frontiers, newFrontier(budget))
+		}
+		s.outscored = make(chan *url.URL)
+		s.depths = map[string]int{}
+	}
+	return s
+}
+
+// newQueues returns the queues of URLs the workers of a crawl read from, one which they all share unless sharding by
+// host, see WithHostSharding
+func newQueues(c *crawler) []chan *url.URL {
+	n := 1
+	if c.hostSharding && c.workerCount > 1 {
+		n = c.workerCount
+	}
+	queues := make([]chan *url.URL, n)
+	for i := range queues {
+		queues[i] = make(chan *url.URL)
+	}
+	return queues
+}
+
+// queue returns the queue of the worker which will fetch u
+func (s *session) queue(u *url.URL) chan *url.URL {
+	return s.newURLs[hostShard(u, len(s.newURLs))]
+}
+
+// enqueueSeeds schedules the seeds for crawling, closing newURLs once they and every URL enqueued after them have been
+// handled
+func (s *session) enqueueSeeds(seedURLs []*url.URL) {
+	seeds := []*url.URL{}
+	for _, seedURL := range seedURLs {
+		normalized := s.normalize(seedURL)
+		if normalized == nil {
+			s.events.publish(URLSkipped{URL: seedURL, Reason: SkipNormalizer})
+			continue
+		}
+		if _, ok := s.cache[s.cacheKey(normalized)]; ok {
+			s.events.publish(URLSkipped{URL: normalized, Reason: SkipDuplicate})
+			continue
+		}
+		s.cache[s.cacheKey(normalized)] = nil
+		seeds = append(seeds, normalized)
+		s.events.publish(URLEnqueued{URL: normalized})
+	}
+	s.enqueued += len(seeds)
+
+	s.outstanding += len(seeds)
+	if s.frontiers != nil {
+		for _, seedURL := range seeds {
+			s.pending = append(s.pending, &frontierURL{url: seedURL, score: s.scorer(seedURL, 0, nil), ignoreBudget: true})
+		}
+		s.pushPending()
+		for i, frontier := range s.frontiers {
+			go frontier.feed(s.ctx, s.newURLs[i], s.outscored)
+		}
+	} else {
+		go func() {
+			for _, seedURL := range seeds {
+				select {
+				case s.queue(seedURL) <- seedURL:
+				case <-s.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	if s.outstanding == 0 {
+		s.closeQueues()
+	}
+}
+
+// handled records that an enqueued URL has been handled, closing the queues once every one has been
+func (s *session) handled() {
+	s.outstanding--
+	if s.outstanding == 0 {
+		s.closeQueues()
+	}
+}
+
+// closeQueues closes newURLs, or the frontiers feeding them, so the workers exit once they've nothing left to fetch
+func (s *session) closeQueues() {
+	if s.frontiers != nil {
+		for _, frontier := range s.frontiers {
+			frontier.close()
+		}
+		return
+	}
+	for _, queue := range s.newURLs {
+		close(queue)
+	}
+}
+
+// limited records that a budget left a link uncrawled, keeping the first one to do so
+func (s *session) limited(reason SkipReason) {
+	if s.limit == "" {
+		s.limit = reason
+	}
+}
+
+// enqueue schedules an in scope link found on a page for crawling if it hasn't been seen before and the page budget
+// allows, returning whether it was
+func (s *session) enqueue(link *url.URL, page *Page, ignoreBudget bool) bool {
+	referrer := page.URL
+	normalized := s.normalize(link)
+	if normalized == nil {
+		s.events.publish(URLSkipped{URL: link, Referrer: referrer, Reason: SkipNormalizer})
+		return false
+	}
+	link = normalized
+	hops := 0
+	if !s.inScope(link) {
+		if hops = s.offsiteHops[s.cacheKey(referrer)] + 1; hops > s.offsiteDepth {
+			s.events.publish(URLSkipped{URL: link, Referrer: referrer, Reason: SkipOutOfScope})
+			return false
+		}
+	}
+	if _, ok := s.cache[s.cacheKey(link)]; ok {
+		s.events.publish(URLSkipped{URL: link, Referrer: referrer, Reason: SkipDuplicate})
+		return false
+	}
+	if err := s.urlLimits.check(link); err != nil {
+		s.cache[s.cacheKey(link)] = referrer // skip it, and only report it, once
+		s.events.publish(URLSkipped{URL: link, Referrer: referrer, Reason: SkipURLLimit, Err: err})
+		s.summary.Skipped++
+		return false
+	}
+	if trap, newTrap, err := s.traps.check(link); err != nil {
+		s.cache[s.cacheKey(link)] = referrer
+		if newTrap {
+			s.events.publish(TrapDetected{Pattern: trap, Referrer: referrer, Err: err})
+			s.summary.Traps = append(s.summary.Traps, trap)
+		}
+		s.events.publish(URLSkipped{URL: link, Referrer: referrer, Reason: SkipCrawlTrap, Err: err})
+		s.summary.Skipped++
+		return false
+	}
+	// when scoring URLs, the page budget is spent as they're handed to the workers instead
+	if !ignoreBudget && s.maxPages > 0 && s.enqueued >= s.maxPages && s.frontiers == nil {
+		s.events.publish(URLSkipped{URL: link, Referrer: referrer, Reason: SkipMaxPages})
+		s.summary.Limited++
+		s.limited(SkipMaxPages)
+		return false
+	}
+	if !ignoreBudget && !s.spendPatternBudgets(link, s.patternSpend) {
+		s.events.publish(URLSkipped{URL: link, Referrer: referrer, Reason: SkipPatternBudget})
+		s.summary.Limited++
+		s.limited(SkipPatternBudget)
+		return false
+	}
+	s.cache[s.cacheKey(link)] = referrer
+	if hops > 0 {
+		s.offsiteHops[s.cacheKey(link)] = hops
+	}
+	s.enqueued++
+	s.events.publish(URLEnqueued{URL: link, Referrer: referrer})
+
+	s.outstanding++
+	if s.frontiers != nil {
+		depth := s.depths[s.cacheKey(referrer)] + 1
+		s.depths[s.cacheKey(link)] = depth
+		s.pending = append(s.pending, &frontierURL{url: link, score: s.scorer(link, depth, page), ignoreBudget: ignoreBudget})
+		return true
+	}
+	go func(newURL *url.URL) {
+		select {
+		case s.queue(newURL) <- newURL:
+		case <-s.ctx.Done():
+		}
+	}(link)
+	return true
+}
+
+// handlePage writes a crawled page to out and enqueues its links
+func (s *session) handlePage(page *Page, sink Sink) error {
+	defer s.handled()
+	defer s.pushPending()
+
+	page.Referrer = s.cache[s.cacheKey(page.URL)]
+	page.OffsiteHops = s.offsiteHops[s.cacheKey(page.URL)]
+	if page.filtered {
+		s.events.publish(URLSkipped{URL: page.URL, Referrer: page.Referrer, Reason: SkipResponseFilter})
+		s.summary.Skipped++
+		return nil
+	}
+	s.events.publish(PageParsed{Page: page})
+	for _, err := range page.malformedLinks {
+		s.events.publish(URLSkipped{Referrer: page.URL, Reason: SkipMalformed, Err: err})
+	}
+	if page.Soft404 != "" {
+		s.events.publish(ErrorOccurred{Err: &FetchError{URL: page.URL, Referrer: page.Referrer, StatusCode: page.StatusCode, Err: errors.Wrapf(ErrSoft404, "%s looks like an error page, %s", page.URL, page.Soft404)}})
+		s.summary.Errors++
+		s.summary.host(page.URL).Errors++ // its status is counted with the page's
+	}
+	if s.mirror != nil && page.OffsiteHops == 0 {
+		if err := s.mirror.save(page); err != nil {
+			return err
+		}
+		page.body = nil
+	}
+	if s.fragments {
+		s.checkFragments(page)
+	}
+	if !page.NoIndex || s.ignoreRobots {
+		if err := sink.Emit(page); err != nil {
+			return err
+		}
+		s.summary.addPage(page)
+	}
+
+	// like redirects, meta refreshes and alternate versions are followed from nofollow pages
+	if page.Refresh != nil && s.followMetaRefresh {
+		s.follow(page, page.Refresh, false)
+	}
+	if s.followAlternates {
+		if page.AMP != nil {
+			s.follow(page, page.AMP, false)
+		}
+		for _, alternate := range page.Alternates {
+			s.follow(page, alternate, false)
+		}
+	}
+
+	if page.NoFollow && !s.ignoreRobots {
+		for _, link := range page.Links {
+			s.events.publish(URLSkipped{URL: link, Referrer: page.URL, Reason: SkipNoFollow})
+		}
+		return nil
+	}
+	s.followLinks(page)
+	if s.followForms {
+		for _, form := range page.Forms {
+			if form.Method == "GET" {
+				s.follow(page, form.submission(), false)
+			}
+		}
+	}
+	for _, link := range []*url.URL{page.Next, page.Prev} {
+		if link != nil {
+			s.follow(page, link, s.paginationPriority)
+		}
+	}
+	return nil
+}
+
+// follow enqueues a link found on a page unless it's filtered out, returning whether it was enqueued
+func (s *session) follow(page *Page, link *url.URL, priority bool) bool {
+	if !s.followLink(page, link) {
+		s.events.publish(URLSkipped{URL: link, Referrer: page.URL, Reason: SkipLinkFilter})
+		return false
+	}
+	return s.enqueue(link, page, priority)
+}
+
+// followLinks follows a page's links, enqueueing at most as many as WithMaxLinksPerPage allows, highest scoring first
+// if scoring URLs. Links to URLs already seen are reported as duplicates whether or not the cap has been reached.
+func (s *session) followLinks(page *Page) {
+	links := page.Links
+	if s.maxLinks > 0 && s.scorer != nil {
+		links = append([]*url.URL{}, links...)
+		depth := s.depths[s.cacheKey(page.URL)] + 1
+		scores := make(map[*url.URL]int, len(links))
+		for _, link := range links {
+			scores[link] = s.scorer(link, depth, page)
+		}
+		sort.SliceStable(links, func(i, j int) bool {
+			return scores[links[i]] > scores[links[j]]
+		})
+	}
+
+	enqueued := 0
+	for _, link := range links {
+		if s.maxLinks > 0 && enqueued >= s.maxLinks && !s.seen(link) {
+			s.events.publish(URLSkipped{URL: link, Referrer: page.URL, Reason: SkipMaxLinks})
+			s.summary.Limited++
+			s.limited(SkipMaxLinks)
+			continue
+		}
+		if s.follow(page, link, false) {
+			enqueued++
+		}
+	}
+}
+
+// seen reports whether a link's URL has already been discovered
+func (s *session) seen(link *url.URL) bool {
+	normalized := s.normalize(link)
+	if normalized == nil {
+		return false
+	}
+	_, ok := s.cache[s.cacheKey(normalized)]
+	return ok
+}
+
+// pushPending pushes the URLs enqueued since it was last called to the frontiers of their queues, all of a page's
+// links at once so that they're ordered by score
+func (s *session) pushPending() {
+	if len(s.pending) == 0 {
+		return
+	}
+	batches := make([][]*frontierURL, len(s.frontiers))
+	for _, u := range s.pending {
+		i := hostShard(u.url, len(s.frontiers))
+		batches[i] = append(batches[i], u)
+	}
+	for i, batch := range batches {
+		if len(batch) > 0 {
+			s.frontiers[i].push(batch...)
+		}
+	}
+	s.pending = nil
+}
+
+// skipOutscored reports a URL left in a frontier once the page budget was spent
+func (s *session) skipOutscored(u *url.URL) {
+	s.events.publish(URLSkipped{URL: u, Referrer: s.cache[s.cacheKey(u)], Reason: SkipOutscored})
+	s.summary.Limited++
+	s.limited(SkipMaxPages)
+	s.handled()
+}
+
+// handleError reports a non-fatal error, returning fatal errors to end the crawl
+func (s *session) handleError(err error) error {
+	if fetchErr, ok := err.(*FetchError); ok {
+		fetchErr.Referrer = s.cache[s.cacheKey(fetchErr.URL)]
+	}
+
+	// HTTP error status codes, timeouts and redirect loops are reported, other errors are fatal
+	if errorClass(err) == ErrorClassOther {
+		return err
+	}
+	s.events.publish(ErrorOccurred{Err: err})
+	s.summary.addError(err)
+	s.handled()
+	return nil
+}
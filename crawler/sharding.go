@@ -0,0 +1,27 @@
+package crawler
+
+import (
+	"hash/fnv"
+	"net/url"
+	"strings"
+)
+
+// WithHostSharding assigns each host to one worker by a hash of its name, rather than handing each URL to whichever
+// worker is free, so that a host's requests are made one at a time over the same keep-alive connection and politeness
+// limits are only contended by its own worker. Crawls of fewer hosts than workers leave the rest idle, so it suits
+// crawls of many hosts, such as with an offsite depth or many seeds.
+func WithHostSharding() Option {
+	return func(c *crawler) {
+		c.hostSharding = true
+	}
+}
+
+// hostShard returns which of n workers fetches u's host, hosts differing only in case having the same worker
+func hostShard(u *url.URL, n int) int {
+	if n <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(u.Hostname())))
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// siteClient serves a page linking to /1, /2 and /3 for any URL of any host
+type siteClient struct{}
+
+func (siteClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`<a href="/1">1</a><a href="/2">2</a><a href="/3">3</a>`)),
+		Request:    req,
+	}, nil
+}
+
+func TestHostShard(t *testing.T) {
+	u := func(rawURL string) *url.URL {
+		parsed, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return parsed
+	}
+	require.Equal(t, 0, hostShard(u("http://monzo.com/"), 1))
+	require.Equal(t, hostShard(u("http://monzo.com/"), 8), hostShard(u("https://MONZO.com:8443/careers"), 8))
+
+	shards := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		shard := hostShard(u(fmt.Sprintf("http://site%d.test/", i)), 4)
+		require.True(t, shard >= 0 && shard < 4)
+		shards[shard] = true
+	}
+	require.Len(t, shards, 4, "hosts should be spread across every worker")
+}
+
+func TestWithHostSharding(t *testing.T) {
+	seeds := []string{}
+	for i := 0; i < 12; i++ {
+		seeds = append(seeds, fmt.Sprintf("http://site%d.test/", i))
+	}
+
+	var mu sync.Mutex
+	workers := map[string]map[int]bool{} // by host
+	c := New(4, siteClient{}, WithHostSharding(), WithLogger(newTestLogger(io.Discard)), WithSubscriber(func(e Event) {
+		if started, ok := e.(FetchStarted); ok {
+			mu.Lock()
+			defer mu.Unlock()
+			if workers[started.URL.Host] == nil {
+				workers[started.URL.Host] = map[int]bool{}
+			}
+			workers[started.URL.Host][started.Worker] = true
+		}
+	}))
+
+	result, err := c.CrawlWithResult(context.Background(), seeds, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, 48, result.Pages)
+	require.Len(t, workers, 12)
+	for host, hostWorkers := range workers {
+		require.Equal(t, map[int]bool{hostShard(&url.URL{Host: host}, 4): true}, hostWorkers, "every page of %s should be fetched by its worker", host)
+	}
+}
@@ -0,0 +1,56 @@
+package crawler
+
+import (
+	"context"
+	"io"
+)
+
+// Sink receives each page a crawl outputs, e.g. to store it in a database or publish it to a queue, see CrawlToSink
+type Sink interface {
+	// Emit is called with each page in turn, from the goroutine running the crawl, and mustn't modify it. An error
+	// aborts the crawl.
+	Emit(*Page) error
+	// Close is called once the crawl has finished, whether or not it failed, e.g. to flush buffered pages
+	Close() error
+}
+
+// writerSink is a Sink writing each page to an io.Writer
+type writerSink struct {
+	w      io.Writer
+	format func(*Page) ([]byte, error)
+}
+
+// WriterSink returns a Sink writing each page to w as format renders it, or with Page.Marshal if format is nil, as
+// Crawl and CrawlAll do. Closing the sink doesn't close w.
+func WriterSink(w io.Writer, format func(*Page) ([]byte, error)) Sink {
+	if format == nil {
+		format = func(p *Page) ([]byte, error) {
+			return p.Marshal(), nil
+		}
+	}
+	return &writerSink{w: w, format: format}
+}
+
+func (s *writerSink) Emit(p *Page) error {
+	formatted, err := s.format(p)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(formatted)
+	return err
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// CrawlToSink crawls as CrawlWithResult does, but emits each page to sink rather than writing it, closing sink once
+// the crawl has finished. WithPageFormat doesn't apply, as the sink receives the pages themselves.
+func (c *crawler) CrawlToSink(ctx context.Context, rawURLs []string, sink Sink) (*CrawlResult, error) {
+	result := &CrawlResult{}
+	err := c.crawl(ctx, rawURLs, sink, result)
+	if closeErr := sink.Close(); err == nil {
+		err = closeErr
+	}
+	return result, err
+}
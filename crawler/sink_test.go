@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink records the URLs of the pages emitted to it, failing with err once it has emitted failAfter pages
+type recordingSink struct {
+	urls      []string
+	closed    bool
+	failAfter int
+	err       error
+	closeErr  error
+}
+
+func (s *recordingSink) Emit(p *Page) error {
+	if s.err != nil && len(s.urls) == s.failAfter {
+		return s.err
+	}
+	s.urls = append(s.urls, p.URL.String())
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return s.closeErr
+}
+
+func TestCrawlToSink(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":  {Links: []string{"/a"}},
+		"/a": {},
+	})
+	defer srv.Close()
+	c := New(1, srv.Client(), WithLogger(newTestLogger(io.Discard)))
+
+	t.Run("emitted", func(t *testing.T) {
+		sink := &recordingSink{}
+		result, err := c.CrawlToSink(context.Background(), []string{srv.URL + "/"}, sink)
+		require.NoError(t, err)
+		require.Equal(t, []string{srv.URL + "/", srv.URL + "/a"}, sink.urls)
+		require.True(t, sink.closed)
+		require.Equal(t, 2, result.Pages)
+	})
+
+	t.Run("emit error", func(t *testing.T) {
+		sink := &recordingSink{failAfter: 1, err: errors.New("disk full")}
+		_, err := c.CrawlToSink(context.Background(), []string{srv.URL + "/"}, sink)
+		require.Equal(t, sink.err, err)
+		require.True(t, sink.closed)
+	})
+
+	t.Run("close error", func(t *testing.T) {
+		sink := &recordingSink{closeErr: errors.New("flush failed")}
+		_, err := c.CrawlToSink(context.Background(), []string{srv.URL + "/"}, sink)
+		require.Equal(t, sink.closeErr, err)
+	})
+}
+
+func TestWriterSink(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/"}, StatusCode: 200}
+
+	out := &bytes.Buffer{}
+	sink := WriterSink(out, nil)
+	require.NoError(t, sink.Emit(page))
+	require.NoError(t, sink.Close())
+	require.Equal(t, string(page.Marshal()), out.String())
+
+	out.Reset()
+	sink = WriterSink(out, func(p *Page) ([]byte, error) { return []byte(p.URL.String() + "\n"), nil })
+	require.NoError(t, sink.Emit(page))
+	require.Equal(t, "http://monzo.com/\n", out.String())
+}
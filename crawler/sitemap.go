@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// maxSitemapDepth is how many levels of sitemap indexes are followed, as an index may only list sitemaps but a broken
+// one could list itself
+const maxSitemapDepth = 3
+
+// WithSitemapComparison fetches the sitemaps of each seed's host, those its robots.txt lists or else its sitemap.xml,
+// following any sitemap indexes, and records the URLs they list in Report.Sitemap, so that they can be compared with
+// the pages reached by following links, see Report.Orphans and Report.MissingFromSitemap. It only has an effect with
+// WithReport.
+func WithSitemapComparison() Option {
+	return func(c *crawler) {
+		c.sitemaps = true
+	}
+}
+
+// WithSitemapSeeds fetches the sitemaps of each seed's host as WithSitemapComparison does, and adds the URLs they list
+// which are in scope to the seeds, so that pages no links lead to are crawled too
+func WithSitemapSeeds() Option {
+	return func(c *crawler) {
+		c.sitemapSeeds = true
+	}
+}
+
+// sitemapDocument is a sitemap, listing pages, or a sitemap index, listing other sitemaps
+type sitemapDocument struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+type sitemapEntry struct {
+	Loc string `xml:"loc"`
+}
+
+// fetchSitemaps returns the URLs listed by the sitemaps of the seeds' hosts, in the order they're listed. A sitemap
+// which can't be fetched or parsed is logged and skipped.
+func (c *crawler) fetchSitemaps(ctx context.Context, httpClient httpClient, seeds []*url.URL) []string {
+	urls := []string{}
+	seen := map[string]bool{}
+	var fetch func(u *url.URL, depth int)
+	fetch = func(u *url.URL, depth int) {
+		if seen[u.String()] || ctx.Err() != nil {
+			return
+		}
+		seen[u.String()] = true
+
+		doc, err := c.fetchSitemap(ctx, httpClient, u)
+		if err != nil {
+			c.logger.Warn("error fetching sitemap", "url", displayURL(u), "error", err.Error())
+			return
+		}
+		for _, entry := range doc.URLs {
+			if loc, err := u.Parse(entry.Loc); err == nil {
+				urls = append(urls, displayURL(loc))
+			}
+		}
+		if depth >= maxSitemapDepth {
+			return
+		}
+		for _, entry := range doc.Sitemaps {
+			if loc, err := u.Parse(entry.Loc); err == nil {
+				fetch(loc, depth+1)
+			}
+		}
+	}
+
+	origins := map[string]bool{}
+	for _, seed := range seeds {
+		origin := &url.URL{Scheme: seed.Scheme, Host: seed.Host, Path: "/"}
+		if (seed.Scheme != "http" && seed.Scheme != "https") || origins[origin.String()] {
+			continue
+		}
+		origins[origin.String()] = true
+		for _, sitemap := range c.sitemapLocations(ctx, httpClient, origin) {
+			fetch(sitemap, 0)
+		}
+	}
+	return urls
+}
+
+// sitemapLocations returns the sitemaps listed by the Sitemap lines of an origin's robots.txt, or its /sitemap.xml if
+// its robots.txt has none or can't be fetched
+func (c *crawler) sitemapLocations(ctx context.Context, httpClient httpClient, origin *url.URL) []*url.URL {
+	var sitemaps []*url.URL
+	robotsURL := origin.ResolveReference(&url.URL{Path: "/robots.txt"})
+	if body, status, err := c.fetchBody(ctx, httpClient, robotsURL); err == nil && status >= 200 && status < 300 {
+		// parsing only fails on reading, which it can't for a byte slice
+		robots, _ := ParseRobotsTxt(bytes.NewReader(body))
+		for _, rawURL := range robots.Sitemaps {
+			if u, err := robotsURL.Parse(rawURL); err == nil {
+				sitemaps = append(sitemaps, u)
+			}
+		}
+	}
+	if len(sitemaps) == 0 {
+		sitemaps = append(sitemaps, origin.ResolveReference(&url.URL{Path: "/sitemap.xml"}))
+	}
+	return sitemaps
+}
+
+// fetchSitemap requests and parses a sitemap, which may be gzipped
+func (c *crawler) fetchSitemap(ctx context.Context, httpClient httpClient, u *url.URL) (*sitemapDocument, error) {
+	body, status, err := c.fetchBody(ctx, httpClient, u)
+	if err != nil {
+		return nil, err
+	}
+	if status < 200 || status >= 300 {
+		return nil, errors.Wrapf(ErrHttpStatusCode, "%d", status)
+	}
+
+	r := io.Reader(bytes.NewReader(body))
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		if r, err = gzip.NewReader(r); err != nil {
+			return nil, err
+		}
+	}
+	doc := &sitemapDocument{}
+	if err := xml.NewDecoder(r).Decode(doc); err != nil {
+		return nil, errors.Wrap(err, "parsing sitemap")
+	}
+	return doc, nil
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemapComparison(t *testing.T) {
+	site := crawltest.Site{
+		"/":       {Title: "Home", Links: []string{"/about", "/old"}},
+		"/about":  {Title: "About"},
+		"/old":    {RedirectTo: "/new"},
+		"/new":    {Title: "New"},
+		"/orphan": {Title: "Orphan"},
+	}
+	srv := crawltest.NewServer(site)
+	defer srv.Close()
+
+	gzipped := &bytes.Buffer{}
+	w := gzip.NewWriter(gzipped)
+	_, err := io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+		<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<url><loc>`+srv.URLFor("/")+`</loc></url>
+			<url><loc>`+srv.URLFor("/new")+`</loc></url>
+			<url><loc>`+srv.URLFor("/orphan")+`</loc></url>
+		</urlset>`)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	site["/sitemap.xml"] = crawltest.Page{
+		Header: http.Header{"Content-Type": {"application/xml"}},
+		Body: `<?xml version="1.0" encoding="UTF-8"?>
+			<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<sitemap><loc>/pages.xml.gz</loc></sitemap>
+				<sitemap><loc>/sitemap.xml</loc></sitemap>
+				<sitemap><loc>/missing.xml</loc></sitemap>
+			</sitemapindex>`,
+	}
+	site["/pages.xml.gz"] = crawltest.Page{Header: http.Header{"Content-Type": {"application/gzip"}}, Body: gzipped.String()}
+
+	report := &Report{}
+	logs := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithReport(report), WithSitemapComparison(), WithLogger(newTestLogger(logs)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	require.Equal(t, []string{srv.URLFor("/"), srv.URLFor("/new"), srv.URLFor("/orphan")}, report.Sitemap)
+	require.Equal(t, []string{srv.URLFor("/orphan")}, report.Orphans())
+	missing := report.MissingFromSitemap()
+	require.Len(t, missing, 1)
+	require.Equal(t, srv.URLFor("/about"), missing[0].URL)
+	require.Equal(t, 1, srv.Requests("/sitemap.xml"))
+	require.Contains(t, logs.String(), "error fetching sitemap")
+
+	t.Run("no sitemap", func(t *testing.T) {
+		delete(site, "/sitemap.xml")
+		report := &Report{}
+		c := New(1, srv.Client(), WithReport(report), WithSitemapComparison(), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+		require.Empty(t, report.Orphans())
+		require.Empty(t, report.MissingFromSitemap())
+	})
+}
+
+func TestSitemapSeeds(t *testing.T) {
+	site := crawltest.Site{
+		"/":       {Title: "Home", Links: []string{"/about"}},
+		"/about":  {Title: "About"},
+		"/orphan": {Title: "Orphan"},
+	}
+	srv := crawltest.NewServer(site)
+	defer srv.Close()
+	site["/robots.txt"] = crawltest.Page{
+		Header: http.Header{"Content-Type": {"text/plain"}},
+		Body:   "User-agent: *\nDisallow:\n\nSitemap: /sitemaps/pages.xml\n",
+	}
+	site["/sitemaps/pages.xml"] = crawltest.Page{
+		Header: http.Header{"Content-Type": {"application/xml"}},
+		Body: `<?xml version="1.0" encoding="UTF-8"?>
+			<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+				<url><loc>` + srv.URLFor("/about") + `</loc></url>
+				<url><loc>` + srv.URLFor("/orphan") + `</loc></url>
+				<url><loc>https://example.com/elsewhere</loc></url>
+			</urlset>`,
+	}
+
+	report := &Report{}
+	c := New(1, srv.Client(), WithReport(report), WithSitemapSeeds(), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	crawled := []string{}
+	for _, page := range report.Pages {
+		crawled = append(crawled, page.URL)
+	}
+	require.ElementsMatch(t, []string{srv.URLFor("/"), srv.URLFor("/about"), srv.URLFor("/orphan")}, crawled)
+	require.Equal(t, 1, srv.Requests("/about"), "URLs both linked to and in the sitemap should be crawled once")
+	require.Equal(t, 0, srv.Requests("/sitemap.xml"), "sitemap.xml shouldn't be fetched when robots.txt lists sitemaps")
+	require.Empty(t, report.Sitemap, "the sitemap should only be recorded with WithSitemapComparison")
+}
@@ -0,0 +1,195 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var ErrSoft404 = errors.New("soft 404")
+
+// soft404Shingle is the number of words in each of the overlapping sequences of words compared to measure how similar
+// a page's text is to a site's 404 page
+const soft404Shingle = 3
+
+// Soft404Rules configures the heuristics used to detect soft 404s, pages which respond with a 2xx status code but look
+// like error pages. Zero values disable the corresponding heuristic.
+type Soft404Rules struct {
+	MaxBodySize   int64            // bodies of at most this many bytes are too small to be real pages
+	TitlePatterns []*regexp.Regexp // matched against each page's title
+	TextPatterns  []*regexp.Regexp // matched against each page's visible text, so should only match error messages
+	// MinSimilarity is the similarity, from 0 to 1, of a page's text to the response to a random URL of the same host at
+	// which the page is a soft 404. The random URL is only requested if MinSimilarity is set, once per host.
+	MinSimilarity float64
+}
+
+// DefaultSoft404Rules are rules which only flag pages that are very likely to be error pages
+var DefaultSoft404Rules = Soft404Rules{
+	MaxBodySize: 64,
+	TitlePatterns: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(404|not found|page (does not|doesn't) exist)\b`),
+	},
+	TextPatterns: []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(page|file) (you('re| are) looking for |you requested )?(was not|wasn't|could not be|couldn't be|cannot be|can't be|not) found\b`),
+	},
+	MinSimilarity: 0.9,
+}
+
+// WithSoft404Detection flags pages which respond with a 2xx status code but look like error pages, setting Page.Soft404
+// and reporting them as errors of class ErrorClassSoft404, so that they're counted as broken links
+func WithSoft404Detection(rules Soft404Rules) Option {
+	return func(c *crawler) {
+		c.soft404Rules = &rules
+	}
+}
+
+// soft404Fetch requests a URL, following any redirects, returning its body, status code and the URL finally responding
+type soft404Fetch func(context.Context, *url.URL) (body []byte, status int, final *url.URL, err error)
+
+// soft404Detector holds the state of soft 404 detection for a single crawl, which is shared by its workers
+type soft404Detector struct {
+	rules Soft404Rules
+	fetch soft404Fetch
+
+	mu     sync.Mutex
+	probes map[string]*soft404Probe // by scheme and host
+}
+
+// soft404Probe is the response of a host to a URL which shouldn't exist
+type soft404Probe struct {
+	once sync.Once
+	url  *url.URL
+	// nil unless the host responded with a 2xx status code without redirecting, e.g. to its home page, which would make
+	// it and pages like it look like soft 404s
+	shingles map[string]struct{}
+}
+
+func newSoft404Detector(rules Soft404Rules, fetch soft404Fetch) *soft404Detector {
+	return &soft404Detector{
+		rules:  rules,
+		fetch:  fetch,
+		probes: map[string]*soft404Probe{},
+	}
+}
+
+// detect returns why a page looks like an error page, or an empty string if it doesn't. Only HTML pages are checked,
+// as small images, stylesheets and 204 No Content responses are expected to have little or no body.
+func (d *soft404Detector) detect(ctx context.Context, page *Page, body []byte) string {
+	if page.StatusCode < 200 || page.StatusCode >= 300 || page.StatusCode == http.StatusNoContent {
+		return ""
+	}
+	if typ := mediaType(page.contentType); typ != "text/html" && typ != "application/xhtml+xml" {
+		return ""
+	}
+	if d.rules.MaxBodySize > 0 && page.ContentLength <= d.rules.MaxBodySize {
+		return fmt.Sprintf("body of %d bytes", page.ContentLength)
+	}
+	for _, pattern := range d.rules.TitlePatterns {
+		if pattern.MatchString(page.Title) {
+			return fmt.Sprintf("title %q looks like an error", page.Title)
+		}
+	}
+
+	text := pageText(body)
+	for _, pattern := range d.rules.TextPatterns {
+		if match := pattern.FindString(text); match != "" {
+			return fmt.Sprintf("text %q looks like an error", match)
+		}
+	}
+	if d.rules.MinSimilarity > 0 {
+		probe := d.probe(ctx, page.URL)
+		if probe.shingles != nil && similarity(shingles(text), probe.shingles) >= d.rules.MinSimilarity {
+			return "similar to the response to " + displayURL(probe.url)
+		}
+	}
+	return ""
+}
+
+// probe returns the response of a page's host to a random URL, requesting it the first time the host is probed
+func (d *soft404Detector) probe(ctx context.Context, u *url.URL) *soft404Probe {
+	key := u.Scheme + "://" + u.Host
+	d.mu.Lock()
+	probe, ok := d.probes[key]
+	if !ok {
+		probe = &soft404Probe{}
+		d.probes[key] = probe
+	}
+	d.mu.Unlock()
+
+	probe.once.Do(func() {
+		nonce := make([]byte, 16)
+		rand.Read(nonce)
+		probe.url = &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/" + hex.EncodeToString(nonce)}
+		body, status, final, err := d.fetch(ctx, probe.url)
+		if err == nil && status >= 200 && status < 300 && final.String() == probe.url.String() {
+			probe.shingles = shingles(pageText(body))
+		}
+	})
+	return probe
+}
+
+// shingles returns the set of overlapping sequences of soft404Shingle words in text
+func shingles(text string) map[string]struct{} {
+	words := strings.Fields(strings.ToLower(text))
+	set := map[string]struct{}{}
+	if len(words) < soft404Shingle {
+		set[strings.Join(words, " ")] = struct{}{}
+		return set
+	}
+	for i := 0; i+soft404Shingle <= len(words); i++ {
+		set[strings.Join(words[i:i+soft404Shingle], " ")] = struct{}{}
+	}
+	return set
+}
+
+// similarity returns the Jaccard similarity of two sets of shingles, from 0 for disjoint sets to 1 for equal ones
+func similarity(a, b map[string]struct{}) float64 {
+	shared := 0
+	for shingle := range a {
+		if _, ok := b[shingle]; ok {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 1
+	}
+	return float64(shared) / float64(union)
+}
+
+// fetchBody requests a URL, returning up to maxBodySize bytes of its body and its status code
+func (c *crawler) fetchBody(ctx context.Context, httpClient httpClient, u *url.URL) ([]byte, int, error) {
+	body, status, _, err := c.fetchFinal(ctx, httpClient, u)
+	return body, status, err
+}
+
+// fetchFinal is fetchBody, also returning the URL which finally responded after following any redirects
+func (c *crawler) fetchFinal(ctx context.Context, httpClient httpClient, u *url.URL) ([]byte, int, *url.URL, error) {
+	resp, err := c.fetch(ctx, httpClient, u)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer resp.Body.Close()
+	final := u
+	if resp.Request != nil && resp.Request.URL != nil {
+		final = resp.Request.URL
+	}
+
+	r := io.Reader(resp.Body)
+	if c.maxBodySize > 0 {
+		r = io.LimitReader(r, c.maxBodySize)
+	}
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(r)
+	return buf.Bytes(), resp.StatusCode, final, err
+}
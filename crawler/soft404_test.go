@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoft404Detection(t *testing.T) {
+	notFound := `<html><head><title>Acme</title></head><body><nav>Home About Blog Contact</nav>` +
+		`<p>Sorry, we looked everywhere for it but there is nothing here any more. Try searching instead.</p></body></html>`
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":      {Title: "Home", Links: []string{"/tiny", "/title", "/text", "/gone"}},
+		"/tiny":  {Body: "<p>?</p>"},
+		"/title": {Title: "Page Not Found", Links: []string{"/real"}},
+		"/text": {Body: `<html><head><title>Acme</title></head><body><p>The page you're looking for could not be found, ` +
+			`it may have been moved or deleted.</p></body></html>`},
+		"/real": {Body: `<html><head><title>Acme</title></head><body><nav>Home About Blog Contact</nav>` +
+			`<p>We make anvils, rockets and other fine products for the discerning coyote.</p></body></html>`},
+		"*": {Body: notFound},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	var out bytes.Buffer
+	c := New(2, srv.Client(), WithSoft404Detection(DefaultSoft404Rules), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URLFor("/"), &out))
+
+	soft404s := map[string]string{}
+	for _, record := range report.BrokenLinks() {
+		require.Equal(t, ErrorClassSoft404, record.Class)
+		require.Equal(t, 200, record.StatusCode)
+		require.Equal(t, srv.URLFor("/"), record.Referrer)
+		soft404s[strings.TrimPrefix(record.URL, srv.URL)] = record.Error
+	}
+	require.Len(t, soft404s, 4)
+	require.Equal(t, srv.URLFor("/tiny")+" looks like an error page, body of 8 bytes: soft 404", soft404s["/tiny"])
+	require.Contains(t, soft404s["/title"], `title "Page Not Found"`)
+	require.Contains(t, soft404s["/text"], `text "page you're looking for could not be found"`)
+	require.Contains(t, soft404s["/gone"], "similar to the response to "+srv.URL+"/")
+	require.Equal(t, 4, report.Summary.Errors)
+
+	// soft 404s are still written out, and their links followed
+	require.Contains(t, out.String(), "Soft404:\n\tbody of 8 bytes\n")
+	require.Equal(t, 1, srv.Requests("/real"))
+
+	t.Run("probe not found", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/": {Body: notFound},
+		})
+		defer srv.Close()
+
+		report := &Report{}
+		c := New(1, srv.Client(), WithSoft404Detection(DefaultSoft404Rules), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URLFor("/"), &bytes.Buffer{}))
+		require.Empty(t, report.Errors)
+	})
+
+	t.Run("probe redirected", func(t *testing.T) {
+		home := `<html><head><title>Acme</title></head><body><p>We make anvils, rockets and other fine products.</p></body></html>`
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":      {Body: home, Links: []string{"/about"}},
+			"/about": {Body: home},
+			"*":      {RedirectTo: "/"},
+		})
+		defer srv.Close()
+
+		report := &Report{}
+		c := New(1, srv.Client(), WithSoft404Detection(DefaultSoft404Rules), WithReport(report), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URLFor("/"), &bytes.Buffer{}))
+		require.Empty(t, report.Errors, "a probe redirected to the home page isn't a soft 404 template")
+	})
+
+	t.Run("politeness", func(t *testing.T) {
+		const delay = 50 * time.Millisecond
+		var mu sync.Mutex
+		var starts []time.Time
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			starts = append(starts, time.Now())
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><title>Acme</title></head><body><p>Anvils, rockets and fine products.</p></body></html>`)
+		}))
+		defer srv.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		c := New(1, srv.Client(), WithSoft404Detection(DefaultSoft404Rules), WithPoliteness(Politeness{Delay: delay, MaxConcurrent: 1}), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.CrawlAll(ctx, []string{srv.URL + "/"}, io.Discard), "the probe waits for the page's request to the host to be over")
+
+		require.Len(t, starts, 2, "the page and the probe")
+		require.True(t, starts[1].Sub(starts[0]) >= delay, "the probe waits for the host's delay, got %s", starts[1].Sub(starts[0]))
+	})
+
+	t.Run("not HTML", func(t *testing.T) {
+		d := newSoft404Detector(DefaultSoft404Rules, nil)
+		for _, page := range []*Page{
+			{StatusCode: 200, ContentLength: 43, contentType: "image/gif"},
+			{StatusCode: 200, ContentLength: 12, contentType: "text/css"},
+			{StatusCode: 200, ContentLength: 2, contentType: "application/json"},
+			{StatusCode: 204, contentType: "text/html"},
+		} {
+			page.URL = &url.URL{Scheme: "http", Host: "monzo.com", Path: "/pixel"}
+			require.Empty(t, d.detect(context.Background(), page, nil), page.contentType)
+		}
+	})
+
+	t.Run("disabled heuristics", func(t *testing.T) {
+		d := newSoft404Detector(Soft404Rules{TitlePatterns: []*regexp.Regexp{regexp.MustCompile("Oops")}}, nil)
+		page := &Page{URL: &url.URL{Scheme: "http", Host: "monzo.com"}, StatusCode: 200, Title: "Not Found", contentType: "text/html"}
+		require.Empty(t, d.detect(context.Background(), page, nil))
+		page.Title = "Oops"
+		require.Equal(t, `title "Oops" looks like an error`, d.detect(context.Background(), page, nil))
+		page.StatusCode = 301
+		require.Empty(t, d.detect(context.Background(), page, nil))
+	})
+}
+
+func TestSimilarity(t *testing.T) {
+	a := shingles("the quick brown fox jumps")
+	require.Len(t, a, 3)
+	require.Equal(t, 1.0, similarity(a, shingles("The  quick brown FOX jumps")))
+	require.Equal(t, 2.0/3, similarity(a, shingles("the quick brown fox")))
+	require.Equal(t, 0.0, similarity(a, shingles("a lazy dog")))
+	require.Equal(t, 1.0, similarity(shingles(""), shingles("")))
+}
@@ -0,0 +1,162 @@
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+	"golang.org/x/net/html"
+)
+
+// The subresource integrity statuses of a script or stylesheet
+const (
+	SRIMissing    = "missing"    // it has no integrity attribute
+	SRIPresent    = "present"    // it has an integrity attribute, which wasn't verified
+	SRIValid      = "valid"      // its integrity attribute matches the resource
+	SRIMismatch   = "mismatch"   // its integrity attribute doesn't match the resource, so browsers refuse to load it
+	SRIUnverified = "unverified" // it couldn't be fetched, or its integrity attribute has no hash browsers support
+)
+
+// sriAlgorithms are the hash algorithms browsers support in integrity attributes, weakest first, as only the hashes of
+// the strongest algorithm given are used
+var sriAlgorithms = []string{"sha256", "sha384", "sha512"}
+
+// Subresource is a script or stylesheet a page loads from another origin
+type Subresource struct {
+	Element   string // "script" or "link"
+	URL       *url.URL
+	Integrity string // the integrity attribute, empty if it's missing
+	Status    string // SRIMissing, SRIPresent, SRIValid, SRIMismatch or SRIUnverified
+}
+
+// WithSubresourceIntegrity records the scripts and stylesheets each page loads from other origins on Page.Subresources,
+// with whether they have an integrity attribute. With verify, each of them is fetched, once per crawl, to check that
+// its integrity attribute matches it.
+func WithSubresourceIntegrity(verify bool) Option {
+	return func(c *crawler) {
+		c.sriCheck = true
+		c.sriVerify = verify
+	}
+}
+
+// collectSubresource records a script or stylesheet of a page if it's loaded from another origin, with its URL resolved
+// by links against the page's base href
+func collectSubresource(page *Page, links *linkextract.Extractor, tag html.Token) {
+	var raw string
+	switch tag.Data {
+	case "script":
+		raw = attrVal(tag, "src")
+	case "link":
+		for _, rel := range strings.Fields(strings.ToLower(attrVal(tag, "rel"))) {
+			if rel == "stylesheet" || rel == "modulepreload" {
+				raw = attrVal(tag, "href")
+			}
+		}
+	}
+	if raw == "" {
+		return
+	}
+	base := page.base()
+	u, err := links.Resolve(raw)
+	if err != nil || u == nil || (u.Scheme == base.Scheme && strings.EqualFold(u.Host, base.Host)) {
+		return
+	}
+	sub := Subresource{Element: tag.Data, URL: u, Integrity: strings.TrimSpace(attrVal(tag, "integrity")), Status: SRIPresent}
+	if sub.Integrity == "" {
+		sub.Status = SRIMissing
+	}
+	page.subresources = append(page.subresources, sub)
+}
+
+// sriVerifier checks the integrity attributes of subresources against the resources, for a single crawl, which is
+// shared by its workers
+type sriVerifier struct {
+	fetch func(context.Context, *url.URL) ([]byte, int, error)
+
+	mu        sync.Mutex
+	resources map[string]*sriResource // by URL
+}
+
+// sriResource holds the digests of a resource, fetched the first time a page loads it
+type sriResource struct {
+	once    sync.Once
+	digests map[string]string // base64 encoded, by algorithm, nil if the resource couldn't be fetched
+}
+
+func newSRIVerifier(fetch func(context.Context, *url.URL) ([]byte, int, error)) *sriVerifier {
+	return &sriVerifier{fetch: fetch, resources: map[string]*sriResource{}}
+}
+
+// verify sets the status of each subresource with an integrity attribute to whether it matches the resource
+func (v *sriVerifier) verify(ctx context.Context, subresources []Subresource) {
+	for i := range subresources {
+		sub := &subresources[i]
+		if sub.Integrity == "" {
+			continue
+		}
+		sub.Status = matchIntegrity(sub.Integrity, v.resource(ctx, sub.URL).digests)
+	}
+}
+
+// resource returns the digests of a resource, fetching it the first time it's verified
+func (v *sriVerifier) resource(ctx context.Context, u *url.URL) *sriResource {
+	v.mu.Lock()
+	resource, ok := v.resources[u.String()]
+	if !ok {
+		resource = &sriResource{}
+		v.resources[u.String()] = resource
+	}
+	v.mu.Unlock()
+
+	resource.once.Do(func() {
+		body, status, err := v.fetch(ctx, u)
+		if err != nil || status < 200 || status >= 300 {
+			return
+		}
+		sum256, sum384, sum512 := sha256.Sum256(body), sha512.Sum384(body), sha512.Sum512(body)
+		resource.digests = map[string]string{
+			"sha256": base64.StdEncoding.EncodeToString(sum256[:]),
+			"sha384": base64.StdEncoding.EncodeToString(sum384[:]),
+			"sha512": base64.StdEncoding.EncodeToString(sum512[:]),
+		}
+	})
+	return resource
+}
+
+// matchIntegrity returns whether any of the hashes of the strongest algorithm in an integrity attribute, e.g.
+// "sha384-oqVu... sha512-Q2j...", match a resource's digests, as browsers check them
+func matchIntegrity(integrity string, digests map[string]string) string {
+	hashes := map[string][]string{}
+	strongest := -1
+	for _, token := range strings.Fields(integrity) {
+		alg, hash, ok := strings.Cut(token, "-")
+		if !ok {
+			continue
+		}
+		hash, _, _ = strings.Cut(hash, "?") // options, which browsers ignore
+		for i, supported := range sriAlgorithms {
+			if alg == supported {
+				hashes[alg] = append(hashes[alg], hash)
+				if i > strongest {
+					strongest = i
+				}
+			}
+		}
+	}
+	if strongest < 0 || digests == nil {
+		return SRIUnverified
+	}
+
+	alg := sriAlgorithms[strongest]
+	for _, hash := range hashes[alg] {
+		if hash == digests[alg] {
+			return SRIValid
+		}
+	}
+	return SRIMismatch
+}
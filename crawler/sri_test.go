@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectSubresources(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/"}}
+	subs := parsed(page, []byte(`<html><head>
+		<script src="/local.js"></script>
+		<script src="https://cdn.example.com/app.js" integrity=" sha384-abc "></script>
+		<script>inline()</script>
+		<link rel="stylesheet" href="https://cdn.example.com/style.css">
+		<link rel="icon" href="https://cdn.example.com/favicon.ico">
+		<script src="http://monzo.com/insecure.js"></script>
+	</head></html>`)).subresources
+
+	require.Equal(t, []Subresource{
+		{Element: "script", URL: &url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/app.js"}, Integrity: "sha384-abc", Status: SRIPresent},
+		{Element: "link", URL: &url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/style.css"}, Status: SRIMissing},
+		{Element: "script", URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/insecure.js"}, Status: SRIMissing},
+	}, subs)
+}
+
+func TestMatchIntegrity(t *testing.T) {
+	sum384, sum512 := sha512.Sum384([]byte("body")), sha512.Sum512([]byte("body"))
+	digests := map[string]string{
+		"sha384": base64.StdEncoding.EncodeToString(sum384[:]),
+		"sha512": base64.StdEncoding.EncodeToString(sum512[:]),
+	}
+
+	tests := []struct {
+		title, integrity string
+		digests          map[string]string
+		expected         string
+	}{
+		{"match", "sha384-" + digests["sha384"], digests, SRIValid},
+		{"one of several", "sha384-wrong sha384-" + digests["sha384"] + "?opt", digests, SRIValid},
+		{"strongest only", "sha384-" + digests["sha384"] + " sha512-wrong", digests, SRIMismatch},
+		{"mismatch", "sha384-wrong", digests, SRIMismatch},
+		{"unsupported", "md5-abc", digests, SRIUnverified},
+		{"not fetched", "sha384-" + digests["sha384"], nil, SRIUnverified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			require.Equal(t, tt.expected, matchIntegrity(tt.integrity, tt.digests))
+		})
+	}
+}
+
+func TestSubresourceIntegrityVerification(t *testing.T) {
+	assets := crawltest.NewServer(crawltest.Site{
+		"/app.js":   {Body: "app()"},
+		"/other.js": {Body: "other()"},
+	})
+	defer assets.Close()
+	sum := sha512.Sum384([]byte("app()"))
+	integrity := "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+
+	srv := crawltest.NewServer(crawltest.Site{
+		"/": {Body: `<html><head>
+			<script src="` + assets.URLFor("/app.js") + `" integrity="` + integrity + `"></script>
+			<script src="` + assets.URLFor("/other.js") + `" integrity="sha384-wrong"></script>
+			<script src="` + assets.URLFor("/missing.js") + `" integrity="sha384-wrong"></script>
+		</head><body><a href="/about"></a></body></html>`},
+		"/about": {Body: `<html><head><script src="` + assets.URLFor("/app.js") + `" integrity="` + integrity + `"></script></head></html>`},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	c := New(2, srv.Client(), WithReport(report), WithSubresourceIntegrity(true), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	statuses := map[string]string{}
+	pages := map[string]int{}
+	for _, usage := range report.Subresources() {
+		statuses[usage.URL] = usage.Status
+		pages[usage.URL] = usage.Pages
+	}
+	require.Equal(t, map[string]string{
+		assets.URLFor("/app.js"):     SRIValid,
+		assets.URLFor("/other.js"):   SRIMismatch,
+		assets.URLFor("/missing.js"): SRIUnverified,
+	}, statuses)
+	require.Equal(t, 2, pages[assets.URLFor("/app.js")])
+	require.Equal(t, 1, assets.Requests("/app.js"))
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// StatsDConfig configures the metrics pushed by StatsDSubscriber
+type StatsDConfig struct {
+	Prefix string // prepended to each metric's name with a dot, e.g. "web_crawler" for "web_crawler.fetches"
+	// DogStatsD tags metrics with the host fetched, status code, skip reason or error class, in the DogStatsD format,
+	// which plain StatsD servers don't understand
+	DogStatsD bool
+	Tags      []string // tags added to every metric if DogStatsD is set, e.g. "env:staging"
+}
+
+// StatsDSubscriber returns a subscriber for WithSubscriber pushing counters and timings of a crawl to a StatsD server
+// over w, usually a UDP connection, with one packet per event:
+//
+//   - fetches, counting the responses received, or "error" for requests which failed, tagged with host and status
+//   - fetch_duration, timing each request in milliseconds, tagged with host
+//   - bytes, counting the body bytes read, tagged with host
+//   - pages, counting the pages parsed, tagged with host
+//   - enqueued, counting the URLs scheduled for crawling
+//   - skipped, counting the URLs which weren't, tagged with reason
+//   - errors, counting the non-fatal errors, tagged with class
+//
+// As StatsD is a best effort protocol, errors writing to w are ignored.
+func StatsDSubscriber(w io.Writer, config StatsDConfig) func(Event) {
+	prefix := ""
+	if config.Prefix != "" {
+		prefix = strings.TrimSuffix(config.Prefix, ".") + "."
+	}
+
+	return func(e Event) {
+		var lines []string
+		metric := func(name, value, kind string, tags ...string) {
+			line := prefix + name + ":" + value + "|" + kind
+			if config.DogStatsD {
+				if tags = append(tags, config.Tags...); len(tags) > 0 {
+					line += "|#" + strings.Join(tags, ",")
+				}
+			}
+			lines = append(lines, line)
+		}
+
+		switch e := e.(type) {
+		case FetchCompleted:
+			host := "host:" + statsDTag(unicodeHost(e.URL.Hostname()))
+			status := "error"
+			if e.Err == nil {
+				status = strconv.Itoa(e.StatusCode)
+			}
+			metric("fetches", "1", "c", host, "status:"+status)
+			metric("fetch_duration", strconv.FormatInt(e.Duration.Milliseconds(), 10), "ms", host)
+			if e.ContentLength > 0 {
+				metric("bytes", strconv.FormatInt(e.ContentLength, 10), "c", host)
+			}
+		case PageParsed:
+			metric("pages", "1", "c", "host:"+statsDTag(unicodeHost(e.Page.URL.Hostname())))
+		case URLEnqueued:
+			metric("enqueued", "1", "c")
+		case URLSkipped:
+			metric("skipped", "1", "c", "reason:"+statsDTag(string(e.Reason)))
+		case ErrorOccurred:
+			metric("errors", "1", "c", "class:"+errorClass(e.Err))
+		}
+		if len(lines) > 0 {
+			fmt.Fprint(w, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+// statsDTag replaces the characters of a tag value which DogStatsD reserves, or which read badly in dashboards, with
+// underscores, e.g. "max pages" becomes "max_pages"
+func statsDTag(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', ',', '|', ':', '#', '\n':
+			return '_'
+		}
+		return r
+	}, value)
+}
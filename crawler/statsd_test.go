@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// packetWriter records each write as a packet, as a UDP connection sends them
+type packetWriter struct {
+	packets []string
+}
+
+func (w *packetWriter) Write(p []byte) (int, error) {
+	w.packets = append(w.packets, string(p))
+	return len(p), nil
+}
+
+func TestStatsDSubscriber(t *testing.T) {
+	u := &url.URL{Scheme: "https", Host: "monzo.com", Path: "/"}
+
+	w := &packetWriter{}
+	publish := StatsDSubscriber(w, StatsDConfig{Prefix: "crawler.", DogStatsD: true, Tags: []string{"env:test"}})
+	publish(FetchCompleted{URL: u, StatusCode: 200, ContentLength: 512, Duration: 150 * time.Millisecond})
+	publish(FetchCompleted{URL: u, Duration: time.Second, Err: errors.New("connection refused")})
+	publish(URLSkipped{URL: u, Reason: SkipMaxPages})
+	publish(ErrorOccurred{Err: &FetchError{URL: u, StatusCode: 404, Err: ErrHttpStatusCode}})
+	publish(FetchStarted{URL: u})
+	require.Equal(t, []string{
+		"crawler.fetches:1|c|#host:monzo.com,status:200,env:test\ncrawler.fetch_duration:150|ms|#host:monzo.com,env:test\ncrawler.bytes:512|c|#host:monzo.com,env:test",
+		"crawler.fetches:1|c|#host:monzo.com,status:error,env:test\ncrawler.fetch_duration:1000|ms|#host:monzo.com,env:test",
+		"crawler.skipped:1|c|#reason:max_pages,env:test",
+		"crawler.errors:1|c|#class:http_status,env:test",
+	}, w.packets)
+
+	w = &packetWriter{}
+	StatsDSubscriber(w, StatsDConfig{Tags: []string{"env:test"}})(FetchCompleted{URL: u, StatusCode: 200, Duration: time.Millisecond})
+	require.Equal(t, []string{"fetches:1|c\nfetch_duration:1|ms"}, w.packets, "tags are only sent to DogStatsD")
+
+	t.Run("crawl", func(t *testing.T) {
+		srv := crawltest.NewServer(crawltest.Site{
+			"/":      {Links: []string{"/about", "/missing"}},
+			"/about": {},
+		})
+		defer srv.Close()
+
+		w := &packetWriter{}
+		c := New(1, srv.Client(), WithSubscriber(StatsDSubscriber(w, StatsDConfig{Prefix: "web_crawler"})), WithLogger(newTestLogger(io.Discard)))
+		require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+		counts := map[string]int{}
+		for _, packet := range w.packets {
+			for _, line := range strings.Split(packet, "\n") {
+				counts[line[:strings.Index(line, ":")]]++
+			}
+		}
+		require.Equal(t, map[string]int{
+			"web_crawler.enqueued":       3,
+			"web_crawler.fetches":        3,
+			"web_crawler.fetch_duration": 3,
+			"web_crawler.bytes":          2,
+			"web_crawler.pages":          2,
+			"web_crawler.errors":         1,
+		}, counts)
+	})
+}
@@ -0,0 +1,374 @@
+package crawler
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary holds statistics accumulated over a crawl
+type Summary struct {
+	Pages     int
+	Errors    int            // non-fatal errors, e.g. HTTP error status codes and timeouts
+	Skipped   int            // links not crawled for exceeding URL limits or being in a crawl trap, and filtered responses
+	Limited   int            // links which weren't crawled because the page budget, a pattern budget or a page's links cap was spent
+	Traps     []string       // the patterns of detected crawl traps
+	Languages map[string]int // the number of pages per detected language
+	Protocols map[string]int // the number of pages per protocol fetched over, recorded with WithHTTP3
+	Bytes     int64          // the body bytes read from every page
+	Hosts     map[string]*HostSummary
+	// ContentTypes holds the pages and body bytes of each media type, e.g. "text/html", or "unknown" for pages with no
+	// Content-Type
+	ContentTypes map[string]*ContentTypeSummary
+	Slowest      []PageStat // the slowest pages to fetch, slowest first, recorded with WithTopPages
+	Largest      []PageStat // the largest pages, largest first, recorded with WithTopPages
+
+	top int // the number of pages kept in Slowest and Largest
+}
+
+// CrawlResult describes how a single crawl went, see CrawlWithResult
+type CrawlResult struct {
+	Pages    int // the pages crawled successfully and written out
+	Errors   int // non-fatal errors, e.g. HTTP error status codes and timeouts
+	Duration time.Duration
+	// Limit is SkipMaxPages, SkipPatternBudget or SkipMaxLinks if a budget left links uncrawled, whichever did so
+	// first, or empty if the crawl was only limited by its scope
+	Limit   SkipReason
+	Summary Summary // the crawl's full statistics, as added to those given with WithSummary
+}
+
+// PageStat is the fetch duration and size of a page, one of the slowest or largest of a crawl
+type PageStat struct {
+	URL           string
+	StatusCode    int
+	FetchDuration time.Duration
+	ContentLength int64
+}
+
+// WithTopPages records the n slowest and n largest pages of each crawl in the Summary, by fetch duration and body size
+func WithTopPages(n int) Option {
+	return func(c *crawler) {
+		c.topPages = n
+	}
+}
+
+// HostSummary holds statistics accumulated over the pages and errors of a single host, including its port if any
+type HostSummary struct {
+	Pages         int
+	Errors        int
+	Bytes         int64         // the body bytes read from the host's pages
+	FetchDuration time.Duration // the total time taken to fetch the host's pages, see AverageLatency
+	Statuses      map[int]int   // the number of responses per status code, of both pages and errors
+}
+
+// ContentTypeSummary holds statistics accumulated over the pages of a single media type
+type ContentTypeSummary struct {
+	Pages int
+	Bytes int64
+}
+
+// mediaType returns the lower cased media type of a Content-Type header, without its parameters, or "unknown"
+func mediaType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	if mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0])); mediaType != "" {
+		return mediaType
+	}
+	return "unknown"
+}
+
+// AverageLatency returns the mean time taken to fetch each of the host's pages
+func (h *HostSummary) AverageLatency() time.Duration {
+	if h.Pages == 0 {
+		return 0
+	}
+	return h.FetchDuration / time.Duration(h.Pages)
+}
+
+// host returns the statistics of the host of u, creating them if need be
+func (s *Summary) host(u *url.URL) *HostSummary {
+	if s.Hosts == nil {
+		s.Hosts = map[string]*HostSummary{}
+	}
+	h, ok := s.Hosts[u.Host]
+	if !ok {
+		h = &HostSummary{Statuses: map[int]int{}}
+		s.Hosts[u.Host] = h
+	}
+	return h
+}
+
+// addError counts a non-fatal error, against its host if it's a FetchError
+func (s *Summary) addError(err error) {
+	s.Errors++
+	if fetchErr, ok := err.(*FetchError); ok && fetchErr.URL != nil {
+		h := s.host(fetchErr.URL)
+		h.Errors++
+		if fetchErr.StatusCode != 0 {
+			h.Statuses[fetchErr.StatusCode]++
+		}
+	}
+}
+
+func (s *Summary) addPage(p *Page) {
+	s.Pages++
+
+	if s.Languages == nil {
+		s.Languages = map[string]int{}
+	}
+	lang := p.Language
+	if lang == "" {
+		lang = "unknown"
+	}
+	s.Languages[lang]++
+
+	if p.Protocol != "" {
+		if s.Protocols == nil {
+			s.Protocols = map[string]int{}
+		}
+		s.Protocols[p.Protocol]++
+	}
+
+	if p.URL != nil {
+		h := s.host(p.URL)
+		h.Pages++
+		h.Bytes += p.ContentLength
+		h.FetchDuration += p.FetchDuration
+		h.Statuses[p.StatusCode]++
+
+		s.Bytes += p.ContentLength
+		if s.ContentTypes == nil {
+			s.ContentTypes = map[string]*ContentTypeSummary{}
+		}
+		typ, ok := s.ContentTypes[mediaType(p.contentType)]
+		if !ok {
+			typ = &ContentTypeSummary{}
+			s.ContentTypes[mediaType(p.contentType)] = typ
+		}
+		typ.Pages++
+		typ.Bytes += p.ContentLength
+	}
+
+	if s.top > 0 && p.URL != nil {
+		stat := PageStat{URL: displayURL(p.URL), StatusCode: p.StatusCode, FetchDuration: p.FetchDuration, ContentLength: p.ContentLength}
+		s.addTop(stat)
+	}
+}
+
+// addTop adds a page to the slowest and largest pages if it's among the top
+func (s *Summary) addTop(stat PageStat) {
+	s.Slowest = rankPage(s.Slowest, stat, s.top, func(a, b PageStat) bool {
+		return a.FetchDuration > b.FetchDuration
+	})
+	s.Largest = rankPage(s.Largest, stat, s.top, func(a, b PageStat) bool {
+		return a.ContentLength > b.ContentLength
+	})
+}
+
+// rankPage inserts stat in to pages, ordered by before, after any it ties with, keeping at most n of them
+func rankPage(pages []PageStat, stat PageStat, n int, before func(a, b PageStat) bool) []PageStat {
+	i := sort.Search(len(pages), func(i int) bool {
+		return before(stat, pages[i])
+	})
+	if i >= n {
+		return pages
+	}
+	pages = append(pages, PageStat{})
+	copy(pages[i+1:], pages[i:])
+	pages[i] = stat
+	if len(pages) > n {
+		pages = pages[:n]
+	}
+	return pages
+}
+
+// add adds the statistics of another crawl to s
+func (s *Summary) add(o *Summary) {
+	s.Pages += o.Pages
+	s.Errors += o.Errors
+	s.Skipped += o.Skipped
+	s.Limited += o.Limited
+	s.Bytes += o.Bytes
+	s.Traps = append(s.Traps, o.Traps...)
+	for lang, n := range o.Languages {
+		if s.Languages == nil {
+			s.Languages = map[string]int{}
+		}
+		s.Languages[lang] += n
+	}
+	for proto, n := range o.Protocols {
+		if s.Protocols == nil {
+			s.Protocols = map[string]int{}
+		}
+		s.Protocols[proto] += n
+	}
+	for host, o := range o.Hosts {
+		if s.Hosts == nil {
+			s.Hosts = map[string]*HostSummary{}
+		}
+		h, ok := s.Hosts[host]
+		if !ok {
+			h = &HostSummary{Statuses: map[int]int{}}
+			s.Hosts[host] = h
+		}
+		h.Pages += o.Pages
+		h.Errors += o.Errors
+		h.Bytes += o.Bytes
+		h.FetchDuration += o.FetchDuration
+		for status, n := range o.Statuses {
+			h.Statuses[status] += n
+		}
+	}
+	for name, o := range o.ContentTypes {
+		if s.ContentTypes == nil {
+			s.ContentTypes = map[string]*ContentTypeSummary{}
+		}
+		typ, ok := s.ContentTypes[name]
+		if !ok {
+			typ = &ContentTypeSummary{}
+			s.ContentTypes[name] = typ
+		}
+		typ.Pages += o.Pages
+		typ.Bytes += o.Bytes
+	}
+	if o.top > s.top {
+		s.top = o.top
+	}
+	for _, stat := range o.Slowest {
+		s.Slowest = rankPage(s.Slowest, stat, s.top, func(a, b PageStat) bool {
+			return a.FetchDuration > b.FetchDuration
+		})
+	}
+	for _, stat := range o.Largest {
+		s.Largest = rankPage(s.Largest, stat, s.top, func(a, b PageStat) bool {
+			return a.ContentLength > b.ContentLength
+		})
+	}
+}
+
+func (s *Summary) Marshal() []byte {
+	out := []byte(fmt.Sprintf("Pages:\n\t%d\nErrors:\n\t%d\nSkipped:\n\t%d\n", s.Pages, s.Errors, s.Skipped))
+	if s.Limited > 0 {
+		out = append(out, []byte(fmt.Sprintf("Limited:\n\t%d\n", s.Limited))...)
+	}
+
+	if len(s.Traps) > 0 {
+		out = append(out, []byte("Traps:\n")...)
+		for _, trap := range s.Traps {
+			out = append(out, []byte("\t"+trap+"\n")...)
+		}
+	}
+
+	if len(s.Languages) > 0 {
+		out = append(out, []byte("Languages:\n")...)
+		langs := make([]string, 0, len(s.Languages))
+		for lang := range s.Languages {
+			langs = append(langs, lang)
+		}
+		sort.Slice(langs, func(i, j int) bool {
+			if s.Languages[langs[i]] != s.Languages[langs[j]] {
+				return s.Languages[langs[i]] > s.Languages[langs[j]]
+			}
+			return langs[i] < langs[j]
+		})
+		for _, lang := range langs {
+			out = append(out, []byte(fmt.Sprintf("\t%s: %d\n", lang, s.Languages[lang]))...)
+		}
+	}
+
+	if len(s.Protocols) > 0 {
+		out = append(out, []byte("Protocols:\n")...)
+		protos := make([]string, 0, len(s.Protocols))
+		for proto := range s.Protocols {
+			protos = append(protos, proto)
+		}
+		sort.Strings(protos)
+		for _, proto := range protos {
+			out = append(out, []byte(fmt.Sprintf("\t%s: %d\n", proto, s.Protocols[proto]))...)
+		}
+	}
+
+	if s.Bytes > 0 {
+		out = append(out, []byte(fmt.Sprintf("Bytes:\n\t%d\n", s.Bytes))...)
+	}
+
+	if len(s.ContentTypes) > 0 {
+		out = append(out, []byte("Content types:\n")...)
+		for _, name := range s.SortedContentTypes() {
+			typ := s.ContentTypes[name]
+			out = append(out, []byte(fmt.Sprintf("\t%s: %d pages, %d bytes\n", name, typ.Pages, typ.Bytes))...)
+		}
+	}
+
+	if len(s.Hosts) > 0 {
+		out = append(out, []byte("Hosts:\n")...)
+		for _, host := range s.SortedHosts() {
+			h := s.Hosts[host]
+			out = append(out, []byte(fmt.Sprintf("\t%s: %d pages, %d errors, %d bytes, %s average latency, statuses %s\n", host, h.Pages, h.Errors, h.Bytes, h.AverageLatency().Round(time.Millisecond), h.statuses()))...)
+		}
+	}
+
+	if len(s.Slowest) > 0 {
+		out = append(out, []byte("Slowest pages:\n")...)
+		for _, stat := range s.Slowest {
+			out = append(out, []byte(fmt.Sprintf("\t%s: %s, %d bytes, status %d\n", stat.URL, stat.FetchDuration.Round(time.Millisecond), stat.ContentLength, stat.StatusCode))...)
+		}
+	}
+
+	if len(s.Largest) > 0 {
+		out = append(out, []byte("Largest pages:\n")...)
+		for _, stat := range s.Largest {
+			out = append(out, []byte(fmt.Sprintf("\t%s: %d bytes, %s, status %d\n", stat.URL, stat.ContentLength, stat.FetchDuration.Round(time.Millisecond), stat.StatusCode))...)
+		}
+	}
+
+	return out
+}
+
+// SortedHosts returns the hosts crawled, those with the most pages first
+func (s *Summary) SortedHosts() []string {
+	hosts := make([]string, 0, len(s.Hosts))
+	for host := range s.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if s.Hosts[hosts[i]].Pages != s.Hosts[hosts[j]].Pages {
+			return s.Hosts[hosts[i]].Pages > s.Hosts[hosts[j]].Pages
+		}
+		return hosts[i] < hosts[j]
+	})
+	return hosts
+}
+
+// SortedContentTypes returns the media types of the pages crawled, those with the most bytes first
+func (s *Summary) SortedContentTypes() []string {
+	names := make([]string, 0, len(s.ContentTypes))
+	for name := range s.ContentTypes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.ContentTypes[names[i]].Bytes != s.ContentTypes[names[j]].Bytes {
+			return s.ContentTypes[names[i]].Bytes > s.ContentTypes[names[j]].Bytes
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// statuses formats the host's distribution of status codes, e.g. "200=12 404=1"
+func (h *HostSummary) statuses() string {
+	codes := make([]int, 0, len(h.Statuses))
+	for code := range h.Statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		parts = append(parts, fmt.Sprintf("%d=%d", code, h.Statuses[code]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	s := &Summary{}
+	s.addPage(&Page{Language: "en"})
+	s.addPage(&Page{Language: "fr"})
+	s.addPage(&Page{Language: "en"})
+	s.addPage(&Page{})
+	s.Errors++
+	s.Skipped += 2
+
+	require.Equal(t, 4, s.Pages)
+	require.Equal(t, map[string]int{"en": 2, "fr": 1, "unknown": 1}, s.Languages)
+	require.Equal(t, "Pages:\n\t4\nErrors:\n\t1\nSkipped:\n\t2\nLanguages:\n\ten: 2\n\tfr: 1\n\tunknown: 1\n", string(s.Marshal()))
+
+	s.Limited = 3
+	require.Contains(t, string(s.Marshal()), "Skipped:\n\t2\nLimited:\n\t3\n")
+}
+
+func TestSummaryHosts(t *testing.T) {
+	page := func(rawURL string, status int, size int64, duration time.Duration) *Page {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return &Page{URL: u, StatusCode: status, ContentLength: size, FetchDuration: duration}
+	}
+	s := &Summary{}
+	s.addPage(page("http://monzo.com/", 200, 100, 100*time.Millisecond))
+	s.addPage(page("http://monzo.com/about", 200, 300, 300*time.Millisecond))
+	s.addPage(page("http://docs.monzo.com/", 200, 50, 50*time.Millisecond))
+	missing, err := url.Parse("http://docs.monzo.com/missing")
+	require.NoError(t, err)
+	s.addError(&FetchError{URL: missing, StatusCode: 404})
+	s.addError(&FetchError{URL: missing})
+
+	require.Equal(t, &HostSummary{Pages: 2, Bytes: 400, FetchDuration: 400 * time.Millisecond, Statuses: map[int]int{200: 2}}, s.Hosts["monzo.com"])
+	require.Equal(t, 200*time.Millisecond, s.Hosts["monzo.com"].AverageLatency())
+	require.Equal(t, 2, s.Hosts["docs.monzo.com"].Errors)
+	require.Equal(t, 2, s.Errors)
+	require.Contains(t, string(s.Marshal()), "Hosts:\n\tmonzo.com: 2 pages, 0 errors, 400 bytes, 200ms average latency, statuses 200=2\n\tdocs.monzo.com: 1 pages, 2 errors, 50 bytes, 50ms average latency, statuses 200=1 404=1\n")
+
+	total := &Summary{}
+	total.add(s)
+	total.add(s)
+	require.Equal(t, 4, total.Hosts["monzo.com"].Pages)
+	require.Equal(t, map[int]int{200: 2, 404: 2}, total.Hosts["docs.monzo.com"].Statuses)
+}
+
+func TestSummaryContentTypes(t *testing.T) {
+	page := func(rawURL, contentType string, size int64) *Page {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return &Page{URL: u, StatusCode: 200, ContentLength: size, contentType: contentType}
+	}
+	s := &Summary{}
+	s.addPage(page("http://monzo.com/", "text/html; charset=utf-8", 100))
+	s.addPage(page("http://monzo.com/about", "text/html", 300))
+	s.addPage(page("http://monzo.com/terms.pdf", "Application/PDF", 1000))
+	s.addPage(page("http://monzo.com/data", "", 0))
+
+	require.Equal(t, int64(1400), s.Bytes)
+	require.Equal(t, map[string]*ContentTypeSummary{
+		"text/html":       {Pages: 2, Bytes: 400},
+		"application/pdf": {Pages: 1, Bytes: 1000},
+		"unknown":         {Pages: 1},
+	}, s.ContentTypes)
+	require.Contains(t, string(s.Marshal()), "Bytes:\n\t1400\nContent types:\n\tapplication/pdf: 1 pages, 1000 bytes\n\ttext/html: 2 pages, 400 bytes\n\tunknown: 1 pages, 0 bytes\n")
+
+	total := &Summary{}
+	total.add(s)
+	total.add(s)
+	require.Equal(t, int64(2800), total.Bytes)
+	require.Equal(t, &ContentTypeSummary{Pages: 4, Bytes: 800}, total.ContentTypes["text/html"])
+}
+
+func TestSummaryTopPages(t *testing.T) {
+	page := func(path string, size int64, duration time.Duration) *Page {
+		return &Page{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: path}, StatusCode: 200, ContentLength: size, FetchDuration: duration}
+	}
+	s := &Summary{top: 2}
+	s.addPage(page("/a", 100, 300*time.Millisecond))
+	s.addPage(page("/b", 300, 100*time.Millisecond))
+	s.addPage(page("/c", 200, 200*time.Millisecond))
+	s.addPage(page("/d", 50, 50*time.Millisecond))
+
+	require.Equal(t, []PageStat{
+		{URL: "http://monzo.com/a", StatusCode: 200, FetchDuration: 300 * time.Millisecond, ContentLength: 100},
+		{URL: "http://monzo.com/c", StatusCode: 200, FetchDuration: 200 * time.Millisecond, ContentLength: 200},
+	}, s.Slowest)
+	require.Equal(t, []string{"http://monzo.com/b", "http://monzo.com/c"}, []string{s.Largest[0].URL, s.Largest[1].URL})
+	require.Contains(t, string(s.Marshal()), "Slowest pages:\n\thttp://monzo.com/a: 300ms, 100 bytes, status 200\n\thttp://monzo.com/c: 200ms, 200 bytes, status 200\nLargest pages:\n\thttp://monzo.com/b: 300 bytes, 100ms, status 200\n")
+
+	total := &Summary{}
+	total.add(&Summary{top: 2, Slowest: []PageStat{{URL: "http://monzo.com/e", FetchDuration: time.Second}}})
+	total.add(s)
+	require.Len(t, total.Slowest, 2)
+	require.Equal(t, "http://monzo.com/e", total.Slowest[0].URL)
+	require.Equal(t, "http://monzo.com/a", total.Slowest[1].URL)
+}
+
+func TestCrawlWithResult(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":        {Links: []string{"/a", "/b", "/missing"}},
+		"/a":       {Links: []string{"/blog/1", "/blog/2"}},
+		"/b":       {},
+		"/blog/1":  {},
+		"/blog/2":  {},
+		"/missing": {Status: http.StatusNotFound},
+	})
+	defer srv.Close()
+
+	t.Run("complete", func(t *testing.T) {
+		c := New(2, srv.Client(), WithLogger(newTestLogger(io.Discard)))
+		result, err := c.CrawlWithResult(context.Background(), []string{srv.URL + "/"}, io.Discard)
+		require.NoError(t, err)
+		require.Equal(t, 5, result.Pages)
+		require.Equal(t, 1, result.Errors)
+		require.Empty(t, result.Limit)
+		require.Positive(t, result.Duration)
+		require.Equal(t, 5, result.Summary.Pages)
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		c := New(1, srv.Client(), WithPatternBudget("/blog/*", 1), WithLogger(newTestLogger(io.Discard)))
+		result, err := c.CrawlWithResult(context.Background(), []string{srv.URL + "/"}, io.Discard)
+		require.NoError(t, err)
+		require.Equal(t, 4, result.Pages)
+		require.Equal(t, SkipPatternBudget, result.Limit)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		c := New(1, srv.Client(), WithLogger(newTestLogger(io.Discard)))
+		result, err := c.CrawlWithResult(ctx, []string{srv.URL + "/"}, io.Discard)
+		require.Equal(t, context.Canceled, err)
+		require.NotNil(t, result)
+	})
+}
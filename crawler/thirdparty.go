@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/linkextract"
+)
+
+// thirdPartyElements are the elements whose URLs are inventoried as references to third parties: links and forms, and
+// the assets of mixedContentElements. parsePage collects their URLs for each of the audits of a page's assets.
+var thirdPartyElements = func() linkextract.Elements {
+	elements := linkextract.Elements{"a": {"href"}, "form": {"action"}}
+	for element, attrs := range mixedContentElements {
+		elements[element] = attrs
+	}
+	return elements
+}()
+
+// ThirdPartyReference is a URL on another registrable domain which a page links to or loads, e.g. an analytics script
+type ThirdPartyReference struct {
+	Element string // e.g. "script"
+	URL     *url.URL
+}
+
+// WithThirdPartyInventory records the URLs on other registrable domains which each page links to or loads, from its
+// links, forms, scripts, stylesheets, images, iframes and media, on Page.ThirdParty, see Report.ThirdPartyDomains
+func WithThirdPartyInventory() Option {
+	return func(c *crawler) {
+		c.thirdParty = true
+	}
+}
+
+// findThirdParty returns the references of a page to other registrable domains than the page's own, once each
+func findThirdParty(page *Page) []ThirdPartyReference {
+	domain := registrableDomain(page.base().Hostname())
+
+	var refs []ThirdPartyReference
+	seen := map[string]bool{}
+	for _, link := range page.assets {
+		if link.URL.Hostname() == "" || strings.EqualFold(registrableDomain(link.URL.Hostname()), domain) {
+			continue
+		}
+		if key := link.Element + " " + link.URL.String(); !seen[key] {
+			seen[key] = true
+			refs = append(refs, ThirdPartyReference{Element: link.Element, URL: link.URL})
+		}
+	}
+	return refs
+}
@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindThirdParty(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "https", Host: "www.monzo.com", Path: "/"}}
+	body := []byte(`<html><head>
+		<link rel="stylesheet" href="https://fonts.googleapis.com/css">
+		<script src="https://www.googletagmanager.com/gtag.js"></script>
+		<script src="https://www.googletagmanager.com/gtag.js"></script>
+		<script src="https://cdn.monzo.com/app.js"></script>
+	</head><body>
+		<a href="/about">About</a>
+		<a href="https://twitter.com/monzo">Twitter</a>
+		<a href="mailto:help@monzo.com">Email</a>
+		<img src="//images.example.com/logo.png">
+		<iframe src="https://www.youtube.com/embed/abc"></iframe>
+		<form action="https://payments.example.com/pay"></form>
+	</body></html>`)
+
+	described := []string{}
+	for _, ref := range findThirdParty(parsed(page, body)) {
+		described = append(described, ref.Element+" "+ref.URL.String())
+	}
+	require.Equal(t, []string{
+		"link https://fonts.googleapis.com/css",
+		"script https://www.googletagmanager.com/gtag.js",
+		"a https://twitter.com/monzo",
+		"img https://images.example.com/logo.png",
+		"iframe https://www.youtube.com/embed/abc",
+		"form https://payments.example.com/pay",
+	}, described)
+}
+
+func TestThirdPartyInventory(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":      {Body: `<html><body><a href="/about">About</a><a href="https://twitter.com/monzo">Twitter</a><script src="https://cdn.example.com/a.js"></script></body></html>`},
+		"/about": {Body: `<html><body><img src="https://cdn.example.com/team.png"><img src="https://cdn.example.com/office.png"></body></html>`},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	out := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithReport(report), WithThirdPartyInventory(), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", out))
+
+	require.Contains(t, out.String(), "ThirdParty:\n\ta: https://twitter.com/monzo\n\tscript: https://cdn.example.com/a.js\n")
+	require.Equal(t, []DomainUsage{
+		{Domain: "cdn.example.com", Pages: 2, References: 3, Elements: []string{"img", "script"}, Examples: []string{srv.URL + "/", srv.URL + "/about"}},
+		{Domain: "twitter.com", Pages: 1, References: 1, Elements: []string{"a"}, Examples: []string{srv.URL + "/"}},
+	}, report.ThirdPartyDomains())
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer records a span for each crawl, with a child span for each page fetched
+func WithTracer(t trace.Tracer) Option {
+	return func(c *crawler) {
+		c.tracer = t
+	}
+}
+
+// startCrawlSpan starts the root span of a crawl, returning a subscriber which records its fetches as child spans and
+// a function to end it with the crawl's result
+func (c *crawler) startCrawlSpan(ctx context.Context, rawURL string) (func(Event), func(*Summary, error)) {
+	ctx, span := c.tracer.Start(ctx, "crawl", trace.WithAttributes(attribute.String("crawl.seed", rawURL)))
+
+	end := func(summary *Summary, err error) {
+		span.SetAttributes(
+			attribute.Int("crawl.pages", summary.Pages),
+			attribute.Int("crawl.errors", summary.Errors),
+			attribute.Int("crawl.skipped", summary.Skipped),
+		)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	return traceFetches(ctx, c.tracer), end
+}
+
+// traceFetches returns a subscriber recording a span under ctx for each completed fetch
+func traceFetches(ctx context.Context, tracer trace.Tracer) func(Event) {
+	return func(e Event) {
+		fetch, ok := e.(FetchCompleted)
+		if !ok {
+			return
+		}
+
+		end := time.Now()
+		_, span := tracer.Start(ctx, "fetch",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithTimestamp(end.Add(-fetch.Duration)),
+			trace.WithAttributes(
+				attribute.String("url.full", displayURL(fetch.URL)),
+				attribute.Int64("http.response.body.size", fetch.ContentLength),
+			),
+		)
+		if fetch.StatusCode != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", fetch.StatusCode))
+		}
+		switch {
+		case fetch.Err != nil:
+			span.RecordError(fetch.Err)
+			span.SetStatus(codes.Error, fetch.Err.Error())
+		case fetch.StatusCode >= 400:
+			span.SetStatus(codes.Error, "")
+		}
+		span.End(trace.WithTimestamp(end))
+	}
+}
@@ -0,0 +1,50 @@
+package crawler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/missing"></a></body></html>`)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	c := New(1, srv.Client(), WithTracer(provider.Tracer("test")), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	root := spans[2]
+	require.Equal(t, "crawl", root.Name())
+	require.Contains(t, root.Attributes(), attribute.Int("crawl.pages", 1))
+	require.Contains(t, root.Attributes(), attribute.Int("crawl.errors", 1))
+
+	for i, path := range []string{"/", "/missing"} {
+		fetch := spans[i]
+		require.Equal(t, "fetch", fetch.Name())
+		require.Equal(t, root.SpanContext().SpanID(), fetch.Parent().SpanID())
+		require.Contains(t, fetch.Attributes(), attribute.String("url.full", srv.URL+path))
+	}
+	require.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", 200))
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Contains(t, spans[1].Attributes(), attribute.Int("http.response.status_code", 404))
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// TrackerSignature identifies an analytics or tracking service by the URLs of its scripts
+type TrackerSignature struct {
+	Name string // e.g. "Google Analytics"
+	// Pattern is matched against the lower cased host name followed by the path of each third-party script, e.g.
+	// "www.google-analytics.com/analytics.js"
+	Pattern *regexp.Regexp
+}
+
+// DefaultTrackers are the signatures of widely used analytics, advertising and session recording services, which may
+// be extended with others by appending to a copy of it
+var DefaultTrackers = []TrackerSignature{
+	{Name: "Google Analytics", Pattern: regexp.MustCompile(`^([^/]+\.)?google-analytics\.com/`)},
+	{Name: "Google Tag Manager", Pattern: regexp.MustCompile(`^([^/]+\.)?googletagmanager\.com/`)},
+	{Name: "Google Ads", Pattern: regexp.MustCompile(`^([^/]+\.)?(googleadservices\.com|googlesyndication\.com|doubleclick\.net)/`)},
+	{Name: "Meta Pixel", Pattern: regexp.MustCompile(`^connect\.facebook\.net/`)},
+	{Name: "LinkedIn Insight", Pattern: regexp.MustCompile(`^snap\.licdn\.com/`)},
+	{Name: "X Ads", Pattern: regexp.MustCompile(`^([^/]+\.)?ads-twitter\.com/`)},
+	{Name: "TikTok Pixel", Pattern: regexp.MustCompile(`^analytics\.tiktok\.com/`)},
+	{Name: "Microsoft Advertising", Pattern: regexp.MustCompile(`^bat\.bing\.com/`)},
+	{Name: "Microsoft Clarity", Pattern: regexp.MustCompile(`^([^/]+\.)?clarity\.ms/`)},
+	{Name: "Hotjar", Pattern: regexp.MustCompile(`^([^/]+\.)?hotjar\.com/`)},
+	{Name: "FullStory", Pattern: regexp.MustCompile(`^([^/]+\.)?fullstory\.com/`)},
+	{Name: "Segment", Pattern: regexp.MustCompile(`^cdn\.segment\.(com|io)/`)},
+	{Name: "Mixpanel", Pattern: regexp.MustCompile(`^([^/]+\.)?(mxpnl|mixpanel)\.com/`)},
+	{Name: "Amplitude", Pattern: regexp.MustCompile(`^([^/]+\.)?amplitude\.com/`)},
+	{Name: "Heap", Pattern: regexp.MustCompile(`^([^/]+\.)?heapanalytics\.com/`)},
+	{Name: "HubSpot", Pattern: regexp.MustCompile(`^([^/]+\.)?(hs-scripts|hs-analytics)\.(com|net)/`)},
+	{Name: "Plausible", Pattern: regexp.MustCompile(`^plausible\.io/js/`)},
+	{Name: "Matomo", Pattern: regexp.MustCompile(`/(matomo|piwik)\.js$`)},
+}
+
+// Tracker is a third-party script of an analytics or tracking service which a page loads
+type Tracker struct {
+	Name string // the name of the TrackerSignature matched
+	URL  *url.URL
+}
+
+// WithTrackerDetection records the analytics and tracking services whose scripts each page loads from other
+// registrable domains on Page.Trackers, identifying them by the first of signatures matching each script, see
+// DefaultTrackers and Report.Trackers
+func WithTrackerDetection(signatures []TrackerSignature) Option {
+	return func(c *crawler) {
+		c.trackers = signatures
+	}
+}
+
+// findTrackers returns the third-party scripts of a page matching any of signatures, once each
+func findTrackers(page *Page, signatures []TrackerSignature) []Tracker {
+	domain := registrableDomain(page.base().Hostname())
+
+	var trackers []Tracker
+	seen := map[string]bool{}
+	for _, link := range page.assets {
+		if link.Element != "script" || link.URL.Hostname() == "" || strings.EqualFold(registrableDomain(link.URL.Hostname()), domain) || seen[link.URL.String()] {
+			continue
+		}
+		seen[link.URL.String()] = true
+		script := strings.ToLower(link.URL.Hostname()) + link.URL.EscapedPath()
+		for _, signature := range signatures {
+			if signature.Pattern.MatchString(script) {
+				trackers = append(trackers, Tracker{Name: signature.Name, URL: link.URL})
+				break
+			}
+		}
+	}
+	return trackers
+}
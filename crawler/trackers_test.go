@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindTrackers(t *testing.T) {
+	page := &Page{URL: &url.URL{Scheme: "https", Host: "monzo.com", Path: "/"}}
+	body := []byte(`<html><head>
+		<script src="https://www.googletagmanager.com/gtag/js?id=G-123"></script>
+		<script src="https://www.googletagmanager.com/gtag/js?id=G-123"></script>
+		<script src="//connect.facebook.net/en_US/fbevents.js"></script>
+		<script src="https://static.hotjar.com/c/hotjar-1.js"></script>
+		<script src="https://cdn.example.com/app.js"></script>
+		<script src="/matomo.js"></script>
+	</head><body>
+		<a href="https://www.google-analytics.com/">Analytics</a>
+		<img src="https://www.google-analytics.com/collect">
+	</body></html>`)
+	parsePage(page, bytes.NewReader(body))
+
+	described := func(trackers []Tracker) []string {
+		described := []string{}
+		for _, tracker := range trackers {
+			described = append(described, tracker.Name+" "+tracker.URL.String())
+		}
+		return described
+	}
+
+	t.Run("default", func(t *testing.T) {
+		require.Equal(t, []string{
+			"Google Tag Manager https://www.googletagmanager.com/gtag/js?id=G-123",
+			"Meta Pixel https://connect.facebook.net/en_US/fbevents.js",
+			"Hotjar https://static.hotjar.com/c/hotjar-1.js",
+		}, described(findTrackers(page, DefaultTrackers)))
+	})
+
+	t.Run("extended", func(t *testing.T) {
+		signatures := append([]TrackerSignature{}, DefaultTrackers...)
+		signatures = append(signatures, TrackerSignature{Name: "Example", Pattern: regexp.MustCompile(`^cdn\.example\.com/`)})
+		require.Contains(t, described(findTrackers(page, signatures)), "Example https://cdn.example.com/app.js")
+	})
+}
+
+func TestTrackerDetection(t *testing.T) {
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":      {Body: `<html><body><a href="/about">About</a><script src="https://www.google-analytics.com/analytics.js"></script></body></html>`},
+		"/about": {Body: `<html><body><script src="https://www.google-analytics.com/analytics.js"></script><script src="https://snap.licdn.com/li.lms-analytics/insight.min.js"></script></body></html>`},
+	})
+	defer srv.Close()
+
+	report := &Report{}
+	out := &bytes.Buffer{}
+	c := New(1, srv.Client(), WithReport(report), WithTrackerDetection(DefaultTrackers), WithLogger(newTestLogger(io.Discard)))
+	require.NoError(t, c.Crawl(srv.URL+"/", out))
+
+	require.Contains(t, out.String(), "Trackers:\n\tGoogle Analytics: https://www.google-analytics.com/analytics.js\n")
+	require.Equal(t, []TrackerUsage{
+		{Name: "Google Analytics", Pages: 2, Scripts: []string{"https://www.google-analytics.com/analytics.js"}, Examples: []string{srv.URL + "/", srv.URL + "/about"}},
+		{Name: "LinkedIn Insight", Pages: 1, Scripts: []string{"https://snap.licdn.com/li.lms-analytics/insight.min.js"}, Examples: []string{srv.URL + "/about"}},
+	}, report.Trackers())
+}
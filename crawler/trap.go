@@ -0,0 +1,126 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrCrawlTrap = errors.New("likely crawl trap")
+
+// TrapLimits configures the heuristics used to detect likely infinite URL spaces such as calendars, faceted navigation
+// and ever-growing query permutations. Zero values disable the corresponding heuristic.
+type TrapLimits struct {
+	MaxURLsPerPattern   int // URLs sharing a pattern, which replaces numeric and ID-like path segments and ignores query values
+	MaxRepeatedSegments int // occurrences of any one path segment within a URL, e.g. /a/b/a/b/a/b
+	MaxQueryVariants    int // distinct query strings for a single path
+}
+
+// DefaultTrapLimits are limits loose enough not to interfere with ordinary sites
+var DefaultTrapLimits = TrapLimits{
+	MaxURLsPerPattern:   1000,
+	MaxRepeatedSegments: 3,
+	MaxQueryVariants:    500,
+}
+
+// WithTrapDetection stops expanding URLs which look like part of a crawl trap, warning once per trap
+func WithTrapDetection(l TrapLimits) Option {
+	return func(c *crawler) {
+		c.trapLimits = l
+	}
+}
+
+var (
+	numericSegment = regexp.MustCompile(`^[0-9]+([-_.][0-9]+)*$`)
+	idSegment      = regexp.MustCompile(`^[0-9a-fA-F-]{16,}$`)
+)
+
+// trapDetector holds the state of trap detection for a single crawl
+type trapDetector struct {
+	limits        TrapLimits
+	patternCounts map[string]int
+	queryVariants map[string]int
+	traps         map[string]struct{}
+}
+
+func newTrapDetector(limits TrapLimits) *trapDetector {
+	return &trapDetector{
+		limits:        limits,
+		patternCounts: map[string]int{},
+		queryVariants: map[string]int{},
+		traps:         map[string]struct{}{},
+	}
+}
+
+// check records a URL about to be crawled, returning an error if it looks like part of a trap. newTrap is true the
+// first time a trap is detected, so that it's only warned about once.
+func (d *trapDetector) check(u *url.URL) (trap string, newTrap bool, err error) {
+	if d.limits.MaxRepeatedSegments > 0 {
+		counts := map[string]int{}
+		for _, segment := range strings.Split(u.EscapedPath(), "/") {
+			if segment == "" {
+				continue
+			}
+			if counts[segment]++; counts[segment] > d.limits.MaxRepeatedSegments {
+				return d.trap(u.Host+"/**/"+segment, "path segment repeated more than %d times", d.limits.MaxRepeatedSegments)
+			}
+		}
+	}
+
+	path := u.Host + u.EscapedPath()
+	if d.limits.MaxQueryVariants > 0 && u.RawQuery != "" {
+		if _, ok := d.traps[path+"?*"]; ok {
+			return path + "?*", false, errors.Wrapf(ErrCrawlTrap, "more than %d query variants", d.limits.MaxQueryVariants)
+		}
+		if d.queryVariants[path]++; d.queryVariants[path] > d.limits.MaxQueryVariants {
+			return d.trap(path+"?*", "more than %d query variants", d.limits.MaxQueryVariants)
+		}
+	}
+
+	pattern := urlPattern(u)
+	if d.limits.MaxURLsPerPattern > 0 {
+		if _, ok := d.traps[pattern]; ok {
+			return pattern, false, errors.Wrapf(ErrCrawlTrap, "more than %d URLs matching pattern", d.limits.MaxURLsPerPattern)
+		}
+		if d.patternCounts[pattern]++; d.patternCounts[pattern] > d.limits.MaxURLsPerPattern {
+			return d.trap(pattern, "more than %d URLs matching pattern", d.limits.MaxURLsPerPattern)
+		}
+	}
+
+	return "", false, nil
+}
+
+func (d *trapDetector) trap(trap, format string, args ...interface{}) (string, bool, error) {
+	_, seen := d.traps[trap]
+	d.traps[trap] = struct{}{}
+	return trap, !seen, errors.Wrapf(ErrCrawlTrap, format, args...)
+}
+
+// urlPattern generalises a URL by replacing numeric and ID-like path segments with placeholders and reducing its query
+// to its sorted parameter names, e.g. "/calendar/2018/06/01?view=day" becomes "/calendar/{n}/{n}/{n}?view"
+func urlPattern(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		switch {
+		case numericSegment.MatchString(segment):
+			segments[i] = "{n}"
+		case idSegment.MatchString(segment):
+			segments[i] = "{id}"
+		}
+	}
+	pattern := u.Host + strings.Join(segments, "/")
+
+	if u.RawQuery != "" {
+		keys := []string{}
+		for key := range u.Query() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pattern += "?" + strings.Join(keys, "&")
+	}
+
+	return pattern
+}
@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLPattern(t *testing.T) {
+	tests := []struct {
+		title, rawURL, expected string
+	}{
+		{"static", "http://www.google.com/about", "www.google.com/about"},
+		{"numeric", "http://www.google.com/calendar/2018/06/01", "www.google.com/calendar/{n}/{n}/{n}"},
+		{"date", "http://www.google.com/events/2018-06-01", "www.google.com/events/{n}"},
+		{"id", "http://www.google.com/items/0f8fad5b-d9cb-469f-a165-70867728950e", "www.google.com/items/{id}"},
+		{"query", "http://www.google.com/search?q=test&color=red&color=blue", "www.google.com/search?color&q"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			u, err := url.Parse(tt.rawURL)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, urlPattern(u))
+		})
+	}
+}
+
+func TestTrapDetector(t *testing.T) {
+	check := func(d *trapDetector, rawURL string) (string, bool, error) {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return d.check(u)
+	}
+
+	t.Run("urls per pattern", func(t *testing.T) {
+		d := newTrapDetector(TrapLimits{MaxURLsPerPattern: 3})
+		for i := 1; i <= 3; i++ {
+			_, _, err := check(d, fmt.Sprintf("http://www.google.com/calendar/%d", i))
+			require.NoError(t, err)
+		}
+
+		trap, newTrap, err := check(d, "http://www.google.com/calendar/4")
+		require.Equal(t, ErrCrawlTrap, errors.Cause(err))
+		require.Equal(t, "www.google.com/calendar/{n}", trap)
+		require.True(t, newTrap)
+
+		_, newTrap, err = check(d, "http://www.google.com/calendar/5")
+		require.Equal(t, ErrCrawlTrap, errors.Cause(err))
+		require.False(t, newTrap)
+
+		_, _, err = check(d, "http://www.google.com/about")
+		require.NoError(t, err)
+	})
+
+	t.Run("repeated segments", func(t *testing.T) {
+		d := newTrapDetector(TrapLimits{MaxRepeatedSegments: 2})
+		_, _, err := check(d, "http://www.google.com/a/b/a/b")
+		require.NoError(t, err)
+
+		trap, newTrap, err := check(d, "http://www.google.com/a/b/a/b/a")
+		require.Equal(t, ErrCrawlTrap, errors.Cause(err))
+		require.Equal(t, "www.google.com/**/a", trap)
+		require.True(t, newTrap)
+
+		_, newTrap, err = check(d, "http://www.google.com/a/b/a/b/a/b")
+		require.Equal(t, ErrCrawlTrap, errors.Cause(err))
+		require.False(t, newTrap)
+	})
+
+	t.Run("query variants", func(t *testing.T) {
+		d := newTrapDetector(TrapLimits{MaxQueryVariants: 2})
+		_, _, err := check(d, "http://www.google.com/shop?color=red")
+		require.NoError(t, err)
+		_, _, err = check(d, "http://www.google.com/shop?size=small")
+		require.NoError(t, err)
+
+		trap, newTrap, err := check(d, "http://www.google.com/shop?color=red&size=small")
+		require.Equal(t, ErrCrawlTrap, errors.Cause(err))
+		require.Equal(t, "www.google.com/shop?*", trap)
+		require.True(t, newTrap)
+
+		_, _, err = check(d, "http://www.google.com/shop")
+		require.NoError(t, err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		d := newTrapDetector(TrapLimits{})
+		for i := 0; i < 100; i++ {
+			_, _, err := check(d, fmt.Sprintf("http://www.google.com/a/a/a/a/%d?page=%d", i, i))
+			require.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,229 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrMalformedOutput = errors.New("malformed crawl output")
+
+// ReadPages reads pages in the format written by Page.Marshal, e.g. the output of an earlier crawl. Unknown sections
+// are ignored, so that output written by newer versions can be read.
+func ReadPages(r io.Reader) ([]*Page, error) {
+	pages := []*Page{}
+	var page *Page
+	section := ""
+	lineNo := 0
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "\t") {
+			section = strings.TrimSuffix(strings.TrimSpace(line), ":")
+			if section == "URL" {
+				page = &Page{}
+				pages = append(pages, page)
+			} else if page == nil {
+				return nil, errors.Wrapf(ErrMalformedOutput, "line %d: %s section before URL", lineNo, section)
+			}
+			continue
+		}
+		if page == nil {
+			return nil, errors.Wrapf(ErrMalformedOutput, "line %d: value before URL", lineNo)
+		}
+
+		if err := setPageField(page, section, line[1:]); err != nil {
+			return nil, errors.Wrapf(ErrMalformedOutput, "line %d: %s: %s", lineNo, section, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// setPageField sets the field of a page written in the given section of its marshalled form from a line of it
+func setPageField(page *Page, section, value string) (err error) {
+	switch section {
+	case "URL":
+		page.URL, err = url.Parse(value)
+	case "Referrer":
+		page.Referrer, err = url.Parse(value)
+	case "RedirectedTo":
+		page.RedirectedTo, err = url.Parse(value)
+	case "Location":
+		page.Location, err = url.Parse(value)
+	case "Refresh":
+		page.Refresh, err = url.Parse(value)
+	case "Next":
+		page.Next, err = url.Parse(value)
+	case "Prev":
+		page.Prev, err = url.Parse(value)
+	case "AMP":
+		page.AMP, err = url.Parse(value)
+	case "Alternates":
+		var alternate *url.URL
+		if alternate, err = url.Parse(value); err == nil {
+			page.Alternates = append(page.Alternates, alternate)
+		}
+	case "Status":
+		page.StatusCode, err = strconv.Atoi(value)
+	case "ContentLength":
+		page.ContentLength, err = strconv.ParseInt(value, 10, 64)
+	case "FetchDuration":
+		page.FetchDuration, err = time.ParseDuration(value)
+	case "ContentHash":
+		page.ContentHash = value
+	case "LastModified":
+		page.LastModified, err = time.Parse(time.RFC3339, value)
+	case "Title":
+		page.Title = value
+	case "Description":
+		page.Description = value
+	case "Protocol":
+		page.Protocol = value
+	case "UserAgent":
+		page.UserAgent = value
+	case "Soft404":
+		page.Soft404 = value
+	case "Language":
+		page.Language = value
+	case "OffsiteHops":
+		page.OffsiteHops, err = strconv.Atoi(value)
+	case "Robots":
+		applyRobotsDirectives(page, value)
+	case "Matches":
+		pattern, context := splitPair(value)
+		page.Matches = append(page.Matches, SearchMatch{Pattern: pattern, Context: context})
+	case "MixedContent":
+		element, rawURL := splitPair(value)
+		var u *url.URL
+		if u, err = url.Parse(rawURL); err == nil {
+			page.MixedContent = append(page.MixedContent, MixedContent{Element: element, URL: u})
+		}
+	case "ThirdParty":
+		element, rawURL := splitPair(value)
+		var u *url.URL
+		if u, err = url.Parse(rawURL); err == nil {
+			page.ThirdParty = append(page.ThirdParty, ThirdPartyReference{Element: element, URL: u})
+		}
+	case "Trackers":
+		name, rawURL := splitPair(value)
+		var u *url.URL
+		if u, err = url.Parse(rawURL); err == nil {
+			page.Trackers = append(page.Trackers, Tracker{Name: name, URL: u})
+		}
+	case "Accessibility":
+		check, target := splitPair(value)
+		page.Accessibility = append(page.Accessibility, AccessibilityIssue{Check: check, Target: target})
+	case "AnchorTexts":
+		rawURL, text := splitPair(value)
+		anchor := AnchorText{Text: text}
+		if i := strings.Index(text, "\t"); i >= 0 {
+			anchor.Text, anchor.Title = text[:i], text[i+1:]
+		}
+		if anchor.URL, err = url.Parse(rawURL); err == nil {
+			page.AnchorTexts = append(page.AnchorTexts, anchor)
+		}
+	case "Images":
+		// e.g. "https://monzo.com/logo.png: width=120 height=40 alt="Monzo"", with the alt text last
+		rawURL, attrs := splitPair(value)
+		image := Image{}
+		if i := strings.Index(attrs, "alt="); i >= 0 {
+			if image.Alt, err = strconv.Unquote(attrs[i+len("alt="):]); err != nil {
+				return err
+			}
+			image.HasAlt, attrs = true, attrs[:i]
+		}
+		for _, attr := range strings.Fields(attrs) {
+			key, v, _ := strings.Cut(attr, "=")
+			switch key {
+			case "width":
+				image.Width = v
+			case "height":
+				image.Height = v
+			}
+		}
+		if image.URL, err = url.Parse(rawURL); err == nil {
+			page.Images = append(page.Images, image)
+		}
+	case "Contacts":
+		source, contact := splitPair(value)
+		page.Contacts = append(page.Contacts, Contact{Source: source, Value: contact})
+	case "Subresources":
+		status, rest := splitPair(value)
+		parts := strings.SplitN(rest, " ", 3)
+		if len(parts) < 2 {
+			return errors.New("expected an element and URL")
+		}
+		sub := Subresource{Element: parts[0], Status: status}
+		if len(parts) == 3 {
+			sub.Integrity = parts[2]
+		}
+		if sub.URL, err = url.Parse(parts[1]); err == nil {
+			page.Subresources = append(page.Subresources, sub)
+		}
+	case "Forms":
+		method, rawURL := value, ""
+		if i := strings.Index(value, " "); i >= 0 {
+			method, rawURL = value[:i], value[i+1:]
+		}
+		var u *url.URL
+		if u, err = url.Parse(rawURL); err == nil {
+			page.Forms = append(page.Forms, Form{Method: method, Action: u})
+		}
+	case "InsecureForms":
+		reason, form := splitPair(value)
+		method, rawURL := form, ""
+		if i := strings.Index(form, " "); i >= 0 {
+			method, rawURL = form[:i], form[i+1:]
+		}
+		var u *url.URL
+		if u, err = url.Parse(rawURL); err == nil {
+			page.InsecureForms = append(page.InsecureForms, InsecureForm{Action: u, Method: method, Reason: reason})
+		}
+	case "Cookies":
+		page.Cookies = append(page.Cookies, parseCookie(value))
+	case "Fields":
+		if page.Fields == nil {
+			page.Fields = map[string][]string{}
+		}
+		field, v := splitPair(value)
+		page.Fields[field] = append(page.Fields[field], v)
+	case "Headers":
+		if page.Headers == nil {
+			page.Headers = http.Header{}
+		}
+		k, v := splitPair(value)
+		page.Headers[k] = append(page.Headers[k], v)
+	case "Links":
+		var link *url.URL
+		if link, err = url.Parse(value); err == nil {
+			page.Links = append(page.Links, link)
+		}
+	}
+	return err
+}
+
+// splitPair splits a "key: value" line of a section listing pairs
+func splitPair(line string) (string, string) {
+	parts := strings.SplitN(line, ": ", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPages(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		pages := []*Page{
+			{
+				URL:           &url.URL{Scheme: "http", Host: "monzo.com", Path: "/"},
+				StatusCode:    200,
+				ContentLength: 512,
+				FetchDuration: 150 * time.Millisecond,
+				ContentHash:   "abc123",
+				LastModified:  time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC),
+				Title:         "Monzo",
+				Description:   "Banking made easy",
+				Language:      "en",
+				NoFollow:      true,
+				Next:          &url.URL{Scheme: "http", Host: "monzo.com", Path: "/2"},
+				AMP:           &url.URL{Scheme: "http", Host: "monzo.com", Path: "/amp"},
+				Alternates:    []*url.URL{{Scheme: "http", Host: "m.monzo.com", Path: "/"}},
+				Matches:       []SearchMatch{{Pattern: "Mondo", Context: "Mondo: now Monzo"}},
+				MixedContent:  []MixedContent{{Element: "img", URL: &url.URL{Scheme: "http", Host: "cdn.monzo.com", Path: "/logo.png"}}},
+				ThirdParty:    []ThirdPartyReference{{Element: "script", URL: &url.URL{Scheme: "https", Host: "analytics.example.com", Path: "/a.js"}}},
+				Trackers:      []Tracker{{Name: "Google Analytics", URL: &url.URL{Scheme: "https", Host: "www.google-analytics.com", Path: "/analytics.js"}}},
+				Contacts:      []Contact{{Source: ContactMailto, Value: "help@monzo.com"}, {Source: ContactTel, Value: "+44 800 802 1281"}},
+				Subresources: []Subresource{
+					{Element: "script", URL: &url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/app.js"}, Integrity: "sha384-abc sha512-def", Status: SRIValid},
+					{Element: "link", URL: &url.URL{Scheme: "https", Host: "cdn.example.com", Path: "/style.css"}, Status: SRIMissing},
+				},
+				Accessibility: []AccessibilityIssue{{Check: AccessibilityEmptyLink, Target: "http://monzo.com/careers"}, {Check: AccessibilityMissingLang}},
+				AnchorTexts: []AnchorText{
+					{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/about"}, Text: "About us", Title: "Who we are"},
+					{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/careers"}},
+				},
+				Images: []Image{
+					{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/logo.png"}, Alt: `Monzo "hot coral"`, HasAlt: true, Width: "120px", Height: "40"},
+					{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/divider.png"}, HasAlt: true},
+					{URL: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/hero.jpg"}, Width: "100%"},
+				},
+				Forms:         []Form{{Method: "GET", Action: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/search"}}},
+				InsecureForms: []InsecureForm{{Action: &url.URL{Scheme: "http", Host: "monzo.com", Path: "/login"}, Method: "POST", Reason: InsecureFormHTTP}},
+				Fields:        map[string][]string{"heading": {"Welcome", "Hello"}},
+				Headers:       http.Header{"Content-Type": {"text/html"}},
+				Cookies:       []Cookie{{Name: "session", Secure: true, HttpOnly: true, SameSite: "Lax"}, {Name: "tracking"}},
+				Links:         []*url.URL{{Scheme: "http", Host: "monzo.com", Path: "/about"}},
+			},
+			{
+				URL:        &url.URL{Scheme: "http", Host: "monzo.com", Path: "/about"},
+				Referrer:   &url.URL{Scheme: "http", Host: "monzo.com", Path: "/"},
+				StatusCode: 301,
+				Location:   &url.URL{Scheme: "http", Host: "example.com", Path: "/"},
+				Refresh:    &url.URL{Scheme: "http", Host: "monzo.com", Path: "/new"},
+			},
+		}
+
+		out := &bytes.Buffer{}
+		for _, page := range pages {
+			out.Write(page.Marshal())
+		}
+
+		read, err := ReadPages(out)
+		require.NoError(t, err)
+		require.Len(t, read, 2)
+		for i := range pages {
+			require.Equal(t, string(pages[i].Marshal()), string(read[i].Marshal()))
+		}
+	})
+
+	t.Run("unknown sections ignored", func(t *testing.T) {
+		read, err := ReadPages(strings.NewReader("URL:\n\thttp://monzo.com\nSomethingNew:\n\tvalue\nStatus:\n\t200\n"))
+		require.NoError(t, err)
+		require.Len(t, read, 1)
+		require.Equal(t, 200, read[0].StatusCode)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := ReadPages(strings.NewReader("URL:\n\thttp://monzo.com\nStatus:\n\tOK\n"))
+		require.Equal(t, ErrMalformedOutput, errors.Cause(err))
+		require.Contains(t, err.Error(), "line 4: Status")
+
+		_, err = ReadPages(strings.NewReader("Status:\n\t200\n"))
+		require.Equal(t, ErrMalformedOutput, errors.Cause(err))
+	})
+}
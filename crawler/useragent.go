@@ -0,0 +1,49 @@
+package crawler
+
+import (
+	"hash/fnv"
+	"net/url"
+)
+
+// DefaultUserAgent is the User-Agent sent with every request unless replaced with WithUserAgent or WithUserAgents
+const DefaultUserAgent = "web_crawler (+https://github.com/eggsbenjamin/web_crawler)"
+
+// UserAgentRotation is how the user agents given to WithUserAgents are rotated
+type UserAgentRotation int
+
+const (
+	// RotatePerRequest sends each request with the next user agent in turn
+	RotatePerRequest UserAgentRotation = iota
+	// RotatePerHost sends every request to a host with the same user agent, so that each host sees a consistent client
+	RotatePerHost
+)
+
+// WithUserAgent replaces DefaultUserAgent as the User-Agent sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *crawler) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithUserAgents rotates the User-Agent sent with each request over userAgents, recording the one used on
+// Page.UserAgent, e.g. to compare how a site responds to different browsers and bots
+func WithUserAgents(rotation UserAgentRotation, userAgents ...string) Option {
+	return func(c *crawler) {
+		c.userAgentRotation = rotation
+		c.userAgents = userAgents
+	}
+}
+
+// userAgentFor returns the User-Agent to send with a request for u
+func (c *crawler) userAgentFor(u *url.URL) string {
+	if len(c.userAgents) == 0 {
+		return c.userAgent
+	}
+	if c.userAgentRotation == RotatePerHost {
+		h := fnv.New32a()
+		h.Write([]byte(u.Hostname()))
+		return c.userAgents[h.Sum32()%uint32(len(c.userAgents))]
+	}
+	turn := c.userAgentTurn.Add(1) - 1
+	return c.userAgents[turn%uint64(len(c.userAgents))]
+}
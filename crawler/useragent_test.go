@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgents(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+		if r.URL.Path == "/" {
+			w.Write([]byte(`<html><body><a href="/a"></a></body></html>`))
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("default", func(t *testing.T) {
+		require.NoError(t, New(1, srv.Client()).Crawl(srv.URL+"/", &bytes.Buffer{}))
+		require.Equal(t, DefaultUserAgent, received["/"])
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, New(1, srv.Client(), WithUserAgent("custom/1.0")).Crawl(srv.URL+"/", &out))
+		require.Equal(t, "custom/1.0", received["/"])
+		require.NotContains(t, out.String(), "UserAgent:")
+	})
+
+	t.Run("rotated per request", func(t *testing.T) {
+		var out bytes.Buffer
+		c := New(1, srv.Client(), WithUserAgents(RotatePerRequest, "one/1.0", "two/1.0"))
+		require.NoError(t, c.Crawl(srv.URL+"/", &out))
+		require.Equal(t, "one/1.0", received["/"])
+		require.Equal(t, "two/1.0", received["/a"])
+		require.Contains(t, out.String(), "UserAgent:\n\tone/1.0\n")
+		require.Contains(t, out.String(), "UserAgent:\n\ttwo/1.0\n")
+	})
+
+	t.Run("request hook overrides", func(t *testing.T) {
+		c := New(1, srv.Client(), WithUserAgent("custom/1.0"), WithRequestHook(func(r *http.Request) error {
+			r.Header.Set("User-Agent", "hooked/1.0")
+			return nil
+		}))
+		require.NoError(t, c.Crawl(srv.URL+"/", &bytes.Buffer{}))
+		require.Equal(t, "hooked/1.0", received["/"])
+	})
+}
+
+func TestUserAgentFor(t *testing.T) {
+	c := New(1, nil, WithUserAgents(RotatePerHost, "one/1.0", "two/1.0", "three/1.0")).(*crawler)
+	seen := map[string]bool{}
+	for _, host := range []string{"a.monzo.com", "b.monzo.com", "c.monzo.com", "d.monzo.com", "e.monzo.com"} {
+		u := &url.URL{Scheme: "http", Host: host}
+		userAgent := c.userAgentFor(u)
+		for i := 0; i < 3; i++ {
+			require.Equal(t, userAgent, c.userAgentFor(&url.URL{Scheme: "https", Host: host, Path: "/page"}))
+		}
+		seen[userAgent] = true
+	}
+	require.True(t, len(seen) > 1, "every host was given the same user agent")
+}
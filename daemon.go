@@ -0,0 +1,853 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+const (
+	defaultJobWorkers   = 10          // the number of workers of a job which doesn't set them, unless WORKERS is set
+	maxQueuedJobs       = 1000        // submissions beyond this many waiting jobs are rejected
+	watchInterval       = time.Second // how often the watched directory is checked for new jobs
+	maxJobSpecSize      = 1 << 20
+	jobProgressInterval = time.Second            // how often a running job's progress is updated
+	followInterval      = 200 * time.Millisecond // how often output being followed is checked for more
+	submittedExtension  = ".submitted"           // added to the name of a job file in the watched directory once submitted
+	invalidExtension    = ".invalid"             // added to the name of a job file in the watched directory which is invalid
+)
+
+// Job states
+const (
+	jobQueued    = "queued"
+	jobRunning   = "running"
+	jobPaused    = "paused"
+	jobDone      = "done"
+	jobFailed    = "failed"
+	jobCancelled = "cancelled"
+)
+
+var (
+	errQueueFull     = errors.New("too many jobs queued")
+	errNoJobSeeds    = errors.New("a job needs at least one seed")
+	errJobFinished   = errors.New("the job has already finished")
+	errJobNotRunning = errors.New("the job isn't running")
+	errJobNotPaused  = errors.New("the job isn't paused")
+)
+
+// jobSpec is a crawl submitted to the daemon
+type jobSpec struct {
+	Seeds    []string `json:"seeds"`
+	Workers  int      `json:"workers,omitempty"`
+	MaxPages int      `json:"max_pages,omitempty"`
+}
+
+// job is a crawl submitted to the daemon and its progress, saved as job.json in its directory
+type job struct {
+	jobSpec
+	ID        string           `json:"id"`
+	Schedule  string           `json:"schedule,omitempty"` // the name of the schedule which submitted the job, if any
+	State     string           `json:"state"`
+	Error     string           `json:"error,omitempty"`
+	Submitted time.Time        `json:"submitted"`
+	Started   *time.Time       `json:"started,omitempty"`
+	Finished  *time.Time       `json:"finished,omitempty"`
+	Progress  *jobProgress     `json:"progress,omitempty"` // updated while the job runs
+	Summary   *crawler.Summary `json:"summary,omitempty"`
+}
+
+// jobProgress is a snapshot of a running job's progress, see crawler.Progress
+type jobProgress struct {
+	Elapsed        float64          `json:"elapsed_seconds"`
+	Crawled        int              `json:"crawled"`
+	Queued         int              `json:"queued"`
+	Errors         int              `json:"errors"`
+	Discovered     int              `json:"discovered"`
+	CompletionRate float64          `json:"pages_per_second"`
+	ETA            *float64         `json:"eta_seconds,omitempty"`        // the earliest the job is likely to finish
+	LatestETA      *float64         `json:"latest_eta_seconds,omitempty"` // unset while URLs are found faster than they're crawled
+	Hosts          map[string]int   `json:"hosts"`
+	Bytes          int64            `json:"bytes"`
+	HostBytes      map[string]int64 `json:"host_bytes"`
+	ErrorClasses   map[string]int   `json:"error_classes,omitempty"`
+	RecentErrors   []string         `json:"recent_errors,omitempty"`
+}
+
+func newJobProgress(p crawler.Progress) *jobProgress {
+	progress := &jobProgress{
+		Elapsed:        p.Elapsed.Seconds(),
+		Crawled:        p.Crawled,
+		Queued:         p.Queued,
+		Errors:         p.Errors,
+		Discovered:     p.Discovered,
+		CompletionRate: p.CompletionRate,
+		Hosts:          p.Hosts,
+		Bytes:          p.Bytes,
+		HostBytes:      p.HostBytes,
+		ErrorClasses:   p.ErrorClasses,
+	}
+	if earliest, latest, bounded := p.ETA(); p.CompletionRate > 0 {
+		eta := earliest.Seconds()
+		progress.ETA = &eta
+		if bounded {
+			latestETA := latest.Seconds()
+			progress.LatestETA = &latestETA
+		}
+	}
+	for _, err := range p.RecentErrors {
+		progress.RecentErrors = append(progress.RecentErrors, err.Error())
+	}
+	return progress
+}
+
+// finished reports whether a job has stopped for good
+func (j *job) finished() bool {
+	return j.State == jobDone || j.State == jobFailed || j.State == jobCancelled
+}
+
+// daemon runs crawl jobs submitted over its API or to a watched directory, a few at a time, storing each job's output
+// in a directory of its own
+type daemon struct {
+	dir         string
+	concurrency int
+	workers     int
+	client      *http.Client
+	politeness  crawler.Politeness // the limits of every job, overridden per host by the config's rate limits
+	wake        chan struct{}      // signalled when a job is queued, to wake a worker waiting for one
+
+	mu       sync.Mutex
+	queue    []*job // the jobs waiting to run, oldest first
+	jobs     map[string]*job
+	cancels  map[string]context.CancelFunc // cancels each running job
+	crawlers map[string]crawler.Crawler    // the crawler of each running job, to pause and resume it
+	ready    bool                          // set while jobs are being run, from start until the daemon stops
+	config   *config                       // applied to jobs as they start, nil if none was given
+}
+
+// daemonStatus is the daemon's load, served by /status
+type daemonStatus struct {
+	Ready       bool `json:"ready"`
+	Running     int  `json:"running"`
+	Paused      int  `json:"paused"`
+	Queued      int  `json:"queued"`
+	MaxQueued   int  `json:"max_queued"`
+	Concurrency int  `json:"concurrency"`
+	Jobs        int  `json:"jobs"`
+}
+
+// runServe implements the serve command, running the daemon until interrupted. It returns the exit code.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "address to serve the job API on")
+	dir := fs.String("dir", "jobs", "directory to store each job's state and output in")
+	watchDir := fs.String("watch", "", "directory to watch for JSON job files, in addition to the API")
+	concurrency := fs.Int("concurrency", 2, "number of jobs to run at once")
+	schedulesPath := fs.String("schedules", "", "JSON file of sites to crawl periodically, see README")
+	configPath := fs.String("config", "", "JSON config file of scope, extraction rules and rate limits for every job, reloaded on SIGHUP, see README")
+	if code, exit := parseFlags(fs, args); exit {
+		return code
+	}
+	if *concurrency < 1 {
+		fatal("-concurrency must be greater than zero", "value", *concurrency)
+	}
+	var schedules []*siteSchedule
+	if *schedulesPath != "" {
+		var err error
+		if schedules, err = loadSchedules(*schedulesPath); err != nil {
+			fatal("error loading schedules", "error", err.Error())
+		}
+	}
+
+	workers := getEnvInt("WORKERS")
+	if workers == 0 {
+		workers = defaultJobWorkers
+	}
+	d, err := newDaemon(*dir, *concurrency, workers, &http.Client{Timeout: time.Second * 2})
+	if err != nil {
+		fatal("error loading jobs", "dir", *dir, "error", err.Error())
+	}
+	d.politeness = envPoliteness(nil)
+	if *configPath != "" {
+		if err := d.loadConfig(*configPath); err != nil {
+			fatal("error loading config", "error", err.Error())
+		}
+		handleReloadSignal(func() {
+			if err := d.loadConfig(*configPath); err != nil {
+				slog.Error("error reloading config, keeping the previous one", "error", err.Error())
+				return
+			}
+			slog.Info("config reloaded, applying to jobs started from now on", "path", *configPath)
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: d,
+		// so that streams of output being followed end when the daemon stops, rather than holding up its shutdown
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+	if *watchDir != "" {
+		go d.watch(ctx, *watchDir, watchInterval)
+	}
+	if len(schedules) > 0 {
+		go d.schedule(ctx, schedules)
+	}
+	done := d.start(ctx)
+
+	slog.Info("serving job API", "addr", *addr, "dir", *dir)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		fatal("error serving job API", "addr", *addr, "error", err.Error())
+	}
+	<-done
+	return exitOK
+}
+
+// newDaemon returns a daemon storing jobs in dir, requeuing any which were queued or running when it last stopped
+func newDaemon(dir string, concurrency, workers int, client *http.Client) (*daemon, error) {
+	d := &daemon{
+		dir:         dir,
+		concurrency: concurrency,
+		workers:     workers,
+		client:      client,
+		wake:        make(chan struct{}, 1),
+		jobs:        map[string]*job{},
+		cancels:     map[string]context.CancelFunc{},
+		crawlers:    map[string]crawler.Crawler{},
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "job.json"))
+	if err != nil {
+		return nil, err
+	}
+	pending := []*job{}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		j := &job{}
+		if err := json.Unmarshal(b, j); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		d.jobs[j.ID] = j
+		if j.State == jobQueued || j.State == jobRunning || j.State == jobPaused {
+			j.State, j.Started, j.Progress = jobQueued, nil, nil
+			pending = append(pending, j)
+		}
+	}
+	sort.Slice(pending, func(a, b int) bool {
+		return pending[a].Submitted.Before(pending[b].Submitted)
+	})
+	for _, j := range pending {
+		if err := d.enqueue(j); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// loadConfig reads the config file at path and applies it to the jobs started from now on, leaving those running as
+// they are. An invalid config is not applied.
+func (d *daemon) loadConfig(path string) error {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.config = cfg
+	return nil
+}
+
+// start runs queued jobs until ctx is done, returning a channel closed once the jobs running have stopped
+func (d *daemon) start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < d.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j, ok := d.next(ctx)
+				if !ok {
+					return
+				}
+				d.run(ctx, j)
+			}
+		}()
+	}
+	d.mu.Lock()
+	d.ready = true
+	d.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		d.mu.Lock()
+		d.ready = false
+		d.mu.Unlock()
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// submit queues a new job, submitted by the named schedule if any
+func (d *daemon) submit(spec jobSpec, schedule string) (*job, error) {
+	if len(spec.Seeds) == 0 {
+		return nil, errNoJobSeeds
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	j := &job{
+		jobSpec:   spec,
+		ID:        hex.EncodeToString(id),
+		Schedule:  schedule,
+		State:     jobQueued,
+		Submitted: time.Now().UTC(),
+	}
+	if err := d.enqueue(j); err != nil {
+		return nil, err
+	}
+	slog.Info("job queued", "job", j.ID, "seeds", j.Seeds)
+	return j, nil
+}
+
+// enqueue saves a queued job and adds it to the queue
+func (d *daemon) enqueue(j *job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.queue) == maxQueuedJobs {
+		return errQueueFull
+	}
+	if err := d.save(j); err != nil {
+		return err
+	}
+	d.jobs[j.ID] = j
+	d.queue = append(d.queue, j)
+	d.signal()
+	return nil
+}
+
+// next waits for the oldest queued job and takes it off the queue, returning false if ctx is done first
+func (d *daemon) next(ctx context.Context) (*job, bool) {
+	for {
+		d.mu.Lock()
+		if len(d.queue) > 0 {
+			j := d.queue[0]
+			d.queue = d.queue[1:]
+			if len(d.queue) > 0 {
+				d.signal() // so that another worker takes the next one
+			}
+			d.mu.Unlock()
+			return j, true
+		}
+		d.mu.Unlock()
+
+		select {
+		case <-d.wake:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// signal wakes a worker waiting for a job, if any are
+func (d *daemon) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default: // a worker is already due to wake
+	}
+}
+
+// run runs a job, writing its output to its directory. A job interrupted by ctx is left queued, to run again when the
+// daemon restarts.
+func (d *daemon) run(ctx context.Context, j *job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	started := time.Now().UTC()
+	running := false
+	d.update(j, func() {
+		if j.State != jobQueued {
+			return // cancelled while queued
+		}
+		j.State, j.Started = jobRunning, &started
+		d.cancels[j.ID] = cancel
+		running = true
+	})
+	if !running {
+		return
+	}
+	logger := slog.With("job", j.ID)
+	logger.Info("job started")
+
+	err := d.crawl(jobCtx, j, logger)
+	if ctx.Err() != nil {
+		d.update(j, func() {
+			delete(d.cancels, j.ID)
+			j.State, j.Started, j.Progress = jobQueued, nil, nil
+		})
+		logger.Info("job interrupted")
+		return
+	}
+
+	finished := time.Now().UTC()
+	d.update(j, func() {
+		delete(d.cancels, j.ID)
+		j.State, j.Finished = jobDone, &finished
+		switch {
+		case jobCtx.Err() != nil:
+			j.State = jobCancelled
+		case err != nil:
+			j.State, j.Error = jobFailed, err.Error()
+		}
+	})
+	if j.State == jobCancelled {
+		logger.Info("job cancelled")
+		return
+	}
+	if err != nil {
+		logger.Warn("job failed", "error", err.Error())
+		return
+	}
+	logger.Info("job finished", "pages", j.Summary.Pages, "errors", j.Summary.Errors)
+}
+
+// crawl crawls a job's seeds, writing the pages to output.txt and the crawl's logs to log.txt in the job's directory
+func (d *daemon) crawl(ctx context.Context, j *job, logger *slog.Logger) error {
+	dir := filepath.Join(d.dir, j.ID)
+	out, err := os.Create(filepath.Join(dir, "output.txt"))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	logs, err := os.Create(filepath.Join(dir, "log.txt"))
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	workers := j.Workers
+	if workers == 0 {
+		workers = d.workers
+	}
+	d.mu.Lock()
+	cfg := d.config
+	d.mu.Unlock()
+	politeness := d.politeness
+	opts := []crawler.Option{}
+	if cfg != nil {
+		// applied first so that the job's own settings take precedence
+		opts = append(opts, cfg.options()...)
+		politeness.Hosts = cfg.hostLimits()
+	}
+	if politeness.Delay > 0 || politeness.MaxConcurrent > 0 || len(politeness.Hosts) > 0 {
+		opts = append(opts, crawler.WithPoliteness(politeness))
+	}
+
+	summary := &crawler.Summary{}
+	opts = append(opts,
+		crawler.WithSummary(summary),
+		crawler.WithTopPages(defaultTopPages),
+		crawler.WithLogger(slog.New(slog.NewTextHandler(logs, nil))),
+		crawler.WithProgress(jobProgressInterval, func(p crawler.Progress) {
+			d.update(j, func() {
+				j.Progress = newJobProgress(p)
+			})
+		}),
+	)
+	if j.MaxPages > 0 {
+		opts = append(opts, crawler.WithMaxPages(j.MaxPages))
+	}
+	c := crawler.New(workers, d.client, opts...)
+	d.mu.Lock()
+	d.crawlers[j.ID] = c
+	if j.State == jobPaused {
+		c.Pause() // paused before the crawler was created
+	}
+	d.mu.Unlock()
+	err = c.CrawlAll(ctx, j.Seeds, out)
+	d.update(j, func() {
+		delete(d.crawlers, j.ID)
+		j.Summary = summary
+	})
+	return err
+}
+
+// update changes a job while holding the lock, then saves it. A job which can't be saved is still updated in memory.
+func (d *daemon) update(j *job, change func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	change()
+	if err := d.save(j); err != nil {
+		slog.Error("error saving job", "job", j.ID, "error", err.Error())
+	}
+}
+
+// cancel cancels a job, stopping it if it's running or removing it from the queue if it isn't
+func (d *daemon) cancel(j *job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch j.State {
+	case jobQueued:
+		for i, queued := range d.queue {
+			if queued == j {
+				d.queue = append(d.queue[:i:i], d.queue[i+1:]...)
+				break
+			}
+		}
+		finished := time.Now().UTC()
+		j.State, j.Finished = jobCancelled, &finished
+		return d.save(j)
+	case jobRunning, jobPaused:
+		d.cancels[j.ID]()
+		return nil // run records the job as cancelled once it has stopped
+	default:
+		return errJobFinished
+	}
+}
+
+// pause stops a running job starting new fetches until it's resumed
+func (d *daemon) pause(j *job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if j.State != jobRunning {
+		return errJobNotRunning
+	}
+	if c, ok := d.crawlers[j.ID]; ok {
+		c.Pause()
+	} // else crawl pauses the crawler once it's created
+	j.State = jobPaused
+	return d.save(j)
+}
+
+// resume lets a paused job start fetching again
+func (d *daemon) resume(j *job) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if j.State != jobPaused {
+		return errJobNotPaused
+	}
+	if c, ok := d.crawlers[j.ID]; ok {
+		c.Resume()
+	}
+	j.State = jobRunning
+	return d.save(j)
+}
+
+// save writes a job to job.json in its directory, via a temporary file so that it's never left half written. The
+// caller must hold the lock.
+func (d *daemon) save(j *job) error {
+	dir := filepath.Join(d.dir, j.ID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, "job.json.tmp")
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, "job.json"))
+}
+
+// watch submits a job for each JSON file added to dir until ctx is done, renaming each file once it's been submitted,
+// or marking it invalid
+func (d *daemon) watch(ctx context.Context, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			slog.Error("error watching for jobs", "dir", dir, "error", err.Error())
+		}
+		for _, path := range paths {
+			d.submitFile(path)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// submitFile submits the job in a file of the watched directory
+func (d *daemon) submitFile(path string) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("error reading job file", "path", path, "error", err.Error())
+		return
+	}
+	spec := jobSpec{}
+	if err := json.Unmarshal(b, &spec); err != nil {
+		slog.Warn("invalid job file", "path", path, "error", err.Error())
+		os.Rename(path, path+invalidExtension)
+		return
+	}
+	j, err := d.submit(spec, "")
+	if err == errQueueFull {
+		return // try again once the queue has room
+	}
+	if err != nil {
+		slog.Warn("invalid job file", "path", path, "error", err.Error())
+		os.Rename(path, path+invalidExtension)
+		return
+	}
+	if err := os.Rename(path, path+submittedExtension); err != nil {
+		slog.Error("error renaming submitted job file, it may be submitted again", "path", path, "job", j.ID, "error", err.Error())
+	}
+}
+
+// ServeHTTP serves the job API:
+//
+//	POST   /jobs              submit a job, e.g. {"seeds": ["http://monzo.com"], "workers": 10, "max_pages": 1000}
+//	GET    /jobs              list the jobs, most recently submitted first, optionally only those in ?state=running
+//	GET    /jobs/{id}         get a job's state, progress while it runs and summary once it's finished
+//	DELETE /jobs/{id}         cancel a job, stopping it if it's running
+//	GET    /jobs/{id}/output  get a job's output so far, or with ?follow=true stream it until the job finishes
+//	GET    /jobs/{id}/log     get a job's log
+//	POST   /jobs/{id}/pause   stop a running job starting new fetches, without losing its progress
+//	POST   /jobs/{id}/resume  let a paused job fetch again
+//	GET    /                  a dashboard of the jobs, see serveDashboard
+//	GET    /healthz           200 OK while the daemon is serving, for liveness probes
+//	GET    /readyz            200 OK while it's running jobs and has room to queue more, else 503, for readiness probes
+//	GET    /status            the jobs running and queued, see daemonStatus
+func (d *daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		d.serveDashboard(w, r)
+		return
+	case "/healthz":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "ok\n")
+		return
+	case "/readyz":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if status := d.status(); !status.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, "not ready\n")
+			return
+		}
+		io.WriteString(w, "ok\n")
+		return
+	case "/status":
+		d.writeJSON(w, http.StatusOK, d.status())
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "jobs" || len(parts) > 3 {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 1 {
+		d.serveJobs(w, r)
+		return
+	}
+
+	d.mu.Lock()
+	j, ok := d.jobs[parts[1]]
+	d.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		d.writeJSON(w, http.StatusOK, j)
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		if err := d.cancel(j); err == errJobFinished {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		d.writeJSON(w, http.StatusAccepted, j)
+	case len(parts) == 2:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	case (parts[2] == "pause" || parts[2] == "resume") && r.Method == http.MethodPost:
+		change := d.pause
+		if parts[2] == "resume" {
+			change = d.resume
+		}
+		if err := change(j); err == errJobNotRunning || err == errJobNotPaused {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		d.writeJSON(w, http.StatusOK, j)
+	case r.Method != http.MethodGet:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	case parts[2] == "output" && r.URL.Query().Get("follow") == "true":
+		d.followOutput(w, r, j)
+	case parts[2] == "output":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeFile(w, r, filepath.Join(d.dir, j.ID, "output.txt"))
+	case parts[2] == "log":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		http.ServeFile(w, r, filepath.Join(d.dir, j.ID, "log.txt"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// status returns the daemon's load. It's ready while it's running jobs and its queue isn't full, so that a load balancer
+// stops sending it jobs which would be rejected.
+func (d *daemon) status() daemonStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := daemonStatus{
+		MaxQueued:   maxQueuedJobs,
+		Concurrency: d.concurrency,
+		Jobs:        len(d.jobs),
+	}
+	for _, j := range d.jobs {
+		switch j.State {
+		case jobRunning:
+			status.Running++
+		case jobPaused:
+			status.Paused++
+		case jobQueued:
+			status.Queued++
+		}
+	}
+	status.Ready = d.ready && len(d.queue) < maxQueuedJobs
+	return status
+}
+
+// serveJobs serves submissions and listings of jobs
+func (d *daemon) serveJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		spec := jobSpec{}
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxJobSpecSize)).Decode(&spec); err != nil {
+			http.Error(w, "invalid job: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		j, err := d.submit(spec, "")
+		switch {
+		case err == errQueueFull:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		case err == errNoJobSeeds:
+			http.Error(w, "invalid job: "+err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+j.ID)
+		d.writeJSON(w, http.StatusAccepted, j)
+	case http.MethodGet:
+		d.writeJSON(w, http.StatusOK, d.list(r.URL.Query().Get("state")))
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// list returns the jobs in a state, or every job if state is empty, most recently submitted first
+func (d *daemon) list(state string) []*job {
+	d.mu.Lock()
+	jobs := make([]*job, 0, len(d.jobs))
+	for _, j := range d.jobs {
+		if state == "" || j.State == state {
+			jobs = append(jobs, j)
+		}
+	}
+	d.mu.Unlock()
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].Submitted.After(jobs[b].Submitted)
+	})
+	return jobs
+}
+
+// followOutput streams a job's output as it's written, until the job has finished and all of it has been sent or the
+// client goes away. The output of a job still queued is streamed once it starts.
+func (d *daemon) followOutput(w http.ResponseWriter, r *http.Request, j *job) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff") // or browsers buffer the stream to sniff it
+	flusher, _ := w.(http.Flusher)
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	var f *os.File
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	for {
+		// checked before reading, so that whatever was written before the job finished is read after
+		d.mu.Lock()
+		finished := j.finished()
+		d.mu.Unlock()
+
+		if f == nil {
+			f, _ = os.Open(filepath.Join(d.dir, j.ID, "output.txt"))
+		}
+		if f != nil {
+			if _, err := io.Copy(w, f); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if finished {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeJSON writes v as the JSON body of a response, holding the lock so that jobs aren't changed while written
+func (d *daemon) writeJSON(w http.ResponseWriter, status int, v any) {
+	d.mu.Lock()
+	b, err := json.MarshalIndent(v, "", "  ")
+	d.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+}
@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemon(t *testing.T) {
+	site := crawltest.NewServer(crawltest.Site{
+		"/":      {Title: "Home", Links: []string{"/about"}},
+		"/about": {Title: "About"},
+	})
+	defer site.Close()
+
+	dir := t.TempDir()
+	d, err := newDaemon(dir, 2, 1, site.Client())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := d.start(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	api := httptest.NewServer(d)
+	defer api.Close()
+
+	getJob := func(t *testing.T, id string) *job {
+		resp, err := http.Get(api.URL + "/jobs/" + id)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		j := &job{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(j))
+		return j
+	}
+	waitFor := func(t *testing.T, id string) *job {
+		var j *job
+		require.Eventually(t, func() bool {
+			j = getJob(t, id)
+			return j.State == jobDone || j.State == jobFailed
+		}, 5*time.Second, 10*time.Millisecond)
+		return j
+	}
+
+	t.Run("api", func(t *testing.T) {
+		resp, err := http.Post(api.URL+"/jobs", "application/json", strings.NewReader(`{"seeds": ["`+site.URLFor("/")+`"]}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		submitted := &job{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(submitted))
+		require.Equal(t, "/jobs/"+submitted.ID, resp.Header.Get("Location"))
+
+		j := waitFor(t, submitted.ID)
+		require.Equal(t, jobDone, j.State)
+		require.Equal(t, 2, j.Summary.Pages)
+
+		resp, err = http.Get(api.URL + "/jobs/" + j.ID + "/output")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		output, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(output), site.URLFor("/about"))
+
+		saved, err := os.ReadFile(filepath.Join(dir, j.ID, "job.json"))
+		require.NoError(t, err)
+		require.Contains(t, string(saved), `"state": "done"`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, body := range []string{`{"seeds": []}`, `not json`} {
+			resp, err := http.Post(api.URL+"/jobs", "application/json", strings.NewReader(body))
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+		resp, err := http.Get(api.URL + "/jobs/missing")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("watched directory", func(t *testing.T) {
+		watchDir := t.TempDir()
+		spec := filepath.Join(watchDir, "monzo.json")
+		require.NoError(t, os.WriteFile(spec, []byte(`{"seeds": ["`+site.URLFor("/about")+`"], "max_pages": 1}`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(watchDir, "broken.json"), []byte(`{`), 0o644))
+		go d.watch(ctx, watchDir, 10*time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			_, err := os.Stat(spec + submittedExtension)
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		require.FileExists(t, filepath.Join(watchDir, "broken.json"+invalidExtension))
+
+		resp, err := http.Get(api.URL + "/jobs")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		jobs := []*job{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&jobs))
+		require.Len(t, jobs, 2)
+		require.Equal(t, []string{site.URLFor("/about")}, jobs[0].Seeds, "most recent first")
+
+		j := waitFor(t, jobs[0].ID)
+		require.Equal(t, 1, j.Summary.Pages)
+	})
+}
+
+func TestDaemonJobControl(t *testing.T) {
+	site := crawltest.NewServer(crawltest.Site{
+		"/":      {Links: []string{"/slow"}},
+		"/slow":  {Latency: 300 * time.Millisecond, Links: []string{"/stuck"}},
+		"/stuck": {Latency: time.Minute},
+	})
+	defer site.Close()
+
+	d, err := newDaemon(t.TempDir(), 1, 1, site.Client())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := d.start(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	api := httptest.NewServer(d)
+	defer api.Close()
+
+	running, err := d.submit(jobSpec{Seeds: []string{site.URLFor("/")}}, "")
+	require.NoError(t, err)
+	queued, err := d.submit(jobSpec{Seeds: []string{site.URLFor("/")}}, "")
+	require.NoError(t, err)
+
+	del := func(t *testing.T, id string) int {
+		req, err := http.NewRequest(http.MethodDelete, api.URL+"/jobs/"+id, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	state := func(j *job) string {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return j.State
+	}
+
+	t.Run("cancel queued", func(t *testing.T) {
+		require.Equal(t, http.StatusAccepted, del(t, queued.ID))
+		require.Equal(t, jobCancelled, state(queued))
+		require.Equal(t, http.StatusConflict, del(t, queued.ID))
+	})
+
+	t.Run("list by state", func(t *testing.T) {
+		resp, err := http.Get(api.URL + "/jobs?state=cancelled")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		jobs := []*job{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&jobs))
+		require.Len(t, jobs, 1)
+		require.Equal(t, queued.ID, jobs[0].ID)
+	})
+
+	t.Run("follow and cancel running", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return state(running) == jobRunning
+		}, 5*time.Second, 10*time.Millisecond)
+
+		resp, err := http.Get(api.URL + "/jobs/" + running.ID + "/output?follow=true")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		go func() {
+			// once the job is stuck fetching the last page
+			for site.Requests("/stuck") == 0 {
+				time.Sleep(10 * time.Millisecond)
+			}
+			d.mu.Lock()
+			j := d.jobs[running.ID]
+			d.mu.Unlock()
+			d.cancel(j)
+		}()
+		output, err := io.ReadAll(resp.Body) // ends once the job is cancelled
+		require.NoError(t, err)
+		require.Contains(t, string(output), site.URLFor("/"))
+		require.Contains(t, string(output), site.URLFor("/slow"))
+		require.Equal(t, jobCancelled, state(running))
+	})
+}
+
+func TestDaemonRequeuesJobs(t *testing.T) {
+	dir := t.TempDir()
+	for id, state := range map[string]string{"a": jobRunning, "b": jobDone} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, id), 0o755))
+		b, err := json.Marshal(&job{ID: id, State: state, jobSpec: jobSpec{Seeds: []string{"http://monzo.com"}}})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, id, "job.json"), b, 0o644))
+	}
+
+	d, err := newDaemon(dir, 1, 1, http.DefaultClient)
+	require.NoError(t, err)
+	require.Len(t, d.jobs, 2)
+	require.Len(t, d.queue, 1)
+	require.Equal(t, "a", d.queue[0].ID)
+	require.Equal(t, jobQueued, d.jobs["a"].State)
+}
+
+func TestDaemonQueueFull(t *testing.T) {
+	d, err := newDaemon(t.TempDir(), 1, 1, http.DefaultClient)
+	require.NoError(t, err)
+
+	jobs := []*job{}
+	for i := 0; i < maxQueuedJobs; i++ {
+		j, err := d.submit(jobSpec{Seeds: []string{"http://monzo.com"}}, "")
+		require.NoError(t, err)
+		jobs = append(jobs, j)
+	}
+	_, err = d.submit(jobSpec{Seeds: []string{"http://monzo.com"}}, "")
+	require.Equal(t, errQueueFull, err)
+
+	require.NoError(t, d.cancel(jobs[0]))
+	j, err := d.submit(jobSpec{Seeds: []string{"http://monzo.com"}}, "")
+	require.NoError(t, err, "a job cancelled while queued frees its place")
+	require.Len(t, d.queue, maxQueuedJobs)
+	require.Equal(t, jobs[1], d.queue[0])
+	require.Equal(t, j, d.queue[maxQueuedJobs-1])
+}
+
+func TestDaemonHealth(t *testing.T) {
+	site := crawltest.NewServer(crawltest.Site{
+		"/": {Latency: time.Minute},
+	})
+	defer site.Close()
+
+	d, err := newDaemon(t.TempDir(), 1, 1, site.Client())
+	require.NoError(t, err)
+	api := httptest.NewServer(d)
+	defer api.Close()
+
+	get := func(t *testing.T, path string) int {
+		resp, err := http.Get(api.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	status := func(t *testing.T) daemonStatus {
+		resp, err := http.Get(api.URL + "/status")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		status := daemonStatus{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		return status
+	}
+
+	require.Equal(t, http.StatusOK, get(t, "/healthz"))
+	require.Equal(t, http.StatusServiceUnavailable, get(t, "/readyz"), "not ready until jobs are run")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := d.start(ctx)
+	require.Equal(t, http.StatusOK, get(t, "/readyz"))
+
+	for i := 0; i < 2; i++ {
+		_, err := d.submit(jobSpec{Seeds: []string{site.URLFor("/")}}, "")
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		return status(t).Running == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, daemonStatus{Ready: true, Running: 1, Queued: 1, MaxQueued: maxQueuedJobs, Concurrency: 1, Jobs: 2}, status(t))
+
+	cancel()
+	<-done
+	require.Equal(t, http.StatusServiceUnavailable, get(t, "/readyz"))
+	require.Equal(t, http.StatusOK, get(t, "/healthz"))
+}
+
+func TestDaemonPause(t *testing.T) {
+	site := crawltest.NewServer(crawltest.Site{
+		"/":      {Latency: 100 * time.Millisecond, Links: []string{"/about"}},
+		"/about": {},
+	})
+	defer site.Close()
+
+	d, err := newDaemon(t.TempDir(), 1, 1, site.Client())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := d.start(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	api := httptest.NewServer(d)
+	defer api.Close()
+
+	post := func(t *testing.T, id, action string) (int, *job) {
+		resp, err := http.Post(api.URL+"/jobs/"+id+"/"+action, "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		j := &job{}
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(j))
+		}
+		return resp.StatusCode, j
+	}
+	state := func(j *job) string {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return j.State
+	}
+
+	j, err := d.submit(jobSpec{Seeds: []string{site.URLFor("/")}}, "")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return state(j) == jobRunning
+	}, 5*time.Second, time.Millisecond)
+
+	status, paused := post(t, j.ID, "pause")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, jobPaused, paused.State)
+	status, _ = post(t, j.ID, "pause")
+	require.Equal(t, http.StatusConflict, status)
+
+	time.Sleep(300 * time.Millisecond) // long enough to have fetched both pages if it weren't paused
+	require.Equal(t, 0, site.Requests("/about"))
+	require.Equal(t, jobPaused, state(j))
+	require.Equal(t, 1, d.status().Paused)
+
+	status, resumed := post(t, j.ID, "resume")
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, jobRunning, resumed.State)
+	require.Eventually(t, func() bool {
+		return state(j) == jobDone
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, site.Requests("/about"))
+
+	status, _ = post(t, j.ID, "resume")
+	require.Equal(t, http.StatusConflict, status)
+}
+
+func TestDaemonReloadConfig(t *testing.T) {
+	site := crawltest.NewServer(crawltest.Site{
+		"/blog/":     {Latency: 200 * time.Millisecond, Links: []string{"/blog/post", "/about"}},
+		"/blog/post": {},
+		"/about":     {},
+	})
+	defer site.Close()
+
+	d, err := newDaemon(t.TempDir(), 2, 1, site.Client())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := d.start(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	wait := func(t *testing.T, j *job) *job {
+		require.Eventually(t, func() bool {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			return j.finished()
+		}, 5*time.Second, 10*time.Millisecond)
+		return j
+	}
+
+	path := writeConfig(t, `{}`)
+	require.NoError(t, d.loadConfig(path))
+	running, err := d.submit(jobSpec{Seeds: []string{site.URLFor("/blog/")}}, "")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return site.Requests("/blog/") == 1
+	}, 5*time.Second, time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"scope": {"policy": "path"}, "rate_limits": {"127.0.0.1": {"concurrency": 1}}}`), 0o644))
+	require.NoError(t, d.loadConfig(path))
+	require.NoError(t, os.WriteFile(path, []byte(`{"scope": {"policy": "site"}}`), 0o644))
+	require.Error(t, d.loadConfig(path), "an invalid config shouldn't replace the previous one")
+
+	started, err := d.submit(jobSpec{Seeds: []string{site.URLFor("/blog/")}}, "")
+	require.NoError(t, err)
+
+	require.Equal(t, 3, wait(t, running).Summary.Pages, "jobs running shouldn't be changed by a reload")
+	require.Equal(t, 2, wait(t, started).Summary.Pages, "jobs started after a reload should use its scope")
+}
+
+func TestDaemonDashboard(t *testing.T) {
+	site := crawltest.NewServer(crawltest.Site{
+		"/": {Links: []string{"/missing"}},
+	})
+	defer site.Close()
+
+	d, err := newDaemon(t.TempDir(), 1, 1, site.Client())
+	require.NoError(t, err)
+	api := httptest.NewServer(d)
+	defer api.Close()
+	dashboard := func(t *testing.T) string {
+		resp, err := http.Get(api.URL + "/")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	require.Contains(t, dashboard(t), "No jobs have been submitted.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := d.start(ctx)
+	defer func() {
+		cancel()
+		<-done
+	}()
+	j, err := d.submit(jobSpec{Seeds: []string{site.URLFor("/") + "?<script>"}}, "")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return j.finished()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	html := dashboard(t)
+	require.Contains(t, html, "Ready: 0 running, 0 paused and 0 queued\nof 1 jobs")
+	require.Contains(t, html, `<a href="/jobs/`+j.ID+`"><code>`+j.ID+`</code></a>`)
+	require.Contains(t, html, `<td class="done">done</td>`)
+	require.Contains(t, html, "?&lt;script&gt;", "seeds should be escaped")
+	require.Contains(t, html, "<li>http_status: 1</li>")
+	require.Contains(t, html, `<a href="/jobs/`+j.ID+`/output">output</a> <a href="/jobs/`+j.ID+`/log">log</a>`)
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	_ "expvar" // registers /debug/vars
+	"net/http"
+	_ "net/http/pprof" // registers /debug/pprof/
+)
+
+// serveDebug serves pprof profiles and expvar runtime stats on addr for the life of the process
+func serveDebug(addr string) {
+	go func() {
+		if err := http.ListenAndServe(addr, nil); err != nil {
+			fatal("error serving debug endpoints", "addr", addr, "error", err.Error())
+		}
+	}()
+}
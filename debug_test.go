@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeDebug(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	serveDebug(addr)
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + addr + "/debug/vars")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "the debug endpoints are served on addr")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	vars := map[string]json.RawMessage{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&vars))
+	require.Contains(t, vars, "memstats", "expvar serves the runtime stats")
+
+	resp, err = http.Get("http://" + addr + "/debug/pprof/goroutine?debug=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "pprof serves profiles")
+}
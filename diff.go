@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// crawlDiff describes the differences between two crawls' outputs, each list being sorted by URL
+type crawlDiff struct {
+	Added          []*crawler.Page
+	Removed        []*crawler.Page
+	StatusChanged  [][2]*crawler.Page // the old and new page
+	ContentChanged []*crawler.Page    // the new page, for pages whose content hash changed
+	BrokenLinks    []brokenLink
+}
+
+// brokenLink is a link in the new crawl to a page in the old crawl which the new crawl couldn't output
+type brokenLink struct {
+	URL      string
+	Referrer string
+}
+
+// runDiff implements the diff command, comparing the crawl outputs at the two paths in args and writing their
+// differences to w. It returns the exit code.
+func runDiff(args []string, w io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: web_crawler diff OLD NEW")
+		return exitConfig
+	}
+
+	before, err := readPagesFile(args[0])
+	if err != nil {
+		fatal("error reading crawl output", "path", args[0], "error", err.Error())
+	}
+	after, err := readPagesFile(args[1])
+	if err != nil {
+		fatal("error reading crawl output", "path", args[1], "error", err.Error())
+	}
+
+	diff := diffPages(before, after)
+	if _, err := w.Write(diff.Marshal()); err != nil {
+		return exitCrawlFailed
+	}
+	if len(diff.BrokenLinks) > 0 {
+		return exitHTTPErrors
+	}
+	return exitOK
+}
+
+func readPagesFile(path string) ([]*crawler.Page, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return crawler.ReadPages(f)
+}
+
+// diffPages compares the pages output by an old and new crawl
+func diffPages(before, after []*crawler.Page) *crawlDiff {
+	oldPages, newPages := pagesByURL(before), pagesByURL(after)
+	diff := &crawlDiff{}
+
+	for _, u := range sortedURLs(newPages) {
+		newPage := newPages[u]
+		oldPage, ok := oldPages[u]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, newPage)
+		case oldPage.StatusCode != newPage.StatusCode:
+			diff.StatusChanged = append(diff.StatusChanged, [2]*crawler.Page{oldPage, newPage})
+		case oldPage.ContentHash != newPage.ContentHash:
+			diff.ContentChanged = append(diff.ContentChanged, newPage)
+		}
+	}
+
+	for _, u := range sortedURLs(oldPages) {
+		if _, ok := newPages[u]; !ok {
+			diff.Removed = append(diff.Removed, oldPages[u])
+		}
+	}
+
+	// only pages which responded successfully or with a redirect are output, so a page from the old crawl which is
+	// still linked to but missing from the new one most likely now returns an error
+	seen := map[brokenLink]struct{}{}
+	for _, u := range sortedURLs(newPages) {
+		for _, link := range newPages[u].Links {
+			target := link.String()
+			if _, ok := newPages[target]; ok {
+				continue
+			}
+			if _, ok := oldPages[target]; !ok {
+				continue
+			}
+			broken := brokenLink{URL: target, Referrer: u}
+			if _, ok := seen[broken]; !ok {
+				seen[broken] = struct{}{}
+				diff.BrokenLinks = append(diff.BrokenLinks, broken)
+			}
+		}
+	}
+
+	return diff
+}
+
+func pagesByURL(pages []*crawler.Page) map[string]*crawler.Page {
+	byURL := make(map[string]*crawler.Page, len(pages))
+	for _, page := range pages {
+		byURL[page.URL.String()] = page
+	}
+	return byURL
+}
+
+func sortedURLs(pages map[string]*crawler.Page) []string {
+	urls := make([]string, 0, len(pages))
+	for u := range pages {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// Marshal formats the diff in the same style as the crawl's output, omitting empty sections
+func (d *crawlDiff) Marshal() []byte {
+	out := []byte{}
+	if len(d.Added) > 0 {
+		out = append(out, []byte("Added:\n")...)
+		for _, page := range d.Added {
+			out = append(out, []byte(fmt.Sprintf("\t%s %d\n", page.URL, page.StatusCode))...)
+		}
+	}
+	if len(d.Removed) > 0 {
+		out = append(out, []byte("Removed:\n")...)
+		for _, page := range d.Removed {
+			out = append(out, []byte(fmt.Sprintf("\t%s %d\n", page.URL, page.StatusCode))...)
+		}
+	}
+	if len(d.StatusChanged) > 0 {
+		out = append(out, []byte("StatusChanged:\n")...)
+		for _, pages := range d.StatusChanged {
+			out = append(out, []byte(fmt.Sprintf("\t%s %d -> %d\n", pages[1].URL, pages[0].StatusCode, pages[1].StatusCode))...)
+		}
+	}
+	if len(d.ContentChanged) > 0 {
+		out = append(out, []byte("ContentChanged:\n")...)
+		for _, page := range d.ContentChanged {
+			out = append(out, []byte("\t"+page.URL.String()+"\n")...)
+		}
+	}
+	if len(d.BrokenLinks) > 0 {
+		out = append(out, []byte("BrokenLinks:\n")...)
+		for _, link := range d.BrokenLinks {
+			out = append(out, []byte("\t"+link.URL+" linked from "+link.Referrer+"\n")...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestPages(t *testing.T, output string) []*crawler.Page {
+	pages, err := crawler.ReadPages(strings.NewReader(output))
+	require.NoError(t, err)
+	return pages
+}
+
+func TestDiffPages(t *testing.T) {
+	before := readTestPages(t, `URL:
+	http://monzo.com/
+Status:
+	200
+ContentHash:
+	a
+Links: 
+	http://monzo.com/about
+	http://monzo.com/blog
+	http://monzo.com/old
+URL:
+	http://monzo.com/about
+Status:
+	200
+ContentHash:
+	b
+Links: 
+URL:
+	http://monzo.com/blog
+Status:
+	200
+ContentHash:
+	c
+Links: 
+URL:
+	http://monzo.com/old
+Status:
+	200
+ContentHash:
+	d
+Links: 
+`)
+	after := readTestPages(t, `URL:
+	http://monzo.com/
+Status:
+	200
+ContentHash:
+	a
+Links: 
+	http://monzo.com/about
+	http://monzo.com/blog
+	http://monzo.com/careers
+URL:
+	http://monzo.com/about
+Status:
+	200
+ContentHash:
+	changed
+Links: 
+	http://monzo.com/blog
+URL:
+	http://monzo.com/careers
+Status:
+	301
+ContentHash:
+	e
+Links: 
+`)
+
+	require.Equal(t, `Added:
+	http://monzo.com/careers 301
+Removed:
+	http://monzo.com/blog 200
+	http://monzo.com/old 200
+ContentChanged:
+	http://monzo.com/about
+BrokenLinks:
+	http://monzo.com/blog linked from http://monzo.com/
+	http://monzo.com/blog linked from http://monzo.com/about
+`, string(diffPages(before, after).Marshal()))
+}
+
+func TestDiffPagesStatusChanged(t *testing.T) {
+	before := readTestPages(t, "URL:\n\thttp://monzo.com/\nStatus:\n\t200\nContentHash:\n\ta\n")
+	after := readTestPages(t, "URL:\n\thttp://monzo.com/\nStatus:\n\t301\nContentHash:\n\tb\n")
+
+	require.Equal(t, "StatusChanged:\n\thttp://monzo.com/ 200 -> 301\n", string(diffPages(before, after).Marshal()))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// writeGitHubAnnotations writes a GitHub Actions workflow command for each finding of a crawl, so that they're shown
+// as annotations on the run and any pull request it's for: an error for each broken link and script or stylesheet not
+// matching its integrity attribute, and a warning for each other error, mixed content asset, insecure form, script or
+// stylesheet from another origin without an integrity attribute, insecure cookie, crawl trap and budget which left
+// links uncrawled
+func writeGitHubAnnotations(w io.Writer, r *crawler.Report) error {
+	var b strings.Builder
+
+	for _, record := range r.Errors {
+		command, title := "warning", "Crawl error"
+		message := record.URL + ": " + record.Error
+		switch record.Class {
+		case crawler.ErrorClassHTTPStatus:
+			command, title = "error", "Broken link"
+			message = fmt.Sprintf("%s returned status code %d", record.URL, record.StatusCode)
+		case crawler.ErrorClassSoft404, crawler.ErrorClassFragment:
+			command, title = "error", "Broken link"
+			message = record.Error
+		}
+		if record.Referrer != "" {
+			message += ", linked from " + record.Referrer
+		}
+		writeWorkflowCommand(&b, command, title, message)
+	}
+	for _, record := range r.MixedContent() {
+		writeWorkflowCommand(&b, "warning", "Mixed content", fmt.Sprintf("%s loads %s %s over http", record.Page, record.Element, record.URL))
+	}
+	for _, record := range r.InsecureForms() {
+		writeWorkflowCommand(&b, "warning", "Insecure form", insecureFormMessage(record))
+	}
+	for _, usage := range r.Subresources() {
+		switch usage.Status {
+		case crawler.SRIMismatch:
+			writeWorkflowCommand(&b, "error", "Subresource integrity", fmt.Sprintf("%s %s doesn't match its integrity attribute, so browsers won't load it, first seen on %s", usage.Element, usage.URL, usage.FirstSeen))
+		case crawler.SRIMissing:
+			writeWorkflowCommand(&b, "warning", "Subresource integrity", fmt.Sprintf("%s %s has no integrity attribute, first seen on %s", usage.Element, usage.URL, usage.FirstSeen))
+		}
+	}
+	for _, record := range r.CookieIssues() {
+		message := fmt.Sprintf("%s sets cookie %s without %s, first seen on %s", record.Host, record.Name, strings.Join(record.Missing, ", "), record.FirstSeen)
+		writeWorkflowCommand(&b, "warning", "Insecure cookie", message)
+	}
+	for _, trap := range r.Summary.Traps {
+		writeWorkflowCommand(&b, "warning", "Crawl trap", "stopped crawling URLs matching "+trap)
+	}
+	if r.Summary.Limited > 0 {
+		writeWorkflowCommand(&b, "warning", "Crawl limited", fmt.Sprintf("%d links weren't crawled as MAX_PAGES or PATTERN_BUDGETS was reached", r.Summary.Limited))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// insecureFormMessage describes why a form is insecure
+func insecureFormMessage(record crawler.InsecureFormRecord) string {
+	if record.Reason == crawler.InsecureFormHTTP {
+		return fmt.Sprintf("%s has a form submitting (%s) over http to %s", record.Page, record.Method, record.Action)
+	}
+	return fmt.Sprintf("%s has a form submitting (%s) to another origin, %s", record.Page, record.Method, record.Action)
+}
+
+// writeWorkflowCommand writes a workflow command, escaping its title and message
+func writeWorkflowCommand(b *strings.Builder, command, title, message string) {
+	properties := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	fmt.Fprintf(b, "::%s title=%s::%s\n", command, properties.Replace(title), data.Replace(message))
+}
+
+// appendGitHubStepSummary appends a Markdown report of a crawl to the job summary GitHub Actions shows for the step,
+// doing nothing when not run by GitHub Actions
+func appendGitHubStepSummary(r *crawler.Report) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := writeMarkdownReport(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	report := &crawler.Report{
+		Summary: crawler.Summary{Limited: 3, Traps: []string{"monzo.com/calendar/{n}"}},
+		Pages: []crawler.PageRecord{
+			{URL: "https://monzo.com/", Subresources: []crawler.SubresourceRecord{
+				{Element: "script", URL: "https://cdn.example.com/app.js", Integrity: "sha384-abc", Status: crawler.SRIMismatch},
+				{Element: "link", URL: "https://cdn.example.com/style.css", Status: crawler.SRIMissing},
+				{Element: "script", URL: "https://cdn.example.com/ok.js", Integrity: "sha384-def", Status: crawler.SRIValid},
+			}, MixedContent: []crawler.MixedContentRecord{{Page: "https://monzo.com/", Element: "img", URL: "http://monzo.com/logo.png"}}},
+			{URL: "https://monzo.com/login", Cookies: []crawler.Cookie{{Name: "session", Secure: true}}, InsecureForms: []crawler.InsecureFormRecord{
+				{Page: "https://monzo.com/login", Action: "http://monzo.com/login", Method: "POST", Reason: crawler.InsecureFormHTTP},
+				{Page: "https://monzo.com/login", Action: "https://example.com/", Method: "GET", Reason: crawler.InsecureFormCrossOrigin},
+			}},
+		},
+		Errors: []crawler.ErrorRecord{
+			{URL: "http://monzo.com/missing", Referrer: "http://monzo.com/", StatusCode: 404, Class: crawler.ErrorClassHTTPStatus},
+			{URL: "http://monzo.com/slow", Class: crawler.ErrorClassTimeout, Error: "timeout\n100%"},
+			{URL: "http://monzo.com/gone", StatusCode: 200, Class: crawler.ErrorClassSoft404, Error: "http://monzo.com/gone looks like an error page, body of 0 bytes: soft 404"},
+			{URL: "http://monzo.com/docs#setup", Referrer: "http://monzo.com/", StatusCode: 200, Class: crawler.ErrorClassFragment, Error: `http://monzo.com/docs has no element with the id or name "setup": broken fragment`},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeGitHubAnnotations(out, report))
+	require.Equal(t, `::error title=Broken link::http://monzo.com/missing returned status code 404, linked from http://monzo.com/
+::warning title=Crawl error::http://monzo.com/slow: timeout%0A100%25
+::error title=Broken link::http://monzo.com/gone looks like an error page, body of 0 bytes: soft 404
+::error title=Broken link::http://monzo.com/docs has no element with the id or name "setup": broken fragment, linked from http://monzo.com/
+::warning title=Mixed content::https://monzo.com/ loads img http://monzo.com/logo.png over http
+::warning title=Insecure form::https://monzo.com/login has a form submitting (POST) over http to http://monzo.com/login
+::warning title=Insecure form::https://monzo.com/login has a form submitting (GET) to another origin, https://example.com/
+::error title=Subresource integrity::script https://cdn.example.com/app.js doesn't match its integrity attribute, so browsers won't load it, first seen on https://monzo.com/
+::warning title=Subresource integrity::link https://cdn.example.com/style.css has no integrity attribute, first seen on https://monzo.com/
+::warning title=Insecure cookie::monzo.com sets cookie session without HttpOnly, SameSite, first seen on https://monzo.com/login
+::warning title=Crawl trap::stopped crawling URLs matching monzo.com/calendar/{n}
+::warning title=Crawl limited::3 links weren't crawled as MAX_PAGES or PATTERN_BUDGETS was reached
+`, out.String())
+}
+
+func TestAppendGitHubStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	require.NoError(t, ioutil.WriteFile(path, []byte("# Build\n\n"), os.ModePerm))
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	require.NoError(t, appendGitHubStepSummary(&crawler.Report{Summary: crawler.Summary{Pages: 1}}))
+
+	summary, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(summary), "# Build\n\n# Crawl report\n")
+	require.Contains(t, string(summary), "| 1 | 0 | 0 | 0 |")
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// htmlTopPages is the number of pages listed in an HTML report's slowest and largest pages tables
+const htmlTopPages = 10
+
+// htmlReport is the data an HTML report's template is executed with
+type htmlReport struct {
+	*crawler.Report
+	Redirects []crawler.PageRecord
+	Internal  []crawler.RedirectRecord // links to redirects
+	Mixed     []crawler.MixedContentRecord
+	Forms     []crawler.InsecureFormRecord
+	Cookies   []crawler.CookieRecord
+	SRI       []crawler.SubresourceUsage
+	Domains   []crawler.DomainUsage // third-party domains
+	Trackers  []crawler.TrackerUsage
+	Endpoints []crawler.FormEndpoint
+	A11y      []crawler.AccessibilityRecord
+	Images    []crawler.ImageUsage // images without dimensions
+	Anchors   []crawler.AnchorTextUsage
+	External  []crawler.ExternalLink
+	Slowest   []crawler.PageRecord
+	Largest   []crawler.PageRecord
+	Orphans   []string
+	Unlisted  []crawler.PageRecord // pages missing from the sitemap
+	Statuses  []htmlBar
+	Languages []htmlBar
+}
+
+// htmlBar is a bar of one of an HTML report's charts
+type htmlBar struct {
+	Label   string
+	Count   int
+	Percent float64 // the bar's length relative to the longest in its chart
+}
+
+// writeHTMLReport renders a crawl's summary, pages, errors, mixed content, insecure forms and cookies, subresource
+// integrity, third-party domains, trackers, form endpoints, accessibility issues, images without dimensions, anchor
+// text, external links, redirects and links to them, duplicate titles and descriptions, sitemap comparison and slowest
+// and largest pages as a single HTML document with sortable tables and charts of status codes and languages, needing no
+// other files or network access to view
+func writeHTMLReport(w io.Writer, r *crawler.Report) error {
+	data := htmlReport{
+		Report:    r,
+		Internal:  r.InternalRedirects(),
+		Mixed:     r.MixedContent(),
+		Forms:     r.InsecureForms(),
+		Cookies:   r.CookieIssues(),
+		SRI:       r.Subresources(),
+		Domains:   r.ThirdPartyDomains(),
+		Trackers:  r.Trackers(),
+		Endpoints: r.FormEndpoints(),
+		A11y:      r.Accessibility(),
+		Images:    r.ImagesWithoutDimensions(),
+		Anchors:   r.AnchorTexts(),
+		External:  r.ExternalLinks(),
+		Slowest:   r.SlowestPages(htmlTopPages),
+		Largest:   r.LargestPages(htmlTopPages),
+		Orphans:   r.Orphans(),
+		Unlisted:  r.MissingFromSitemap(),
+	}
+
+	statuses := map[string]int{}
+	for _, page := range r.Pages {
+		statuses[strconv.Itoa(page.StatusCode)]++
+		if page.RedirectedTo != "" || page.Location != "" || page.Refresh != "" {
+			data.Redirects = append(data.Redirects, page)
+		}
+	}
+	for _, record := range r.Errors {
+		label := record.Class
+		if record.StatusCode != 0 {
+			label = strconv.Itoa(record.StatusCode)
+		}
+		statuses[label]++
+	}
+	data.Statuses = htmlBars(statuses)
+	data.Languages = htmlBars(r.Summary.Languages)
+
+	return htmlReportTemplate.Execute(w, data)
+}
+
+// htmlBars returns a bar per label, in label order
+func htmlBars(counts map[string]int) []htmlBar {
+	max := 0
+	for _, count := range counts {
+		if count > max {
+			max = count
+		}
+	}
+
+	bars := make([]htmlBar, 0, len(counts))
+	for label, count := range counts {
+		bars = append(bars, htmlBar{Label: label, Count: count, Percent: float64(count) * 100 / float64(max)})
+	}
+	sort.Slice(bars, func(i, j int) bool {
+		return bars[i].Label < bars[j].Label
+	})
+	return bars
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Crawl report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #eee; cursor: pointer; user-select: none; }
+th[data-order="asc"]::after { content: " \25B2"; }
+th[data-order="desc"]::after { content: " \25BC"; }
+td.number { text-align: right; }
+.chart { margin-bottom: 2em; }
+.bar { display: flex; align-items: center; margin: 0.2em 0; }
+.bar span { width: 8em; }
+.bar div { background: #4a7ebb; height: 1em; margin-right: 0.5em; }
+</style>
+</head>
+<body>
+<h1>Crawl report</h1>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Pages</th><th>Errors</th><th>Skipped</th><th>Limited</th></tr>
+<tr><td class="number">{{.Summary.Pages}}</td><td class="number">{{.Summary.Errors}}</td><td class="number">{{.Summary.Skipped}}</td><td class="number">{{.Summary.Limited}}</td></tr>
+</table>
+{{with .Summary.Traps}}<p>Crawl traps detected:</p>
+<ul>{{range .}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+
+{{with .Statuses}}<h3>Status codes</h3>
+<div class="chart">{{range .}}
+<div class="bar"><span>{{.Label}}</span><div style="width: {{.Percent}}%"></div>{{.Count}}</div>{{end}}
+</div>{{end}}
+
+{{with .Languages}}<h3>Languages</h3>
+<div class="chart">{{range .}}
+<div class="bar"><span>{{.Label}}</span><div style="width: {{.Percent}}%"></div>{{.Count}}</div>{{end}}
+</div>{{end}}
+
+<h2>Errors ({{len .Errors}})</h2>
+{{if .Errors}}<table class="sortable">
+<thead><tr><th>URL</th><th>Status</th><th>Class</th><th>Error</th><th>Linked from</th></tr></thead>
+<tbody>{{range .Errors}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{if .StatusCode}}{{.StatusCode}}{{end}}</td><td>{{.Class}}</td><td>{{.Error}}</td><td>{{if .Referrer}}<a href="{{.Referrer}}">{{.Referrer}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{else}}<p>None.</p>{{end}}
+
+{{with .Mixed}}<h2>Mixed content ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Page</th><th>Element</th><th>URL</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.Page}}">{{.Page}}</a></td><td>{{.Element}}</td><td>{{.URL}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Forms}}<h2>Insecure forms ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Page</th><th>Action</th><th>Method</th><th>Reason</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.Page}}">{{.Page}}</a></td><td>{{.Action}}</td><td>{{.Method}}</td><td>{{.Reason}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Cookies}}<h2>Insecure cookies ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Host</th><th>Cookie</th><th>Missing</th><th>First seen on</th></tr></thead>
+<tbody>{{range .}}
+<tr><td>{{.Host}}</td><td>{{.Name}}</td><td>{{range $i, $attr := .Missing}}{{if $i}}, {{end}}{{$attr}}{{end}}</td><td><a href="{{.FirstSeen}}">{{.FirstSeen}}</a></td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .SRI}}<h2>Subresource integrity</h2>
+<table class="sortable">
+<thead><tr><th>Resource</th><th>Element</th><th>Integrity</th><th>Pages</th><th>First seen on</th></tr></thead>
+<tbody>{{range .}}
+<tr><td>{{.URL}}</td><td>{{.Element}}</td><td>{{.Status}}</td><td class="number">{{.Pages}}</td><td><a href="{{.FirstSeen}}">{{.FirstSeen}}</a></td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Domains}}<h2>Third-party domains ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Domain</th><th>Pages</th><th>References</th><th>Elements</th><th>Example pages</th></tr></thead>
+<tbody>{{range .}}
+<tr><td>{{.Domain}}</td><td class="number">{{.Pages}}</td><td class="number">{{.References}}</td><td>{{range $i, $element := .Elements}}{{if $i}}, {{end}}{{$element}}{{end}}</td><td>{{range $i, $page := .Examples}}{{if $i}}, {{end}}<a href="{{$page}}">{{$page}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Trackers}}<h2>Trackers ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Tracker</th><th>Pages</th><th>Scripts</th><th>Example pages</th></tr></thead>
+<tbody>{{range .}}
+<tr><td>{{.Name}}</td><td class="number">{{.Pages}}</td><td>{{range $i, $script := .Scripts}}{{if $i}}, {{end}}{{$script}}{{end}}</td><td>{{range $i, $page := .Examples}}{{if $i}}, {{end}}<a href="{{$page}}">{{$page}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Endpoints}}<h2>Form endpoints ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Method</th><th>Action</th><th>Pages</th><th>Example pages</th></tr></thead>
+<tbody>{{range .}}
+<tr><td>{{.Method}}</td><td>{{.Action}}</td><td class="number">{{.Pages}}</td><td>{{range $i, $page := .Examples}}{{if $i}}, {{end}}<a href="{{$page}}">{{$page}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .A11y}}<h2>Accessibility ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Page</th><th>Images missing alt text</th><th>Empty links</th><th>Missing lang</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.Page}}">{{.Page}}</a></td><td class="number">{{.MissingAlt}}</td><td class="number">{{.EmptyLinks}}</td><td>{{if .MissingLang}}yes{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Images}}<h2>Images without dimensions ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Image</th><th>Pages</th><th>Example pages</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{.Pages}}</td><td>{{range $i, $page := .Examples}}{{if $i}}, {{end}}<a href="{{$page}}">{{$page}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Anchors}}<h2>Anchor text ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>URL</th><th>Links</th><th>Pages</th><th>Texts</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{.Links}}</td><td class="number">{{.Pages}}</td><td>{{range $i, $text := .Texts}}{{if $i}}, {{end}}{{if $text.Text}}{{$text.Text}}{{else}}(no text){{end}} ({{$text.Links}}){{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .External}}<h2>External links ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>URL</th><th>Status</th><th>Pages</th><th>Example pages</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{if .StatusCode}}{{.StatusCode}}{{end}}</td><td class="number">{{.Pages}}</td><td>{{range $i, $page := .Examples}}{{if $i}}, {{end}}<a href="{{$page}}">{{$page}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+<h2>Redirects ({{len .Redirects}})</h2>
+{{if .Redirects}}<table class="sortable">
+<thead><tr><th>URL</th><th>Status</th><th>Redirected to</th></tr></thead>
+<tbody>{{range .Redirects}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{.StatusCode}}</td><td>{{if .RedirectedTo}}{{.RedirectedTo}}{{else if .Location}}{{.Location}} (not followed){{else}}{{.Refresh}} (meta refresh){{end}}</td></tr>{{end}}
+</tbody>
+</table>{{else}}<p>None.</p>{{end}}
+
+{{with .Internal}}<h2>Links to redirects ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Page</th><th>Link</th><th>Redirects to</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.Source}}">{{.Source}}</a></td><td>{{.Target}}</td><td>{{.Destination}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .DuplicateTitles}}<h2>Duplicate titles ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Title</th><th>Pages</th></tr></thead>
+<tbody>{{range .}}
+<tr><td>{{.Value}}</td><td>{{range $i, $url := .URLs}}{{if $i}}<br>{{end}}<a href="{{$url}}">{{$url}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .DuplicateDescriptions}}<h2>Duplicate descriptions ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>Description</th><th>Pages</th></tr></thead>
+<tbody>{{range .}}
+<tr><td>{{.Value}}</td><td>{{range $i, $url := .URLs}}{{if $i}}<br>{{end}}<a href="{{$url}}">{{$url}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Orphans}}<h2>Orphan pages ({{len .}})</h2>
+<p>Listed in the sitemap, but not linked to by any page crawled.</p>
+<ul>{{range .}}<li><a href="{{.}}">{{.}}</a></li>{{end}}</ul>{{end}}
+
+{{with .Unlisted}}<h2>Missing from the sitemap ({{len .}})</h2>
+<table class="sortable">
+<thead><tr><th>URL</th><th>Linked from</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td>{{if .Referrer}}<a href="{{.Referrer}}">{{.Referrer}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Slowest}}<h2>Slowest pages</h2>
+<table class="sortable">
+<thead><tr><th>URL</th><th>Status</th><th>Duration (ms)</th><th>Size (bytes)</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{.StatusCode}}</td><td class="number">{{.FetchDuration.Milliseconds}}</td><td class="number">{{.ContentLength}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+{{with .Largest}}<h2>Largest pages</h2>
+<table class="sortable">
+<thead><tr><th>URL</th><th>Status</th><th>Size (bytes)</th><th>Duration (ms)</th></tr></thead>
+<tbody>{{range .}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{.StatusCode}}</td><td class="number">{{.ContentLength}}</td><td class="number">{{.FetchDuration.Milliseconds}}</td></tr>{{end}}
+</tbody>
+</table>{{end}}
+
+<h2>Pages ({{len .Pages}})</h2>
+<table class="sortable">
+<thead><tr><th>URL</th><th>Status</th><th>Title</th><th>Duration (ms)</th><th>Size (bytes)</th><th>Linked from</th></tr></thead>
+<tbody>{{range .Pages}}
+<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td class="number">{{.StatusCode}}</td><td>{{.Title}}</td><td class="number">{{.FetchDuration.Milliseconds}}</td><td class="number">{{.ContentLength}}</td><td>{{if .Referrer}}<a href="{{.Referrer}}">{{.Referrer}}</a>{{end}}</td></tr>{{end}}
+</tbody>
+</table>
+
+<script>
+document.querySelectorAll("table.sortable th").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var index = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var tbody = th.closest("table").tBodies[0];
+    var asc = th.dataset.order !== "asc";
+    th.parentNode.querySelectorAll("th").forEach(function (other) { delete other.dataset.order; });
+    th.dataset.order = asc ? "asc" : "desc";
+    var rows = Array.prototype.slice.call(tbody.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[index].textContent, y = b.cells[index].textContent;
+      var cmp = (x !== "" && y !== "" && !isNaN(x) && !isNaN(y)) ? x - y : x.localeCompare(y);
+      return asc ? cmp : -cmp;
+    });
+    rows.forEach(function (row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	report := &crawler.Report{
+		Summary: crawler.Summary{Pages: 3, Errors: 1, Languages: map[string]int{"en": 3}},
+		Pages: []crawler.PageRecord{
+			{URL: "http://monzo.com/", StatusCode: 200, Title: "<Monzo>", FetchDuration: 100 * time.Millisecond},
+			{URL: "http://monzo.com/old", StatusCode: 200, RedirectedTo: "http://monzo.com/new", Title: "<Monzo>"},
+			{URL: "http://monzo.com/away", StatusCode: 301, Location: "http://example.com/"},
+		},
+		LinkedFrom: map[string][]string{"http://monzo.com/old": {"http://monzo.com/"}},
+		Errors: []crawler.ErrorRecord{
+			{URL: "http://monzo.com/missing", Referrer: "http://monzo.com/", StatusCode: 404, Class: crawler.ErrorClassHTTPStatus},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeHTMLReport(out, report))
+	html := out.String()
+
+	require.Contains(t, html, "<h2>Errors (1)</h2>")
+	require.Contains(t, html, `<a href="http://monzo.com/missing">http://monzo.com/missing</a></td><td class="number">404</td>`)
+	require.Contains(t, html, "<h2>Redirects (2)</h2>")
+	require.Contains(t, html, "<td>http://monzo.com/new</td>")
+	require.Contains(t, html, "<td>http://example.com/ (not followed)</td>")
+	require.Contains(t, html, "<h2>Slowest pages</h2>\n<table class=\"sortable\">\n<thead><tr><th>URL</th><th>Status</th><th>Duration (ms)</th><th>Size (bytes)</th></tr></thead>\n<tbody>\n<tr><td><a href=\"http://monzo.com/\">http://monzo.com/</a></td><td class=\"number\">200</td><td class=\"number\">100</td>")
+	require.Contains(t, html, "<h2>Largest pages</h2>")
+	require.Contains(t, html, `<h2>Duplicate titles (1)</h2>`)
+	require.Contains(t, html, `<tr><td>&lt;Monzo&gt;</td><td><a href="http://monzo.com/">http://monzo.com/</a><br><a href="http://monzo.com/old">http://monzo.com/old</a></td></tr>`)
+	require.NotContains(t, html, "Duplicate descriptions")
+	require.NotContains(t, html, "Orphan pages")
+	require.NotContains(t, html, "Mixed content")
+	require.NotContains(t, html, "Insecure forms")
+	require.NotContains(t, html, "Insecure cookies")
+	require.NotContains(t, html, "Subresource integrity")
+	require.NotContains(t, html, "Third-party domains")
+	require.NotContains(t, html, "Trackers")
+	require.NotContains(t, html, "Form endpoints")
+	require.NotContains(t, html, "Accessibility")
+	require.NotContains(t, html, "Images without dimensions")
+	require.NotContains(t, html, "Anchor text")
+	require.NotContains(t, html, "External links")
+	require.NotContains(t, html, "Missing from the sitemap")
+	require.Contains(t, html, `<h2>Links to redirects (1)</h2>`)
+	require.Contains(t, html, `<tr><td><a href="http://monzo.com/">http://monzo.com/</a></td><td>http://monzo.com/old</td><td>http://monzo.com/new</td></tr>`)
+	require.Contains(t, html, "<h2>Pages (3)</h2>")
+	require.Contains(t, html, "<td>&lt;Monzo&gt;</td>")
+	require.Contains(t, html, `<div class="bar"><span>200</span><div style="width: 100%"></div>2</div>`)
+	require.Contains(t, html, `<div class="bar"><span>404</span><div style="width: 50%"></div>1</div>`)
+	require.Contains(t, html, `<div class="bar"><span>en</span><div style="width: 100%"></div>3</div>`)
+}
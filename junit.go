@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     float64         `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport renders a crawl as a JUnit XML test report, with a passing test case for each page crawled and a
+// failing one for each broken link or other error, so that CI servers can show a site's health as test results
+func writeJUnitReport(w io.Writer, r *crawler.Report) error {
+	suite := junitTestSuite{Name: "crawl"}
+
+	for _, page := range r.Pages {
+		if page.Soft404 != "" {
+			continue // reported as a failing test case with the errors
+		}
+		seconds := page.FetchDuration.Seconds()
+		suite.Time += seconds
+		suite.Cases = append(suite.Cases, junitTestCase{Name: page.URL, ClassName: junitClassName(page.URL), Time: seconds})
+	}
+	for _, record := range r.Errors {
+		text := record.Error
+		if record.Referrer != "" {
+			text += "\nlinked from " + record.Referrer
+		}
+		message := record.Error
+		if record.Class == crawler.ErrorClassHTTPStatus {
+			message = fmt.Sprintf("status code %d", record.StatusCode)
+		}
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      record.URL,
+			ClassName: junitClassName(record.URL),
+			Failure:   &junitFailure{Message: message, Type: record.Class, Text: text},
+		})
+		suite.Failures++
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitClassName groups the test cases of a URL by its host, which CI servers show as a package
+func junitClassName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "crawl"
+	}
+	return u.Host
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	report := &crawler.Report{
+		Pages: []crawler.PageRecord{
+			{URL: "http://monzo.com/", StatusCode: 200, FetchDuration: 500 * time.Millisecond},
+		},
+		Errors: []crawler.ErrorRecord{
+			{
+				URL:        "http://monzo.com/missing",
+				Referrer:   "http://monzo.com/",
+				StatusCode: 404,
+				Class:      crawler.ErrorClassHTTPStatus,
+				Error:      "http://monzo.com/missing returned status code: 404",
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeJUnitReport(out, report))
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="crawl" tests="2" failures="1" time="0.5">
+    <testcase name="http://monzo.com/" classname="monzo.com" time="0.5"></testcase>
+    <testcase name="http://monzo.com/missing" classname="monzo.com" time="0">
+      <failure message="status code 404" type="http_status">http://monzo.com/missing returned status code: 404&#xA;linked from http://monzo.com/</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, out.String())
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// newLogger returns a logger writing records at or above level to w, formatted as "text" or "json"
+func newLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level '%s', expected debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format '%s', expected text or json", format)
+	}
+}
+
+// fatal logs msg and its attributes at error level, then exits with exitConfig
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(exitConfig)
+}
+
+// parseFlags parses args with fs, which should continue on error, returning whether the command should exit rather than
+// run and with which code: exitOK for -h, once the usage has been printed, or exitConfig for an unknown or invalid flag,
+// rather than the 2 the flag package exits with, which would read as a crawl which found broken links
+func parseFlags(fs *flag.FlagSet, args []string) (code int, exit bool) {
+	switch err := fs.Parse(args); {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK, true
+	case err != nil:
+		return exitConfig, true
+	}
+	return exitOK, false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		w := &bytes.Buffer{}
+		l, err := newLogger(w, "warn", "text")
+		require.NoError(t, err)
+		l.Info("fetched page", "url", "https://monzo.com/")
+		require.Empty(t, w.String(), "records below the level are dropped")
+		l.Warn("slow page", "url", "https://monzo.com/")
+		require.Contains(t, w.String(), `level=WARN msg="slow page" url=https://monzo.com/`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		w := &bytes.Buffer{}
+		l, err := newLogger(w, "DEBUG", "JSON")
+		require.NoError(t, err, "the level and format are case insensitive")
+		l.Debug("fetched page", "url", "https://monzo.com/")
+		record := map[string]any{}
+		require.NoError(t, json.Unmarshal(w.Bytes(), &record))
+		require.Equal(t, "DEBUG", record["level"])
+		require.Equal(t, "fetched page", record["msg"])
+		require.Equal(t, "https://monzo.com/", record["url"])
+	})
+
+	t.Run("unknown level", func(t *testing.T) {
+		_, err := newLogger(io.Discard, "verbose", "text")
+		require.EqualError(t, err, "unknown log level 'verbose', expected debug, info, warn or error")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := newLogger(io.Discard, "info", "logfmt")
+		require.EqualError(t, err, "unknown log format 'logfmt', expected text or json")
+	})
+}
+
+func TestParseFlags(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		args         []string
+		expectedCode int
+		expectedExit bool
+	}{
+		{"valid", []string{"-workers", "2"}, exitOK, false},
+		{"help", []string{"-h"}, exitOK, true},
+		{"unknown flag", []string{"-nope"}, exitConfig, true},
+		{"invalid value", []string{"-workers", "many"}, exitConfig, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("web_crawler", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			fs.Int("workers", 1, "")
+			code, exit := parseFlags(fs, tc.args)
+			require.Equal(t, tc.expectedCode, code)
+			require.Equal(t, tc.expectedExit, exit)
+		})
+	}
+}
@@ -1,37 +1,627 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/eggsbenjamin/web_crawler/crawler"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultTopPages is the number of slowest and largest pages listed in the summary unless TOP_PAGES is set
+const defaultTopPages = 10
+
+// Exit codes, documented in the README
+const (
+	exitOK          = 0 // the crawl completed without errors
+	exitConfig      = 1 // the crawler was misconfigured and never started
+	exitHTTPErrors  = 2 // the crawl completed, but some pages returned HTTP error status codes or timed out
+	exitLimited     = 3 // the crawl completed without errors, but a budget left links uncrawled
+	exitCrawlFailed = 4 // the crawl was aborted by a fatal error, e.g. a write to stdout failing
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "robots" {
+		os.Exit(runRobots(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(runServe(os.Args[2:]))
+	}
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	seedsPath := flag.String("seeds", "", "file of newline separated seed URLs to crawl, '-' for stdin")
+	tui := flag.Bool("tui", false, "show a live dashboard of the crawl's progress on stderr")
+	debugAddr := flag.String("debug-addr", "", "address to serve pprof and expvar debug endpoints on, e.g. localhost:6060")
+	logLevel := flag.String("log-level", "info", "minimum level of log records written to stderr: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "format of log records: text or json")
+	errorsPath := flag.String("errors-file", "", "file to write non-fatal errors to as newline delimited JSON")
+	format := flag.String("format", "text", "format of each page written out: text or template")
+	outputPath := flag.String("output", "", "file to write the pages to rather than stdout, gzipped if it ends in .gz")
+	gzipOutput := flag.Bool("gzip", false, "gzip the pages written to stdout or -output as they're written")
+	shardPages := flag.Int("shard-pages", 0, "split -output into numbered files of at most this many pages each, with an index")
+	shardSize := flag.Int64("shard-size", 0, "split -output into numbered files of at most this many bytes each, before compression, with an index")
+	tmplText := flag.String("template", "", "Go template executed with each page when -format is template, e.g. '{{.URL}} {{len .Links}}'")
+	markdownPath := flag.String("report-markdown", "", "file to write a Markdown report of the crawl's summary, broken links and slowest pages to")
+	htmlPath := flag.String("report-html", "", "file to write a self-contained HTML report of the crawl's pages, errors and redirects to")
+	manifestPath := flag.String("manifest", "", "file to write a JSON manifest of each URL crawled's content hash, size and Last-Modified time to")
+	contactsPath := flag.String("report-contacts", "", "file to write the email addresses and phone numbers found on each page to as CSV, from mailto: and tel: links and the pages' text")
+	junitPath := flag.String("report-junit", "", "file to write a JUnit XML report to, with a failing test case per broken link or error")
+	githubAnnotations := flag.Bool("github-annotations", false, "write GitHub Actions annotations for broken links and other findings to stderr, and a report to the job summary")
+	cassetteDir := flag.String("cassette", "", "directory to record responses to, replaying them instead of making requests on later runs")
+	offline := flag.Bool("offline", false, "with -cassette, fail requests which weren't recorded rather than making them")
+	userAgentsPath := flag.String("user-agents", "", "file of newline separated User-Agents to rotate requests over, see USER_AGENT_ROTATION")
+	dir := flag.String("dir", "", "directory of a static site to crawl as its root, from file:///, e.g. a site generator's build output")
+	mirrorDir := flag.String("mirror", "", "directory to save a copy of the site to, with links rewritten to browse it offline")
+	configPath := flag.String("config", "", "JSON file of extraction rules, scope and per-section overrides, see README")
+	var searchLiterals, searchExprs stringsFlag
+	flag.Var(&searchLiterals, "search", "report pages whose text contains this string instead of writing every page, may be repeated")
+	flag.Var(&searchExprs, "search-regex", "as -search, but for a regular expression, may be repeated")
+	var trackers stringsFlag
+	flag.Var(&trackers, "tracker", "a tracker to detect as well as the built-in ones, as a name and a regular expression matching its scripts' host and path, e.g. 'Acme=^cdn\\.acme\\.com/', may be repeated")
+	if code, exit := parseFlags(flag.CommandLine, os.Args[1:]); exit {
+		os.Exit(code)
+	}
+
+	logger, err := newLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	if *debugAddr != "" {
+		serveDebug(*debugAddr)
+	}
+
 	workersStr := mustGetEnv("WORKERS")
 	workers, err := strconv.Atoi(workersStr)
 	if err != nil {
-		log.Fatalf("env var 'WORKERS' is non-numeric: %s", workersStr)
+		fatal("env var is non-numeric", "var", "WORKERS", "value", workersStr)
 	}
 	if workers == 0 {
-		log.Fatalf("env var 'WORKERS' must be greater than zero: %d", workers)
+		fatal("env var must be greater than zero", "var", "WORKERS", "value", workers)
 	}
 
-	url := mustGetEnv("URL")
-	c := crawler.New(workers, &http.Client{Timeout: time.Second * 2})
+	seeds := []string{}
+	if url := os.Getenv("URL"); url != "" {
+		seeds = append(seeds, url)
+	}
+	if *dir != "" {
+		seeds = append(seeds, "file:///")
+	}
+	if *seedsPath == "" && len(seeds) == 0 && isPiped(os.Stdin) {
+		*seedsPath = "-"
+	}
+	if *seedsPath != "" {
+		fileSeeds, err := readSeedsFile(*seedsPath)
+		if err != nil {
+			fatal("error reading seeds", "path", *seedsPath, "error", err.Error())
+		}
+		seeds = append(seeds, fileSeeds...)
+	}
+	if len(seeds) == 0 {
+		fatal("env var not set and no seeds given", "var", "URL")
+	}
 
-	if err := c.Crawl(url, os.Stdout); err != nil {
-		log.Fatalf("error crawling %s: %q", url, err)
+	summary := &crawler.Summary{}
+	opts := []crawler.Option{crawler.WithSummary(summary)}
+	var hostLimits map[string]crawler.HostLimits
+	if *configPath != "" {
+		// applied first so that env vars take precedence
+		cfg, err := loadConfig(*configPath)
+		if err != nil {
+			fatal("error loading config", "error", err.Error())
+		}
+		opts = append(opts, cfg.options()...)
+		hostLimits = cfg.hostLimits()
+	}
+	if *dir != "" {
+		opts = append(opts, crawler.WithLocalFiles(*dir))
+	} else {
+		for _, seed := range seeds {
+			if strings.HasPrefix(seed, "file:") {
+				opts = append(opts, crawler.WithLocalFiles("/"))
+				break
+			}
+		}
+	}
+	if *mirrorDir != "" {
+		opts = append(opts, crawler.WithMirror(*mirrorDir))
+	}
+	if headers := os.Getenv("CAPTURE_HEADERS"); headers != "" {
+		opts = append(opts, crawler.WithCaptureHeaders(strings.Split(headers, ",")...))
+	}
+	topPages := defaultTopPages
+	if os.Getenv("TOP_PAGES") != "" {
+		topPages = getEnvInt("TOP_PAGES")
+	}
+	if topPages > 0 {
+		opts = append(opts, crawler.WithTopPages(topPages))
+	}
+	if maxPages := getEnvInt("MAX_PAGES"); maxPages > 0 {
+		opts = append(opts, crawler.WithMaxPages(maxPages))
+	}
+	if maxBodySize := getEnvInt("MAX_BODY_SIZE"); maxBodySize > 0 {
+		opts = append(opts, crawler.WithMaxBodySize(int64(maxBodySize)))
+	}
+	if maxLinks := getEnvInt("MAX_LINKS_PER_PAGE"); maxLinks > 0 {
+		opts = append(opts, crawler.WithMaxLinksPerPage(maxLinks))
+	}
+	if budgets := os.Getenv("PATTERN_BUDGETS"); budgets != "" {
+		for _, budget := range strings.Split(budgets, ",") {
+			i := strings.LastIndex(budget, "=")
+			if i < 0 {
+				fatal("env var is malformed, expected 'pattern=max,pattern=max'", "var", "PATTERN_BUDGETS", "value", budgets)
+			}
+			max, err := strconv.Atoi(budget[i+1:])
+			if err != nil {
+				fatal("env var has a non-numeric max", "var", "PATTERN_BUDGETS", "value", budget)
+			}
+			opts = append(opts, crawler.WithPatternBudget(budget[:i], max))
+		}
+	}
+	opts = append(opts, crawler.WithURLLimits(crawler.URLLimits{
+		MaxLength:       getEnvInt("MAX_URL_LENGTH"),
+		MaxPathSegments: getEnvInt("MAX_PATH_SEGMENTS"),
+		MaxQueryParams:  getEnvInt("MAX_QUERY_PARAMS"),
+	}))
+	if os.Getenv("TRAP_DETECTION") == "true" {
+		opts = append(opts, crawler.WithTrapDetection(crawler.DefaultTrapLimits))
+	}
+	switch os.Getenv("PRIORITY") {
+	case "":
+	case "shorter-paths":
+		opts = append(opts, crawler.WithPriority(crawler.ShorterPathsFirst))
+	case "shallowest":
+		opts = append(opts, crawler.WithPriority(crawler.ShallowestFirst))
+	default:
+		fatal("env var must be shorter-paths or shallowest", "var", "PRIORITY", "value", os.Getenv("PRIORITY"))
+	}
+	if os.Getenv("PAGINATION_PRIORITY") == "true" {
+		opts = append(opts, crawler.WithPaginationPriority())
+	}
+	if depth := getEnvInt("OFFSITE_DEPTH"); depth > 0 {
+		opts = append(opts, crawler.WithOffsiteDepth(depth))
+	}
+	if aliases := os.Getenv("HOST_ALIASES"); aliases != "" {
+		for _, group := range strings.Split(aliases, ";") {
+			parts := strings.SplitN(group, "=", 2)
+			if len(parts) != 2 {
+				fatal("env var is malformed, expected 'host=alias,alias;host=alias'", "var", "HOST_ALIASES", "value", aliases)
+			}
+			opts = append(opts, crawler.WithHostAliases(parts[0], strings.Split(parts[1], ",")...))
+		}
+	}
+	switch scope := os.Getenv("SCOPE"); scope {
+	case "", "host":
+	case "domain":
+		opts = append(opts, crawler.WithScopePolicy(crawler.SameRegistrableDomain{}))
+	case "path":
+		opts = append(opts, crawler.WithScopePolicy(crawler.PathPrefix{}))
+	default:
+		fatal("env var must be one of host, domain or path", "var", "SCOPE", "value", scope)
+	}
+	if domains := os.Getenv("SCOPE_DOMAINS"); domains != "" {
+		opts = append(opts, crawler.WithScopePolicy(crawler.Domains{
+			Include: strings.Split(domains, ","),
+			Exclude: splitNonEmpty(os.Getenv("SCOPE_EXCLUDE"), ","),
+		}))
+	}
+	if rules := os.Getenv("EXTRACTION_RULES"); rules != "" {
+		for _, rule := range strings.Split(rules, ";") {
+			parts := strings.SplitN(rule, "=", 2)
+			if len(parts) != 2 {
+				fatal("env var is malformed, expected 'field=selector;field=selector@attr'", "var", "EXTRACTION_RULES", "value", rules)
+			}
+			extraction := crawler.ExtractionRule{Field: parts[0], Selector: parts[1]}
+			if i := strings.LastIndex(parts[1], "@"); i >= 0 {
+				extraction.Selector, extraction.Attr = parts[1][:i], parts[1][i+1:]
+			}
+			opts = append(opts, crawler.WithExtractionRules(extraction))
+		}
+	}
+	if os.Getenv("IGNORE_ROBOTS_DIRECTIVES") == "true" {
+		opts = append(opts, crawler.WithIgnoreRobotsDirectives())
+	}
+	if os.Getenv("FOLLOW_META_REFRESH") == "true" {
+		opts = append(opts, crawler.WithFollowMetaRefresh())
+	}
+	if jitterMax := getEnvDuration("JITTER_MAX"); jitterMax > 0 {
+		opts = append(opts, crawler.WithJitter(getEnvDuration("JITTER_MIN"), jitterMax))
+	}
+	if politeness := envPoliteness(hostLimits); politeness.Delay > 0 || politeness.MaxConcurrent > 0 || len(politeness.Hosts) > 0 {
+		opts = append(opts, crawler.WithPoliteness(politeness))
+	}
+	if os.Getenv("HOST_SHARDING") == "true" {
+		opts = append(opts, crawler.WithHostSharding())
+	}
+	if os.Getenv("HTTP3") == "true" {
+		opts = append(opts, crawler.WithHTTP3())
+	}
+	if userAgent := os.Getenv("USER_AGENT"); userAgent != "" {
+		opts = append(opts, crawler.WithUserAgent(userAgent))
+	}
+	if *userAgentsPath != "" {
+		// the same format as a seeds file, so blank lines and comments are skipped
+		userAgents, err := readSeedsFile(*userAgentsPath)
+		if err != nil {
+			fatal("error reading user agents", "path", *userAgentsPath, "error", err.Error())
+		}
+		rotation := crawler.RotatePerRequest
+		switch os.Getenv("USER_AGENT_ROTATION") {
+		case "", "request":
+		case "host":
+			rotation = crawler.RotatePerHost
+		default:
+			fatal("env var must be request or host", "var", "USER_AGENT_ROTATION", "value", os.Getenv("USER_AGENT_ROTATION"))
+		}
+		opts = append(opts, crawler.WithUserAgents(rotation, userAgents...))
+	}
+	if os.Getenv("SOFT_404_DETECTION") == "true" {
+		opts = append(opts, crawler.WithSoft404Detection(crawler.DefaultSoft404Rules))
+	}
+	if os.Getenv("FRAGMENT_VALIDATION") == "true" {
+		opts = append(opts, crawler.WithFragmentValidation())
+	}
+	if os.Getenv("FOLLOW_ALTERNATES") == "true" {
+		opts = append(opts, crawler.WithFollowAlternates())
+	}
+	if os.Getenv("MIXED_CONTENT_DETECTION") == "true" {
+		opts = append(opts, crawler.WithMixedContentDetection())
+	}
+	if os.Getenv("FORM_DISCOVERY") == "true" || os.Getenv("FOLLOW_GET_FORMS") == "true" {
+		opts = append(opts, crawler.WithFormDiscovery(os.Getenv("FOLLOW_GET_FORMS") == "true"))
+	}
+	if os.Getenv("INSECURE_FORM_DETECTION") == "true" {
+		opts = append(opts, crawler.WithInsecureFormDetection())
+	}
+	if os.Getenv("SRI_CHECK") == "true" || os.Getenv("SRI_VERIFY") == "true" {
+		opts = append(opts, crawler.WithSubresourceIntegrity(os.Getenv("SRI_VERIFY") == "true"))
+	}
+	if os.Getenv("COOKIE_AUDIT") == "true" {
+		opts = append(opts, crawler.WithCookieAudit())
+	}
+	if os.Getenv("ACCESSIBILITY_CHECKS") == "true" {
+		opts = append(opts, crawler.WithAccessibilityChecks())
+	}
+	if os.Getenv("IMAGE_INVENTORY") == "true" {
+		opts = append(opts, crawler.WithImageInventory())
+	}
+	if os.Getenv("ANCHOR_TEXT") == "true" {
+		opts = append(opts, crawler.WithAnchorText())
+	}
+	if os.Getenv("THIRD_PARTY_INVENTORY") == "true" {
+		opts = append(opts, crawler.WithThirdPartyInventory())
+	}
+	if os.Getenv("TRACKER_DETECTION") == "true" || len(trackers) > 0 {
+		signatures, err := trackerSignatures(trackers)
+		if err != nil {
+			fatal("invalid -tracker", "error", err.Error())
+		}
+		opts = append(opts, crawler.WithTrackerDetection(signatures))
+	}
+	if os.Getenv("SITEMAP_COMPARISON") == "true" {
+		opts = append(opts, crawler.WithSitemapComparison())
+	}
+	if os.Getenv("SITEMAP_SEEDS") == "true" {
+		opts = append(opts, crawler.WithSitemapSeeds())
+	}
+	if os.Getenv("MAX_REDIRECTS") != "" || os.Getenv("CROSS_HOST_REDIRECTS") != "" || os.Getenv("SCOPED_REDIRECTS") != "" {
+		policy := crawler.RedirectPolicy{
+			FollowCrossHost: os.Getenv("CROSS_HOST_REDIRECTS") != "false",
+			EnforceScope:    os.Getenv("SCOPED_REDIRECTS") == "true",
+		}
+		if os.Getenv("MAX_REDIRECTS") != "" {
+			maxRedirects := getEnvInt("MAX_REDIRECTS")
+			if maxRedirects < 0 {
+				fatal("env var is negative", "var", "MAX_REDIRECTS", "value", maxRedirects)
+			}
+			policy.MaxRedirects = &maxRedirects
+		}
+		opts = append(opts, crawler.WithRedirectPolicy(policy))
+	}
+
+	var pageFormat func(*crawler.Page) ([]byte, error) // Page.Marshal if nil
+	switch *format {
+	case "text":
+	case "template":
+		if *tmplText == "" {
+			fatal("-format template requires -template")
+		}
+		tmpl, err := crawler.ParseTemplate(*tmplText)
+		if err != nil {
+			fatal("invalid -template", "error", err.Error())
+		}
+		pageFormat = crawler.TemplateFormat(tmpl)
+	default:
+		fatal("-format must be text or template", "value", *format)
+	}
+
+	var sink crawler.Sink
+	switch {
+	case len(searchLiterals) > 0 || len(searchExprs) > 0:
+		patterns, err := searchPatterns(searchLiterals, searchExprs)
+		if err != nil {
+			fatal("invalid -search-regex", "error", err.Error())
+		}
+		opts = append(opts, crawler.WithSearch(patterns...), crawler.WithSubscriber(reportMatches(os.Stdout)))
+		sink = crawler.WriterSink(io.Discard, nil)
+	case *shardPages > 0 || *shardSize > 0:
+		if *outputPath == "" {
+			fatal("-shard-pages and -shard-size require -output")
+		}
+		sink = newShardedSink(*outputPath, *gzipOutput, pageFormat, *shardPages, *shardSize)
+	default:
+		out, closeOutput, err := openOutput(*outputPath, *gzipOutput)
+		if err != nil {
+			fatal("error creating output file", "path", *outputPath, "error", err.Error())
+		}
+		sink = &outputSink{Sink: crawler.WriterSink(out, pageFormat), close: closeOutput}
+	}
+
+	if *errorsPath != "" {
+		f, err := os.Create(*errorsPath)
+		if err != nil {
+			fatal("error creating errors file", "path", *errorsPath, "error", err.Error())
+		}
+		defer f.Close()
+		opts = append(opts, crawler.WithErrorReport(f))
+	}
+
+	if *contactsPath != "" {
+		opts = append(opts, crawler.WithContactExtraction())
+	}
+	var report *crawler.Report
+	if *markdownPath != "" || *htmlPath != "" || *junitPath != "" || *manifestPath != "" || *contactsPath != "" || *githubAnnotations {
+		report = &crawler.Report{}
+		opts = append(opts, crawler.WithReport(report))
+	}
+
+	flushTraces := func() {}
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+		var tracer trace.Tracer
+		tracer, flushTraces = newOTLPTracer()
+		opts = append(opts, crawler.WithTracer(tracer))
+	}
+	if addr := os.Getenv("STATSD_ADDR"); addr != "" {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			fatal("error dialling StatsD", "addr", addr, "error", err.Error())
+		}
+		defer conn.Close()
+		prefix := "web_crawler"
+		if p, ok := os.LookupEnv("STATSD_PREFIX"); ok {
+			prefix = p
+		}
+		opts = append(opts, crawler.WithSubscriber(crawler.StatsDSubscriber(conn, crawler.StatsDConfig{
+			Prefix:    prefix,
+			DogStatsD: os.Getenv("DOGSTATSD") == "true",
+			Tags:      splitNonEmpty(os.Getenv("STATSD_TAGS"), ","),
+		})))
+	}
+	if *tui {
+		// the dashboard is redrawn over stderr, so the crawl's own logs would only be overwritten
+		opts = append(opts,
+			crawler.WithProgress(dashboardInterval, newDashboard(os.Stderr).update),
+			crawler.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		)
+	}
+
+	client := &http.Client{Timeout: time.Second * 2}
+	if os.Getenv("HTTP3") == "true" && (os.Getenv("SOCKS_PROXY") != "" || os.Getenv("PROXIES") != "") {
+		fatal("HTTP3 can't be used with SOCKS_PROXY or PROXIES, as QUIC would bypass the proxy")
+	}
+	switch proxyURL, proxies := os.Getenv("SOCKS_PROXY"), os.Getenv("PROXIES"); {
+	case proxyURL != "" && proxies != "":
+		fatal("only one of SOCKS_PROXY and PROXIES may be set")
+	case proxyURL != "":
+		transport, err := socksTransport(proxyURL)
+		if err != nil {
+			fatal("invalid SOCKS proxy", "var", "SOCKS_PROXY", "error", err.Error())
+		}
+		client.Transport = transport
+	case proxies != "":
+		maxFailures := getEnvInt("PROXY_MAX_FAILURES")
+		if maxFailures == 0 {
+			maxFailures = defaultProxyMaxFailures
+		}
+		pool, err := newProxyPool(strings.Split(proxies, ","), maxFailures)
+		if err != nil {
+			fatal("invalid proxy pool", "var", "PROXIES", "error", err.Error())
+		}
+		client.Transport = pool
+	}
+	var c crawler.Crawler
+	switch {
+	case *cassetteDir != "":
+		// requests which weren't recorded are made with the recorder, or fail if offline
+		var recorder interface {
+			Do(*http.Request) (*http.Response, error)
+		} = client
+		if *offline {
+			recorder = nil
+		}
+		cassette, err := crawler.NewCassette(*cassetteDir, recorder)
+		if err != nil {
+			fatal("error opening cassette", "path", *cassetteDir, "error", err.Error())
+		}
+		c = crawler.New(workers, cassette, opts...)
+	case *offline:
+		fatal("-offline requires -cassette")
+	default:
+		c = crawler.New(workers, client, opts...)
+	}
+	handlePauseSignals(c)
+
+	// interrupting the crawl stops it early, but still reports on the pages crawled so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	_, err = c.CrawlToSink(ctx, seeds, sink)
+	stop()
+	flushTraces()
+	interrupted := err == context.Canceled
+	if err != nil && !interrupted {
+		slog.Error("error crawling", "seeds", seeds, "error", err.Error())
+		os.Exit(exitCrawlFailed)
+	}
+	if interrupted {
+		slog.Warn("crawl interrupted")
+	}
+	os.Stderr.Write(summary.Marshal())
+	reportFiles := []struct {
+		path  string
+		write func(io.Writer, *crawler.Report) error
+	}{
+		{*markdownPath, writeMarkdownReport},
+		{*htmlPath, writeHTMLReport},
+		{*junitPath, writeJUnitReport},
+		{*manifestPath, writeManifest},
+		{*contactsPath, writeContactsReport},
+	}
+	for _, file := range reportFiles {
+		if file.path == "" {
+			continue
+		}
+		if err := writeReportFile(file.path, report, file.write); err != nil {
+			slog.Error("error writing report", "path", file.path, "error", err.Error())
+		}
+	}
+	if *githubAnnotations {
+		if err := writeGitHubAnnotations(os.Stderr, report); err != nil {
+			slog.Error("error writing GitHub annotations", "error", err.Error())
+		}
+		if err := appendGitHubStepSummary(report); err != nil {
+			slog.Error("error writing GitHub job summary", "error", err.Error())
+		}
+	}
+	if interrupted {
+		os.Exit(exitCrawlFailed)
+	}
+	os.Exit(exitCode(summary))
+}
+
+// exitCode returns the exit code for a crawl which completed with the given summary
+func exitCode(s *crawler.Summary) int {
+	switch {
+	case s.Errors > 0:
+		return exitHTTPErrors
+	case s.Limited > 0:
+		return exitLimited
+	default:
+		return exitOK
 	}
 }
 
 func mustGetEnv(k string) string {
 	v := os.Getenv(k)
 	if v == "" {
-		log.Fatalf("env var '%s' not set", k)
+		fatal("env var not set", "var", k)
 	}
 	return v
 }
+
+// getEnvInt returns the numeric value of an optional env var, or zero if it isn't set
+func getEnvInt(k string) int {
+	v := os.Getenv(k)
+	if v == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		fatal("env var is non-numeric", "var", k, "value", v)
+	}
+	return i
+}
+
+// getEnvDuration returns the duration an env var is set to, e.g. 500ms, or zero if it isn't set
+func getEnvDuration(k string) time.Duration {
+	v := os.Getenv(k)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		fatal("env var is not a duration, e.g. 500ms", "var", k, "value", v)
+	}
+	return d
+}
+
+// envPoliteness returns the politeness limits set by the POLITENESS_* env vars, with the config file's per-host limits
+func envPoliteness(hosts map[string]crawler.HostLimits) crawler.Politeness {
+	return crawler.Politeness{
+		Delay:         getEnvDuration("POLITENESS_DELAY"),
+		MaxConcurrent: getEnvInt("POLITENESS_MAX_CONCURRENT"),
+		GroupByIP:     os.Getenv("POLITENESS_BY_IP") == "true",
+		Hosts:         hosts,
+	}
+}
+
+// splitNonEmpty splits s on sep, returning nil rather than a single empty string if s is empty
+func splitNonEmpty(s, sep string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, sep)
+}
+
+// writeReportFile creates the file at path and writes the report to it with write
+func writeReportFile(path string, r *crawler.Report, write func(io.Writer, *crawler.Report) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// isPiped reports whether f is a pipe or regular file rather than a terminal
+func isPiped(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// readSeedsFile reads newline separated seed URLs from the file at path, or stdin if path is "-"
+func readSeedsFile(path string) ([]string, error) {
+	r := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return readSeeds(r)
+}
+
+// readSeeds reads newline separated seed URLs, skipping blank lines and '#' comments
+func readSeeds(r io.Reader) ([]string, error) {
+	seeds := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		seeds = append(seeds, line)
+	}
+	return seeds, scanner.Err()
+}
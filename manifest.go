@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// manifestEntry describes the content of a URL crawled
+type manifestEntry struct {
+	Status       int        `json:"status"`
+	Size         int64      `json:"size"`
+	SHA256       string     `json:"sha256"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
+}
+
+// writeManifest writes a JSON object mapping each URL crawled to the hash, size and Last-Modified time of its content,
+// sorted by URL, as a baseline to check a site's integrity against or a list of URLs to warm a cache with. A URL
+// crawled more than once, by crawls running at once, has the content it had the last time.
+func writeManifest(w io.Writer, r *crawler.Report) error {
+	manifest := map[string]manifestEntry{}
+	for _, page := range r.Pages {
+		entry := manifestEntry{Status: page.StatusCode, Size: page.ContentLength, SHA256: page.ContentHash}
+		if !page.LastModified.IsZero() {
+			lastModified := page.LastModified.UTC()
+			entry.LastModified = &lastModified
+		}
+		manifest[page.URL] = entry
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(manifest)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteManifest(t *testing.T) {
+	report := &crawler.Report{
+		Pages: []crawler.PageRecord{
+			{URL: "http://monzo.com/", StatusCode: 200, ContentLength: 512, ContentHash: "abc123"},
+			{URL: "http://monzo.com/about", StatusCode: 200, ContentLength: 64, ContentHash: "def456", LastModified: time.Date(2018, 3, 1, 12, 0, 0, 0, time.FixedZone("BST", 3600))},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeManifest(out, report))
+	require.Equal(t, `{
+  "http://monzo.com/": {
+    "status": 200,
+    "size": 512,
+    "sha256": "abc123"
+  },
+  "http://monzo.com/about": {
+    "status": 200,
+    "size": 64,
+    "sha256": "def456",
+    "last_modified": "2018-03-01T11:00:00Z"
+  }
+}
+`, out.String())
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// markdownTopPages is the number of pages listed in a Markdown report's slowest and largest pages tables
+const markdownTopPages = 10
+
+// writeMarkdownReport renders a crawl's summary, broken links and alternates, other errors, mixed content, insecure
+// forms and cookies, subresource integrity, third-party domains, trackers, form endpoints, accessibility issues, images
+// without dimensions, anchor text, external links, links to redirects, duplicate titles and descriptions, sitemap comparison and slowest and largest
+// pages as a Markdown document
+func writeMarkdownReport(w io.Writer, r *crawler.Report) error {
+	var b strings.Builder
+
+	b.WriteString("# Crawl report\n\n## Summary\n\n| Pages | Errors | Skipped | Limited |\n| --- | --- | --- | --- |\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d |\n", r.Summary.Pages, r.Summary.Errors, r.Summary.Skipped, r.Summary.Limited)
+
+	if len(r.Summary.Languages) > 0 {
+		langs := make([]string, 0, len(r.Summary.Languages))
+		for lang := range r.Summary.Languages {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+		b.WriteString("\n| Language | Pages |\n| --- | --- |\n")
+		for _, lang := range langs {
+			fmt.Fprintf(&b, "| %s | %d |\n", markdownCell(lang), r.Summary.Languages[lang])
+		}
+	}
+
+	if len(r.Summary.Protocols) > 0 {
+		protos := make([]string, 0, len(r.Summary.Protocols))
+		for proto := range r.Summary.Protocols {
+			protos = append(protos, proto)
+		}
+		sort.Strings(protos)
+		b.WriteString("\n| Protocol | Pages |\n| --- | --- |\n")
+		for _, proto := range protos {
+			fmt.Fprintf(&b, "| %s | %d |\n", markdownCell(proto), r.Summary.Protocols[proto])
+		}
+	}
+
+	if len(r.Summary.ContentTypes) > 0 {
+		b.WriteString("\n| Content type | Pages | Bytes |\n| --- | --- | --- |\n")
+		for _, name := range r.Summary.SortedContentTypes() {
+			typ := r.Summary.ContentTypes[name]
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", markdownCell(name), typ.Pages, typ.Bytes)
+		}
+	}
+
+	if len(r.Summary.Hosts) > 0 {
+		b.WriteString("\n| Host | Pages | Errors | Bytes | Average latency | Statuses |\n| --- | --- | --- | --- | --- | --- |\n")
+		for _, host := range r.Summary.SortedHosts() {
+			h := r.Summary.Hosts[host]
+			codes := make([]int, 0, len(h.Statuses))
+			for code := range h.Statuses {
+				codes = append(codes, code)
+			}
+			sort.Ints(codes)
+			statuses := make([]string, 0, len(codes))
+			for _, code := range codes {
+				statuses = append(statuses, fmt.Sprintf("%d: %d", code, h.Statuses[code]))
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %s | %s |\n", markdownCell(host), h.Pages, h.Errors, h.Bytes, h.AverageLatency().Round(time.Millisecond), strings.Join(statuses, ", "))
+		}
+	}
+
+	if len(r.Summary.Traps) > 0 {
+		b.WriteString("\nCrawl traps detected:\n\n")
+		for _, trap := range r.Summary.Traps {
+			fmt.Fprintf(&b, "- `%s`\n", trap)
+		}
+	}
+
+	broken := r.BrokenLinks()
+	fmt.Fprintf(&b, "\n## Broken links (%d)\n\n", len(broken))
+	if len(broken) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| URL | Status | Linked from |\n| --- | --- | --- |\n")
+		for _, record := range broken {
+			status := fmt.Sprint(record.StatusCode)
+			switch record.Class {
+			case crawler.ErrorClassSoft404:
+				status += " (soft 404)"
+			case crawler.ErrorClassFragment:
+				status += " (missing anchor)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(record.URL), status, markdownCell(record.Referrer))
+		}
+	}
+
+	if alternates := r.BrokenAlternates(); len(alternates) > 0 {
+		fmt.Fprintf(&b, "\n## Broken alternates (%d)\n\n| Page | Alternate | Rel | Status |\n| --- | --- | --- | --- |\n", len(alternates))
+		for _, record := range alternates {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d |\n", markdownCell(record.Page), markdownCell(record.Alternate), record.Rel, record.Error.StatusCode)
+		}
+	}
+
+	other := []crawler.ErrorRecord{}
+	for _, record := range r.Errors {
+		if !record.Broken() {
+			other = append(other, record)
+		}
+	}
+	if len(other) > 0 {
+		fmt.Fprintf(&b, "\n## Other errors (%d)\n\n| URL | Class | Error | Linked from |\n| --- | --- | --- | --- |\n", len(other))
+		for _, record := range other {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", markdownCell(record.URL), record.Class, markdownCell(record.Error), markdownCell(record.Referrer))
+		}
+	}
+
+	if mixed := r.MixedContent(); len(mixed) > 0 {
+		fmt.Fprintf(&b, "\n## Mixed content (%d)\n\n| Page | Element | URL |\n| --- | --- | --- |\n", len(mixed))
+		for _, record := range mixed {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(record.Page), record.Element, markdownCell(record.URL))
+		}
+	}
+
+	if forms := r.InsecureForms(); len(forms) > 0 {
+		fmt.Fprintf(&b, "\n## Insecure forms (%d)\n\n| Page | Action | Method | Reason |\n| --- | --- | --- | --- |\n", len(forms))
+		for _, record := range forms {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", markdownCell(record.Page), markdownCell(record.Action), record.Method, record.Reason)
+		}
+	}
+
+	if cookies := r.CookieIssues(); len(cookies) > 0 {
+		fmt.Fprintf(&b, "\n## Insecure cookies (%d)\n\n| Host | Cookie | Missing | First seen on |\n| --- | --- | --- | --- |\n", len(cookies))
+		for _, record := range cookies {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", markdownCell(record.Host), markdownCell(record.Name), strings.Join(record.Missing, ", "), markdownCell(record.FirstSeen))
+		}
+	}
+
+	if subresources := r.Subresources(); len(subresources) > 0 {
+		covered := 0
+		for _, usage := range subresources {
+			if usage.Status != crawler.SRIMissing {
+				covered++
+			}
+		}
+		fmt.Fprintf(&b, "\n## Subresource integrity (%d of %d)\n\n| Resource | Element | Integrity | Pages | First seen on |\n| --- | --- | --- | --- | --- |\n", covered, len(subresources))
+		for _, usage := range subresources {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", markdownCell(usage.URL), usage.Element, usage.Status, usage.Pages, markdownCell(usage.FirstSeen))
+		}
+	}
+
+	if domains := r.ThirdPartyDomains(); len(domains) > 0 {
+		fmt.Fprintf(&b, "\n## Third-party domains (%d)\n\n| Domain | Pages | References | Elements | Example pages |\n| --- | --- | --- | --- | --- |\n", len(domains))
+		for _, usage := range domains {
+			fmt.Fprintf(&b, "| %s | %d | %d | %s | %s |\n", markdownCell(usage.Domain), usage.Pages, usage.References, strings.Join(usage.Elements, ", "), markdownCell(strings.Join(usage.Examples, ", ")))
+		}
+	}
+
+	if trackers := r.Trackers(); len(trackers) > 0 {
+		fmt.Fprintf(&b, "\n## Trackers (%d)\n\n| Tracker | Pages | Scripts | Example pages |\n| --- | --- | --- | --- |\n", len(trackers))
+		for _, usage := range trackers {
+			fmt.Fprintf(&b, "| %s | %d | %s | %s |\n", markdownCell(usage.Name), usage.Pages, markdownCell(strings.Join(usage.Scripts, ", ")), markdownCell(strings.Join(usage.Examples, ", ")))
+		}
+	}
+
+	if forms := r.FormEndpoints(); len(forms) > 0 {
+		fmt.Fprintf(&b, "\n## Form endpoints (%d)\n\n| Method | Action | Pages | Example pages |\n| --- | --- | --- | --- |\n", len(forms))
+		for _, endpoint := range forms {
+			fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", markdownCell(endpoint.Method), markdownCell(endpoint.Action), endpoint.Pages, markdownCell(strings.Join(endpoint.Examples, ", ")))
+		}
+	}
+
+	if pages := r.Accessibility(); len(pages) > 0 {
+		fmt.Fprintf(&b, "\n## Accessibility (%d)\n\n| Page | Images missing alt text | Empty links | Missing lang |\n| --- | --- | --- | --- |\n", len(pages))
+		for _, record := range pages {
+			lang := ""
+			if record.MissingLang {
+				lang = "yes"
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", markdownCell(record.Page), record.MissingAlt, record.EmptyLinks, lang)
+		}
+	}
+
+	if images := r.ImagesWithoutDimensions(); len(images) > 0 {
+		fmt.Fprintf(&b, "\n## Images without dimensions (%d)\n\n| Image | Pages | Example pages |\n| --- | --- | --- |\n", len(images))
+		for _, usage := range images {
+			fmt.Fprintf(&b, "| %s | %d | %s |\n", markdownCell(usage.URL), usage.Pages, markdownCell(strings.Join(usage.Examples, ", ")))
+		}
+	}
+
+	if usages := r.AnchorTexts(); len(usages) > 0 {
+		fmt.Fprintf(&b, "\n## Anchor text (%d)\n\n| URL | Links | Pages | Texts |\n| --- | --- | --- | --- |\n", len(usages))
+		for _, usage := range usages {
+			texts := make([]string, 0, len(usage.Texts))
+			for _, text := range usage.Texts {
+				if text.Text == "" {
+					text.Text = "(no text)"
+				}
+				texts = append(texts, fmt.Sprintf("%s (%d)", text.Text, text.Links))
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", markdownCell(usage.URL), usage.Links, usage.Pages, markdownCell(strings.Join(texts, ", ")))
+		}
+	}
+
+	if links := r.ExternalLinks(); len(links) > 0 {
+		fmt.Fprintf(&b, "\n## External links (%d)\n\n| URL | Status | Pages | Example pages |\n| --- | --- | --- | --- |\n", len(links))
+		for _, link := range links {
+			status := ""
+			if link.StatusCode != 0 {
+				status = strconv.Itoa(link.StatusCode)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %d | %s |\n", markdownCell(link.URL), status, link.Pages, markdownCell(strings.Join(link.Examples, ", ")))
+		}
+	}
+
+	if redirects := r.InternalRedirects(); len(redirects) > 0 {
+		fmt.Fprintf(&b, "\n## Links to redirects (%d)\n\n| Page | Link | Redirects to |\n| --- | --- | --- |\n", len(redirects))
+		for _, record := range redirects {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(record.Source), markdownCell(record.Target), markdownCell(record.Destination))
+		}
+	}
+
+	for _, duplicates := range []struct {
+		name    string
+		records []crawler.DuplicateRecord
+	}{
+		{"Title", r.DuplicateTitles()},
+		{"Description", r.DuplicateDescriptions()},
+	} {
+		if len(duplicates.records) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## Duplicate %ss (%d)\n\n| %s | Pages |\n| --- | --- |\n", strings.ToLower(duplicates.name), len(duplicates.records), duplicates.name)
+		for _, record := range duplicates.records {
+			fmt.Fprintf(&b, "| %s | %s |\n", markdownCell(record.Value), markdownCell(strings.Join(record.URLs, ", ")))
+		}
+	}
+
+	if orphans := r.Orphans(); len(orphans) > 0 {
+		fmt.Fprintf(&b, "\n## Orphan pages (%d)\n\nListed in the sitemap, but not linked to by any page crawled.\n\n", len(orphans))
+		for _, u := range orphans {
+			fmt.Fprintf(&b, "- %s\n", u)
+		}
+	}
+	if missing := r.MissingFromSitemap(); len(missing) > 0 {
+		fmt.Fprintf(&b, "\n## Missing from the sitemap (%d)\n\n| URL | Linked from |\n| --- | --- |\n", len(missing))
+		for _, page := range missing {
+			fmt.Fprintf(&b, "| %s | %s |\n", markdownCell(page.URL), markdownCell(page.Referrer))
+		}
+	}
+
+	if slowest := r.SlowestPages(markdownTopPages); len(slowest) > 0 {
+		b.WriteString("\n## Slowest pages\n\n| URL | Status | Duration | Size |\n| --- | --- | --- | --- |\n")
+		for _, page := range slowest {
+			fmt.Fprintf(&b, "| %s | %d | %s | %d |\n", markdownCell(page.URL), page.StatusCode, page.FetchDuration, page.ContentLength)
+		}
+	}
+
+	if largest := r.LargestPages(markdownTopPages); len(largest) > 0 {
+		b.WriteString("\n## Largest pages\n\n| URL | Status | Size | Duration |\n| --- | --- | --- | --- |\n")
+		for _, page := range largest {
+			fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", markdownCell(page.URL), page.StatusCode, page.ContentLength, page.FetchDuration)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes s for a Markdown table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
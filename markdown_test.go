@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMarkdownReport(t *testing.T) {
+	report := &crawler.Report{
+		Summary: crawler.Summary{Pages: 2, Errors: 2, Languages: map[string]int{"en": 2}, Protocols: map[string]int{"HTTP/3.0": 1, "HTTP/2.0": 1},
+			ContentTypes: map[string]*crawler.ContentTypeSummary{"text/html": {Pages: 2, Bytes: 1536}},
+			Hosts: map[string]*crawler.HostSummary{
+				"monzo.com": {Pages: 2, Errors: 2, Bytes: 1536, FetchDuration: 1100 * time.Millisecond, Statuses: map[int]int{200: 2, 404: 1}},
+			},
+		},
+		Pages: []crawler.PageRecord{
+			{URL: "http://monzo.com/", StatusCode: 200, FetchDuration: 100 * time.Millisecond, ContentLength: 512, AMP: "http://monzo.com/missing", Title: "Monzo", ThirdParty: []crawler.ThirdPartyRecord{
+				{Element: "script", URL: "https://cdn.example.com/app.js"},
+			}, Trackers: []crawler.TrackerRecord{
+				{Name: "Google Analytics", URL: "https://www.google-analytics.com/analytics.js"},
+			}, Forms: []crawler.FormRecord{
+				{Method: "GET", Action: "http://monzo.com/search"},
+			}, Accessibility: []crawler.AccessibilityIssue{
+				{Check: crawler.AccessibilityMissingAlt, Target: "http://monzo.com/logo.png"},
+				{Check: crawler.AccessibilityMissingLang},
+			}, Images: []crawler.ImageRecord{
+				{URL: "http://monzo.com/logo.png", Width: "120", Height: "40"},
+				{URL: "http://monzo.com/hero.jpg", Alt: "Cards", HasAlt: true, Width: "100%"},
+			}, AnchorTexts: []crawler.AnchorTextRecord{
+				{URL: "http://monzo.com/slow", Text: "Slow"},
+				{URL: "http://monzo.com/slow"},
+			}},
+			{URL: "http://monzo.com/slow", StatusCode: 200, FetchDuration: time.Second, ContentLength: 1024, Title: "Monzo"},
+			{URL: "http://monzo.com/old", StatusCode: 200, RedirectedTo: "http://monzo.com/slow", MixedContent: []crawler.MixedContentRecord{
+				{Page: "http://monzo.com/old", Element: "script", URL: "http://cdn.monzo.com/app.js"},
+			}, Subresources: []crawler.SubresourceRecord{
+				{Element: "script", URL: "https://cdn.example.com/app.js", Integrity: "sha384-abc", Status: crawler.SRIValid},
+				{Element: "link", URL: "https://cdn.example.com/style.css", Status: crawler.SRIMissing},
+			}, ThirdParty: []crawler.ThirdPartyRecord{
+				{Element: "a", URL: "https://twitter.com/monzo"},
+				{Element: "script", URL: "https://cdn.example.com/app.js"},
+			}, Cookies: []crawler.Cookie{{Name: "session", HttpOnly: true}}, InsecureForms: []crawler.InsecureFormRecord{
+				{Page: "http://monzo.com/old", Action: "http://monzo.com/login", Method: "POST", Reason: crawler.InsecureFormHTTP},
+			}},
+		},
+		Errors: []crawler.ErrorRecord{
+			{URL: "http://monzo.com/missing", Referrer: "http://monzo.com/", StatusCode: 404, Class: crawler.ErrorClassHTTPStatus},
+			{URL: "http://monzo.com/timeout", Referrer: "http://monzo.com/", Class: crawler.ErrorClassTimeout, Error: "a | b"},
+			{URL: "http://monzo.com/slow#faq", Referrer: "http://monzo.com/", StatusCode: 200, Class: crawler.ErrorClassFragment, Error: "broken fragment"},
+		},
+		Sitemap:    []string{"http://monzo.com/", "http://monzo.com/orphan"},
+		LinkedFrom: map[string][]string{"http://monzo.com/old": {"http://monzo.com/"}, "https://twitter.com/monzo": {"http://monzo.com/"}},
+		External:   []string{"https://twitter.com/monzo"},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, writeMarkdownReport(out, report))
+	require.Equal(t, `# Crawl report
+
+## Summary
+
+| Pages | Errors | Skipped | Limited |
+| --- | --- | --- | --- |
+| 2 | 2 | 0 | 0 |
+
+| Language | Pages |
+| --- | --- |
+| en | 2 |
+
+| Protocol | Pages |
+| --- | --- |
+| HTTP/2.0 | 1 |
+| HTTP/3.0 | 1 |
+
+| Content type | Pages | Bytes |
+| --- | --- | --- |
+| text/html | 2 | 1536 |
+
+| Host | Pages | Errors | Bytes | Average latency | Statuses |
+| --- | --- | --- | --- | --- | --- |
+| monzo.com | 2 | 2 | 1536 | 550ms | 200: 2, 404: 1 |
+
+## Broken links (2)
+
+| URL | Status | Linked from |
+| --- | --- | --- |
+| http://monzo.com/missing | 404 | http://monzo.com/ |
+| http://monzo.com/slow#faq | 200 (missing anchor) | http://monzo.com/ |
+
+## Broken alternates (1)
+
+| Page | Alternate | Rel | Status |
+| --- | --- | --- | --- |
+| http://monzo.com/ | http://monzo.com/missing | amphtml | 404 |
+
+## Other errors (1)
+
+| URL | Class | Error | Linked from |
+| --- | --- | --- | --- |
+| http://monzo.com/timeout | timeout | a \| b | http://monzo.com/ |
+
+## Mixed content (1)
+
+| Page | Element | URL |
+| --- | --- | --- |
+| http://monzo.com/old | script | http://cdn.monzo.com/app.js |
+
+## Insecure forms (1)
+
+| Page | Action | Method | Reason |
+| --- | --- | --- | --- |
+| http://monzo.com/old | http://monzo.com/login | POST | http |
+
+## Insecure cookies (1)
+
+| Host | Cookie | Missing | First seen on |
+| --- | --- | --- | --- |
+| monzo.com | session | Secure, SameSite | http://monzo.com/old |
+
+## Subresource integrity (1 of 2)
+
+| Resource | Element | Integrity | Pages | First seen on |
+| --- | --- | --- | --- | --- |
+| https://cdn.example.com/app.js | script | valid | 1 | http://monzo.com/old |
+| https://cdn.example.com/style.css | link | missing | 1 | http://monzo.com/old |
+
+## Third-party domains (2)
+
+| Domain | Pages | References | Elements | Example pages |
+| --- | --- | --- | --- | --- |
+| cdn.example.com | 2 | 2 | script | http://monzo.com/, http://monzo.com/old |
+| twitter.com | 1 | 1 | a | http://monzo.com/old |
+
+## Trackers (1)
+
+| Tracker | Pages | Scripts | Example pages |
+| --- | --- | --- | --- |
+| Google Analytics | 1 | https://www.google-analytics.com/analytics.js | http://monzo.com/ |
+
+## Form endpoints (1)
+
+| Method | Action | Pages | Example pages |
+| --- | --- | --- | --- |
+| GET | http://monzo.com/search | 1 | http://monzo.com/ |
+
+## Accessibility (1)
+
+| Page | Images missing alt text | Empty links | Missing lang |
+| --- | --- | --- | --- |
+| http://monzo.com/ | 1 | 0 | yes |
+
+## Images without dimensions (1)
+
+| Image | Pages | Example pages |
+| --- | --- | --- |
+| http://monzo.com/hero.jpg | 1 | http://monzo.com/ |
+
+## Anchor text (1)
+
+| URL | Links | Pages | Texts |
+| --- | --- | --- | --- |
+| http://monzo.com/slow | 2 | 1 | (no text) (1), Slow (1) |
+
+## External links (1)
+
+| URL | Status | Pages | Example pages |
+| --- | --- | --- | --- |
+| https://twitter.com/monzo |  | 1 | http://monzo.com/ |
+
+## Links to redirects (1)
+
+| Page | Link | Redirects to |
+| --- | --- | --- |
+| http://monzo.com/ | http://monzo.com/old | http://monzo.com/slow |
+
+## Duplicate titles (1)
+
+| Title | Pages |
+| --- | --- |
+| Monzo | http://monzo.com/, http://monzo.com/slow |
+
+## Orphan pages (1)
+
+Listed in the sitemap, but not linked to by any page crawled.
+
+- http://monzo.com/orphan
+
+## Missing from the sitemap (1)
+
+| URL | Linked from |
+| --- | --- |
+| http://monzo.com/slow |  |
+
+## Slowest pages
+
+| URL | Status | Duration | Size |
+| --- | --- | --- | --- |
+| http://monzo.com/slow | 200 | 1s | 1024 |
+| http://monzo.com/ | 200 | 100ms | 512 |
+| http://monzo.com/old | 200 | 0s | 0 |
+
+## Largest pages
+
+| URL | Status | Size | Duration |
+| --- | --- | --- | --- |
+| http://monzo.com/slow | 200 | 1024 | 1s |
+| http://monzo.com/ | 200 | 512 | 100ms |
+| http://monzo.com/old | 200 | 0 | 0s |
+`, out.String())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// outputSink writes the crawl's pages to the output opened by openOutput, closing it once the crawl has finished
+type outputSink struct {
+	crawler.Sink
+	close func() error
+}
+
+func (s *outputSink) Close() error {
+	return s.close()
+}
+
+// openOutput returns the writer to write the crawl's pages to: the file at path, or stdout if path is empty, compressed
+// with gzip as it's written if compress is set or path ends in ".gz". The returned function finishes the gzip stream
+// and closes the file, so must be called once the crawl has finished.
+func openOutput(path string, compress bool) (io.Writer, func() error, error) {
+	out, closeFile := io.Writer(os.Stdout), func() error { return nil }
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closeFile = f, f.Close
+	}
+	if !compress && !strings.HasSuffix(path, ".gz") {
+		return out, closeFile, nil
+	}
+
+	gz := gzip.NewWriter(out)
+	return gz, func() error {
+		err := gz.Close()
+		if closeErr := closeFile(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
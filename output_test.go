@@ -0,0 +1,47 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenOutput(t *testing.T) {
+	dir := t.TempDir()
+	read := func(path string, compressed bool) string {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		r := io.Reader(f)
+		if compressed {
+			gz, err := gzip.NewReader(f)
+			require.NoError(t, err)
+			r = gz
+		}
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	for _, test := range []struct {
+		name       string
+		compress   bool
+		compressed bool
+	}{
+		{"pages.txt", false, false},
+		{"pages.txt.gz", false, true},
+		{"pages.ndjson", true, true},
+	} {
+		path := filepath.Join(dir, test.name)
+		out, closeOutput, err := openOutput(path, test.compress)
+		require.NoError(t, err)
+		_, err = io.WriteString(out, "URL:\n\thttp://monzo.com/\n")
+		require.NoError(t, err)
+		require.NoError(t, closeOutput())
+		require.Equal(t, "URL:\n\thttp://monzo.com/\n", read(path, test.compressed), test.name)
+	}
+}
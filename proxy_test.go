@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// socksServer is a minimal SOCKS5 proxy requiring a username and password, which records the address of each
+// connection it's asked to make
+type socksServer struct {
+	net.Listener
+	user, password string
+
+	mu      sync.Mutex
+	targets []string
+}
+
+func newSOCKSServer(t *testing.T, user, password string) *socksServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &socksServer{Listener: ln, user: user, password: password}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socksServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// greeting, offering username and password authentication
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	conn.Write([]byte{5, 2})
+
+	readString := func() string {
+		n := make([]byte, 1)
+		io.ReadFull(conn, n)
+		b := make([]byte, n[0])
+		io.ReadFull(conn, b)
+		return string(b)
+	}
+	io.ReadFull(conn, make([]byte, 1))
+	if readString() != s.user || readString() != s.password {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		host = readString()
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+	s.mu.Lock()
+	s.targets = append(s.targets, target)
+	s.mu.Unlock()
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+// lastTarget returns the address of the last connection the proxy was asked to make
+func (s *socksServer) lastTarget() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.targets[len(s.targets)-1]
+}
+
+func TestSOCKSTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "proxied")
+	}))
+	defer srv.Close()
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	proxy := newSOCKSServer(t, "user", "secret")
+	defer proxy.Close()
+
+	get := func(t *testing.T, proxyURL string) {
+		transport, err := socksTransport(proxyURL)
+		require.NoError(t, err)
+		resp, err := (&http.Client{Transport: transport}).Get("http://localhost:" + port + "/")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "proxied", string(body))
+	}
+
+	t.Run("remote resolution", func(t *testing.T) {
+		get(t, "socks5h://user:secret@"+proxy.Addr().String())
+		require.Equal(t, "localhost:"+port, proxy.lastTarget())
+	})
+
+	t.Run("local resolution", func(t *testing.T) {
+		get(t, "socks5://user:secret@"+proxy.Addr().String())
+		require.Equal(t, "127.0.0.1:"+port, proxy.lastTarget())
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		transport, err := socksTransport("socks5h://user:wrong@" + proxy.Addr().String())
+		require.NoError(t, err)
+		_, err = (&http.Client{Transport: transport}).Get("http://localhost:" + port + "/")
+		require.Error(t, err)
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := socksTransport("http://" + proxy.Addr().String())
+		require.EqualError(t, err, `unsupported proxy scheme "http", expected socks5 or socks5h`)
+	})
+}
+
+func TestProxyPool(t *testing.T) {
+	newProxy := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name)
+		}))
+	}
+	a, b := newProxy("a"), newProxy("b")
+	defer a.Close()
+	defer b.Close()
+	dead := newProxy("dead")
+	dead.Close()
+
+	get := func(t *testing.T, client *http.Client) string {
+		resp, err := client.Get("http://monzo.com/")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("weighted round robin", func(t *testing.T) {
+		pool, err := newProxyPool([]string{a.URL + "=2", b.URL}, defaultProxyMaxFailures)
+		require.NoError(t, err)
+		client := &http.Client{Transport: pool}
+
+		via := []string{}
+		for i := 0; i < 6; i++ {
+			via = append(via, get(t, client))
+		}
+		require.Equal(t, []string{"a", "b", "a", "a", "b", "a"}, via)
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		pool, err := newProxyPool([]string{dead.URL, a.URL}, 2)
+		require.NoError(t, err)
+		client := &http.Client{Transport: pool}
+
+		// requests through the dead proxy are retried through a until it's evicted
+		for i := 0; i < 4; i++ {
+			require.Equal(t, "a", get(t, client))
+		}
+		require.Equal(t, 1, pool.size())
+	})
+
+	t.Run("every proxy evicted", func(t *testing.T) {
+		pool, err := newProxyPool([]string{dead.URL}, 1)
+		require.NoError(t, err)
+		client := &http.Client{Transport: pool}
+
+		_, err = client.Get("http://monzo.com/")
+		require.Error(t, err)
+		_, err = client.Get("http://monzo.com/")
+		require.ErrorIs(t, err, errNoProxies)
+	})
+
+	t.Run("invalid weight", func(t *testing.T) {
+		_, err := newProxyPool([]string{a.URL + "=0"}, defaultProxyMaxFailures)
+		require.EqualError(t, err, fmt.Sprintf("invalid weight for proxy %q, expected a positive integer", a.URL))
+	})
+}
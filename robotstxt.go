@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// runRobots implements the robots command, testing whether URLs may be crawled under a robots.txt file and writing
+// whether each is allowed or blocked, and by which rule, to w. It returns the exit code.
+func runRobots(args []string, w io.Writer) int {
+	fs := flag.NewFlagSet("robots", flag.ContinueOnError)
+	robotsPath := fs.String("robots", "", "robots.txt file or URL to test every URL against, rather than fetching each URL's site's own")
+	userAgent := fs.String("user-agent", "", "User-Agent to test the URLs for, USER_AGENT or the crawler's own by default")
+	crawlPath := fs.String("crawl", "", "crawl output whose pages and links to test, rather than URLs given as arguments or on stdin")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: web_crawler robots [-robots FILE|URL] [-user-agent UA] [-crawl OUTPUT | URL...]")
+		fs.PrintDefaults()
+	}
+	if code, exit := parseFlags(fs, args); exit {
+		return code
+	}
+
+	if *userAgent == "" {
+		*userAgent = os.Getenv("USER_AGENT")
+	}
+	if *userAgent == "" {
+		*userAgent = crawler.DefaultUserAgent
+	}
+
+	var urls []string
+	var err error
+	switch {
+	case fs.NArg() > 0:
+		urls = fs.Args()
+	case *crawlPath != "":
+		pages, err := readPagesFile(*crawlPath)
+		if err != nil {
+			fatal("error reading crawl output", "path", *crawlPath, "error", err.Error())
+		}
+		urls = crawledURLs(pages)
+	default:
+		if urls, err = readSeeds(os.Stdin); err != nil {
+			fatal("error reading URLs", "error", err.Error())
+		}
+	}
+
+	tester := &robotsTester{client: &http.Client{Timeout: time.Second * 2}, userAgent: *userAgent, sites: map[string]*robotsSite{}}
+	if *robotsPath != "" {
+		robots, err := tester.load(*robotsPath)
+		if err != nil {
+			fatal("error reading robots.txt", "path", *robotsPath, "error", err.Error())
+		}
+		tester.fixed = &robotsSite{robots: robots}
+	}
+
+	blocked := false
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil || !u.IsAbs() {
+			fatal("invalid URL", "url", rawURL)
+		}
+		allowed, reason := tester.test(u)
+		verdict := "allowed"
+		if !allowed {
+			verdict = "blocked"
+			blocked = true
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", verdict, rawURL, reason); err != nil {
+			return exitCrawlFailed
+		}
+	}
+	if blocked {
+		return exitHTTPErrors
+	}
+	return exitOK
+}
+
+// crawledURLs returns the URLs of the pages of a crawl's output and of the links they had, once each, in the order
+// they're first seen
+func crawledURLs(pages []*crawler.Page) []string {
+	urls := []string{}
+	seen := map[string]bool{}
+	add := func(u *url.URL) {
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			urls = append(urls, u.String())
+		}
+	}
+	for _, page := range pages {
+		add(page.URL)
+		for _, link := range page.Links {
+			add(link)
+		}
+	}
+	return urls
+}
+
+// robotsTester tests URLs against a robots.txt file, either the one given with -robots or each site's own, fetched the
+// first time one of its URLs is tested
+type robotsTester struct {
+	client    *http.Client
+	userAgent string
+	fixed     *robotsSite            // nil unless -robots is given
+	sites     map[string]*robotsSite // by scheme and host
+}
+
+// robotsSite is a site's robots.txt, or why it couldn't be fetched
+type robotsSite struct {
+	robots *crawler.RobotsTxt
+	err    error
+}
+
+// test returns whether u may be crawled, and why
+func (t *robotsTester) test(u *url.URL) (bool, string) {
+	site := t.fixed
+	if site == nil {
+		origin := u.Scheme + "://" + u.Host
+		if site = t.sites[origin]; site == nil {
+			robots, err := t.load(origin + "/robots.txt")
+			site = &robotsSite{robots: robots, err: err}
+			t.sites[origin] = site
+		}
+	}
+
+	// a site whose robots.txt is unreachable is assumed to disallow everything, as RFC 9309 requires
+	if site.err != nil {
+		return false, "robots.txt unreachable: " + site.err.Error()
+	}
+	allowed, rule := site.robots.Test(t.userAgent, u)
+	if rule == nil {
+		return allowed, "no matching rule"
+	}
+	return allowed, fmt.Sprintf("%s (line %d)", rule, rule.Line)
+}
+
+// load reads a robots.txt file from a path, or fetches it from an http or https URL. A robots.txt which doesn't exist,
+// responding with a 4xx status code, allows everything.
+func (t *robotsTester) load(path string) (*crawler.RobotsTxt, error) {
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return crawler.ParseRobotsTxt(f)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return crawler.ParseRobotsTxt(resp.Body)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &crawler.RobotsTxt{}, nil
+	default:
+		return nil, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRobots(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+	}))
+	defer srv.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	t.Run("site's own", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		code := runRobots([]string{srv.URL + "/", srv.URL + "/private/a", missing.URL + "/private/a", broken.URL + "/"}, out)
+		require.Equal(t, exitHTTPErrors, code)
+		require.Equal(t, []string{
+			"allowed\t" + srv.URL + "/\tno matching rule",
+			"blocked\t" + srv.URL + "/private/a\tDisallow: /private/ (line 2)",
+			"allowed\t" + missing.URL + "/private/a\tno matching rule",
+			"blocked\t" + broken.URL + "/\trobots.txt unreachable: status code 503",
+		}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+	})
+
+	t.Run("local file and crawl output", func(t *testing.T) {
+		dir := t.TempDir()
+		robotsPath := filepath.Join(dir, "robots.txt")
+		require.NoError(t, os.WriteFile(robotsPath, []byte("User-agent: googlebot\nDisallow: /\n"), 0o644))
+		crawlPath := filepath.Join(dir, "pages.txt")
+		page := &crawler.Page{
+			URL:   &url.URL{Scheme: "http", Host: "monzo.com", Path: "/"},
+			Links: []*url.URL{{Scheme: "http", Host: "monzo.com", Path: "/about"}, {Scheme: "http", Host: "monzo.com", Path: "/"}},
+		}
+		require.NoError(t, os.WriteFile(crawlPath, page.Marshal(), 0o644))
+
+		out := &bytes.Buffer{}
+		require.Equal(t, exitOK, runRobots([]string{"-robots", robotsPath, "-crawl", crawlPath}, out))
+		require.Equal(t, "allowed\thttp://monzo.com/\tno matching rule\nallowed\thttp://monzo.com/about\tno matching rule\n", out.String())
+
+		out.Reset()
+		require.Equal(t, exitHTTPErrors, runRobots([]string{"-robots", robotsPath, "-user-agent", "Googlebot/2.1", "http://monzo.com/about"}, out))
+		require.Equal(t, "blocked\thttp://monzo.com/about\tDisallow: / (line 2)\n", out.String())
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		require.Equal(t, exitConfig, runRobots([]string{"-robot", "robots.txt"}, io.Discard), "a misconfigured run shouldn't exit as if URLs were blocked")
+		require.Equal(t, exitOK, runRobots([]string{"-h"}, io.Discard))
+	})
+}
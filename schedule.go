@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are the shorthands accepted in place of a cron expression
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// siteSchedule is a site crawled periodically by the daemon, from the JSON file given with serve -schedules
+type siteSchedule struct {
+	Name     string   `json:"name"`
+	Cron     string   `json:"cron"` // e.g. "*/30 * * * *", or a macro such as "@daily"
+	Seeds    []string `json:"seeds"`
+	Workers  int      `json:"workers"`
+	MaxPages int      `json:"max_pages"`
+	Keep     int      `json:"keep"`    // the number of finished jobs to keep, zero for all of them
+	MaxAge   string   `json:"max_age"` // how long to keep finished jobs, e.g. "720h", empty for ever
+
+	cron   *cronSchedule
+	maxAge time.Duration
+}
+
+// loadSchedules reads and validates the schedules file at path
+func loadSchedules(path string) ([]*siteSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var schedules []*siteSchedule
+	if err := dec.Decode(&schedules); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line, col := position(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %s", path, line, col, err)
+		}
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	problems := []string{}
+	names := map[string]bool{}
+	for i, s := range schedules {
+		if s.Name == "" {
+			problems = append(problems, fmt.Sprintf("schedules[%d].name is required", i))
+		} else if names[s.Name] {
+			problems = append(problems, fmt.Sprintf("schedules[%d].name %q is used by another schedule", i, s.Name))
+		}
+		names[s.Name] = true
+		if len(s.Seeds) == 0 {
+			problems = append(problems, fmt.Sprintf("schedules[%d].seeds needs at least one seed", i))
+		}
+		if s.cron, err = parseCron(s.Cron); err != nil {
+			problems = append(problems, fmt.Sprintf("schedules[%d].cron %q is invalid: %s", i, s.Cron, err))
+		}
+		if s.Keep < 0 {
+			problems = append(problems, fmt.Sprintf("schedules[%d].keep must not be negative", i))
+		}
+		if s.MaxAge != "" {
+			if s.maxAge, err = time.ParseDuration(s.MaxAge); err != nil || s.maxAge <= 0 {
+				problems = append(problems, fmt.Sprintf("schedules[%d].max_age %q is not a positive duration, e.g. 720h", i, s.MaxAge))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s is invalid:\n\t%s", path, strings.Join(problems, "\n\t"))
+	}
+	return schedules, nil
+}
+
+// cronSchedule is a parsed cron expression, each field a set of the values it matches
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// as in cron, a day matches if either its day of the month or of the week does, unless one of them is *
+	domAny, dowAny bool
+}
+
+// parseCron parses a standard five field cron expression, "minute hour day-of-month month day-of-week", each field
+// being *, a value, a range such as 1-5, or a list of them, optionally with a step such as */15
+func parseCron(expr string) (*cronSchedule, error) {
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	c := &cronSchedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	for i, field := range []struct {
+		set      *uint64
+		min, max int
+		name     string
+	}{
+		{&c.minute, 0, 59, "minute"},
+		{&c.hour, 0, 23, "hour"},
+		{&c.dom, 1, 31, "day of the month"},
+		{&c.month, 1, 12, "month"},
+		{&c.dow, 0, 7, "day of the week"},
+	} {
+		set, err := parseCronField(fields[i], field.min, field.max)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", field.name, err)
+		}
+		*field.set = set
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is also Sunday
+	}
+	return c, nil
+}
+
+// parseCronField returns the set of values from min to max a field matches
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step > 1 {
+				hi = max // as in cron, 5/15 means from 5 to the maximum every 15
+			}
+			if lo < min || hi > max || lo > hi {
+				return 0, fmt.Errorf("%q is out of the range %d-%d", part, min, max)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// matches reports whether the schedule is due in the minute of t
+func (c *cronSchedule) matches(t time.Time) bool {
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// schedule submits a job for each schedule whenever it's due, until ctx is done. Schedules are checked at the start of
+// every minute, in local time.
+func (d *daemon) schedule(ctx context.Context, schedules []*siteSchedule) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		select {
+		case minute := <-timer.C:
+			d.runSchedules(minute.Truncate(time.Minute), schedules)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// runSchedules submits a job for each schedule due at minute, unless its last job is still queued or running, and
+// removes the jobs of each schedule which are past its retention
+func (d *daemon) runSchedules(minute time.Time, schedules []*siteSchedule) {
+	for _, s := range schedules {
+		d.prune(s, minute)
+		if !s.cron.matches(minute) {
+			continue
+		}
+		if d.scheduled(s) {
+			slog.Warn("skipping scheduled crawl, the last one hasn't finished", "schedule", s.Name)
+			continue
+		}
+		j, err := d.submit(jobSpec{Seeds: s.Seeds, Workers: s.Workers, MaxPages: s.MaxPages}, s.Name)
+		if err != nil {
+			slog.Error("error submitting scheduled crawl", "schedule", s.Name, "error", err.Error())
+			continue
+		}
+		slog.Info("scheduled crawl submitted", "schedule", s.Name, "job", j.ID)
+	}
+}
+
+// scheduled reports whether a job of a schedule is queued or running
+func (d *daemon) scheduled(s *siteSchedule) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, j := range d.jobs {
+		if j.Schedule == s.Name && !j.finished() {
+			return true
+		}
+	}
+	return false
+}
+
+// prune removes the finished jobs of a schedule beyond the number it keeps or older than its maximum age, along with
+// their output
+func (d *daemon) prune(s *siteSchedule, now time.Time) {
+	if s.Keep == 0 && s.maxAge == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	finished := []*job{}
+	for _, j := range d.jobs {
+		if j.Schedule == s.Name && j.finished() {
+			finished = append(finished, j)
+		}
+	}
+	sort.Slice(finished, func(a, b int) bool {
+		return finished[a].Submitted.After(finished[b].Submitted)
+	})
+	expired := []*job{}
+	for i, j := range finished {
+		if (s.Keep > 0 && i >= s.Keep) || (s.maxAge > 0 && now.Sub(j.Submitted) > s.maxAge) {
+			expired = append(expired, j)
+			delete(d.jobs, j.ID)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, j := range expired {
+		if err := os.RemoveAll(filepath.Join(d.dir, j.ID)); err != nil {
+			slog.Error("error removing expired job", "schedule", s.Name, "job", j.ID, "error", err.Error())
+			continue
+		}
+		slog.Info("removed expired job", "schedule", s.Name, "job", j.ID)
+	}
+}
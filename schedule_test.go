@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return tm
+	}
+
+	for _, tt := range []struct {
+		expr    string
+		matches []string
+		misses  []string
+	}{
+		{"*/15 * * * *", []string{"2018-03-01 12:00", "2018-03-01 12:45"}, []string{"2018-03-01 12:10"}},
+		{"30 9-17 * * 1-5", []string{"2018-03-01 09:30", "2018-03-02 17:30"}, []string{"2018-03-03 09:30", "2018-03-01 18:30", "2018-03-01 09:31"}},
+		{"0 0 1,15 * *", []string{"2018-03-01 00:00", "2018-03-15 00:00"}, []string{"2018-03-02 00:00"}},
+		{"0 0 1 * 0", []string{"2018-03-01 00:00", "2018-03-04 00:00"}, []string{"2018-03-05 00:00"}}, // the 1st or a Sunday
+		{"0 0 * * 7", []string{"2018-03-04 00:00"}, []string{"2018-03-05 00:00"}},
+		{"5/20 * * * *", []string{"2018-03-01 12:05", "2018-03-01 12:45"}, []string{"2018-03-01 12:00"}},
+		{"@daily", []string{"2018-03-01 00:00"}, []string{"2018-03-01 12:00"}},
+	} {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := parseCron(tt.expr)
+			require.NoError(t, err)
+			for _, s := range tt.matches {
+				require.True(t, c.matches(at(s)), s)
+			}
+			for _, s := range tt.misses {
+				require.False(t, c.matches(at(s)), s)
+			}
+		})
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@fortnightly"} {
+		_, err := parseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestLoadSchedules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"name": "monzo", "cron": "@hourly", "seeds": ["http://monzo.com"], "keep": 24, "max_age": "168h"},
+		{"name": "monzo", "cron": "every hour", "seeds": [], "max_age": "a week"}
+	]`), 0o644))
+
+	_, err := loadSchedules(path)
+	require.EqualError(t, err, path+` is invalid:
+	schedules[1].name "monzo" is used by another schedule
+	schedules[1].seeds needs at least one seed
+	schedules[1].cron "every hour" is invalid: expected 5 fields, got 2
+	schedules[1].max_age "a week" is not a positive duration, e.g. 720h`)
+}
+
+func TestRunSchedules(t *testing.T) {
+	d, err := newDaemon(t.TempDir(), 1, 1, http.DefaultClient)
+	require.NoError(t, err)
+	cron, err := parseCron("0 * * * *")
+	require.NoError(t, err)
+	s := &siteSchedule{Name: "monzo", Seeds: []string{"http://monzo.com"}, Keep: 2, cron: cron}
+	start := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	d.runSchedules(start.Add(time.Minute), []*siteSchedule{s})
+	require.Empty(t, d.jobs, "not due")
+
+	d.runSchedules(start, []*siteSchedule{s})
+	require.Len(t, d.jobs, 1)
+	first, _ := d.next(context.Background())
+	require.Equal(t, "monzo", first.Schedule)
+
+	d.runSchedules(start.Add(time.Hour), []*siteSchedule{s})
+	require.Len(t, d.jobs, 1, "shouldn't overlap the last crawl")
+
+	// finish crawls an hour apart, of which only the most recent two are kept
+	for i := 0; i < 3; i++ {
+		d.update(first, func() {
+			first.State = jobDone
+		})
+		d.runSchedules(start.Add(time.Duration(i+1)*time.Hour), []*siteSchedule{s})
+		next, _ := d.next(context.Background())
+		next.Submitted = first.Submitted.Add(time.Hour)
+		first = next
+	}
+	d.update(first, func() {
+		first.State = jobDone
+	})
+	d.prune(s, start.Add(4*time.Hour))
+	require.Len(t, d.jobs, 2)
+	entries, err := os.ReadDir(d.dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	s.Keep, s.maxAge = 0, 90*time.Minute
+	d.prune(s, first.Submitted.Add(time.Hour))
+	require.Len(t, d.jobs, 1, "only the most recent is younger than max age")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// stringsFlag is a flag which may be given more than once, collecting each value
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// searchPatterns compiles literal search strings and regular expressions into the patterns given to WithSearch
+func searchPatterns(literals, exprs []string) ([]*regexp.Regexp, error) {
+	patterns := []*regexp.Regexp{}
+	for _, literal := range literals {
+		patterns = append(patterns, regexp.MustCompile(regexp.QuoteMeta(literal)))
+	}
+	for _, expr := range exprs {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// reportMatches returns a subscriber writing the URL of each page with search matches to w, followed by the context
+// of each match
+func reportMatches(w io.Writer) func(crawler.Event) {
+	return func(e crawler.Event) {
+		parsed, ok := e.(crawler.PageParsed)
+		if !ok || len(parsed.Page.Matches) == 0 {
+			return
+		}
+		fmt.Fprintln(w, parsed.Page.URL)
+		for _, match := range parsed.Page.Matches {
+			fmt.Fprintf(w, "\t%s\n", match.Context)
+		}
+	}
+}
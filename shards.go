@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// shardedSink splits the crawl's pages across numbered files, e.g. "crawl-0001.ndjson", "crawl-0002.ndjson", starting
+// a new file once the current one has maxPages pages or another page would take it over maxBytes, and writes an index
+// of the files once the crawl has finished. Pages are never split across files.
+type shardedSink struct {
+	path     string // the -output path the files are numbered after
+	compress bool
+	format   func(*crawler.Page) ([]byte, error)
+	maxPages int
+	maxBytes int64 // of the pages as formatted, before compression
+
+	shards       []shardEntry
+	current      io.Writer
+	closeCurrent func() error
+}
+
+// shardEntry describes one of the files of a sharded output in its index
+type shardEntry struct {
+	File     string `json:"file"` // relative to the index
+	Pages    int    `json:"pages"`
+	Bytes    int64  `json:"bytes"` // before compression
+	FirstURL string `json:"first_url"`
+	LastURL  string `json:"last_url"`
+}
+
+func newShardedSink(path string, compress bool, format func(*crawler.Page) ([]byte, error), maxPages int, maxBytes int64) *shardedSink {
+	if format == nil {
+		format = func(p *crawler.Page) ([]byte, error) {
+			return p.Marshal(), nil
+		}
+	}
+	return &shardedSink{path: path, compress: compress, format: format, maxPages: maxPages, maxBytes: maxBytes}
+}
+
+func (s *shardedSink) Emit(p *crawler.Page) error {
+	formatted, err := s.format(p)
+	if err != nil {
+		return err
+	}
+	if s.current == nil || s.full(int64(len(formatted))) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	if _, err := s.current.Write(formatted); err != nil {
+		return err
+	}
+
+	shard := &s.shards[len(s.shards)-1]
+	if shard.Pages == 0 {
+		shard.FirstURL = p.URL.String()
+	}
+	shard.LastURL = p.URL.String()
+	shard.Pages++
+	shard.Bytes += int64(len(formatted))
+	return nil
+}
+
+// full reports whether the current file can't take another page of n bytes. A page larger than maxBytes is written to
+// a file of its own.
+func (s *shardedSink) full(n int64) bool {
+	shard := s.shards[len(s.shards)-1]
+	return (s.maxPages > 0 && shard.Pages >= s.maxPages) || (s.maxBytes > 0 && shard.Pages > 0 && shard.Bytes+n > s.maxBytes)
+}
+
+// rotate closes the current file, if any, and starts the next
+func (s *shardedSink) rotate() error {
+	if s.current != nil {
+		if err := s.closeCurrent(); err != nil {
+			return err
+		}
+	}
+	name, ext := splitOutputPath(s.path)
+	path := fmt.Sprintf("%s-%04d%s", name, len(s.shards)+1, ext)
+	out, closeOutput, err := openOutput(path, s.compress)
+	if err != nil {
+		return err
+	}
+	s.current, s.closeCurrent = out, closeOutput
+	s.shards = append(s.shards, shardEntry{File: filepath.Base(path)})
+	return nil
+}
+
+// Close closes the current file and writes the index, e.g. "crawl-index.json", listing each file with the number of
+// pages and bytes it has and the URLs of its first and last pages
+func (s *shardedSink) Close() error {
+	if s.current != nil {
+		if err := s.closeCurrent(); err != nil {
+			return err
+		}
+	}
+	name, _ := splitOutputPath(s.path)
+	f, err := os.Create(name + "-index.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	shards := s.shards
+	if shards == nil {
+		shards = []shardEntry{}
+	}
+	if err := enc.Encode(shards); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// splitOutputPath splits an output path in to the path the files of a sharded output are numbered after and their
+// extension, keeping ".gz" with the extension before it, e.g. "out/crawl" and ".txt.gz" for "out/crawl.txt.gz"
+func splitOutputPath(path string) (string, string) {
+	gz := ""
+	if strings.HasSuffix(path, ".gz") {
+		path, gz = strings.TrimSuffix(path, ".gz"), ".gz"
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext), ext + gz
+}
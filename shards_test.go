@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitOutputPath(t *testing.T) {
+	for path, expected := range map[string][2]string{
+		"crawl":              {"crawl", ""},
+		"out/crawl.ndjson":   {"out/crawl", ".ndjson"},
+		"out/crawl.txt.gz":   {"out/crawl", ".txt.gz"},
+		"out.d/crawl.gz":     {"out.d/crawl", ".gz"},
+		"out.d/crawl.ndjson": {"out.d/crawl", ".ndjson"},
+	} {
+		name, ext := splitOutputPath(path)
+		require.Equal(t, expected, [2]string{name, ext}, path)
+	}
+}
+
+func TestShardedSink(t *testing.T) {
+	page := func(rawURL string) *crawler.Page {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return &crawler.Page{URL: u}
+	}
+	format := func(p *crawler.Page) ([]byte, error) {
+		return []byte(p.URL.Path + "\n"), nil
+	}
+	readIndex := func(path string) []shardEntry {
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var shards []shardEntry
+		require.NoError(t, json.Unmarshal(b, &shards))
+		return shards
+	}
+
+	t.Run("by page count", func(t *testing.T) {
+		dir := t.TempDir()
+		sink := newShardedSink(filepath.Join(dir, "crawl.ndjson"), false, format, 2, 0)
+		for _, path := range []string{"/", "/about", "/careers", "/blog", "/help"} {
+			require.NoError(t, sink.Emit(page("http://monzo.com"+path)))
+		}
+		require.NoError(t, sink.Close())
+
+		require.Equal(t, []shardEntry{
+			{File: "crawl-0001.ndjson", Pages: 2, Bytes: 9, FirstURL: "http://monzo.com/", LastURL: "http://monzo.com/about"},
+			{File: "crawl-0002.ndjson", Pages: 2, Bytes: 15, FirstURL: "http://monzo.com/careers", LastURL: "http://monzo.com/blog"},
+			{File: "crawl-0003.ndjson", Pages: 1, Bytes: 6, FirstURL: "http://monzo.com/help", LastURL: "http://monzo.com/help"},
+		}, readIndex(filepath.Join(dir, "crawl-index.json")))
+		b, err := os.ReadFile(filepath.Join(dir, "crawl-0002.ndjson"))
+		require.NoError(t, err)
+		require.Equal(t, "/careers\n/blog\n", string(b))
+	})
+
+	t.Run("by size", func(t *testing.T) {
+		dir := t.TempDir()
+		sink := newShardedSink(filepath.Join(dir, "crawl.txt.gz"), false, format, 0, 10)
+		// "/careers-at-monzo\n" is larger than the limit, so has a file of its own
+		for _, path := range []string{"/", "/about", "/careers-at-monzo", "/blog"} {
+			require.NoError(t, sink.Emit(page("http://monzo.com"+path)))
+		}
+		require.NoError(t, sink.Close())
+
+		shards := readIndex(filepath.Join(dir, "crawl-index.json"))
+		require.Len(t, shards, 3)
+		require.Equal(t, "crawl-0001.txt.gz", shards[0].File)
+		require.Equal(t, []int{2, 1, 1}, []int{shards[0].Pages, shards[1].Pages, shards[2].Pages})
+		require.Equal(t, "crawl-0002.txt.gz", shards[1].File)
+		require.Equal(t, "http://monzo.com/careers-at-monzo", shards[1].FirstURL)
+	})
+
+	t.Run("no pages", func(t *testing.T) {
+		dir := t.TempDir()
+		sink := newShardedSink(filepath.Join(dir, "crawl.ndjson"), false, format, 2, 0)
+		require.NoError(t, sink.Close())
+		require.Equal(t, []shardEntry{}, readIndex(filepath.Join(dir, "crawl-index.json")))
+	})
+}
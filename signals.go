@@ -0,0 +1,43 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// handlePauseSignals pauses the crawler on SIGUSR1 and resumes it on SIGUSR2
+func handlePauseSignals(c crawler.Crawler) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for sig := range sigs {
+			if sig == syscall.SIGUSR1 {
+				slog.Info("pausing crawl, send SIGUSR2 to resume")
+				c.Pause()
+			} else {
+				slog.Info("resuming crawl")
+				c.Resume()
+			}
+		}
+	}()
+}
+
+// handleReloadSignal calls reload on each SIGHUP
+func handleReloadSignal(reload func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+
+	go func() {
+		for range sigs {
+			reload()
+		}
+	}()
+}
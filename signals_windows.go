@@ -0,0 +1,9 @@
+package main
+
+import "github.com/eggsbenjamin/web_crawler/crawler"
+
+// handlePauseSignals is a no-op as windows has no SIGUSR1 or SIGUSR2
+func handlePauseSignals(c crawler.Crawler) {}
+
+// handleReloadSignal is a no-op as windows has no SIGHUP
+func handleReloadSignal(reload func()) {}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newOTLPTracer returns a tracer exporting spans over OTLP/HTTP, configured by the standard OTEL_EXPORTER_OTLP_*
+// env vars, and a function to flush any spans still buffered once the crawl completes
+func newOTLPTracer() (trace.Tracer, func()) {
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		fatal("error creating OTLP trace exporter", "error", err.Error())
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName("web_crawler"))),
+	)
+	shutdown := func() {
+		if err := provider.Shutdown(context.Background()); err != nil {
+			slog.Error("error flushing traces", "error", err.Error())
+		}
+	}
+
+	return provider.Tracer("github.com/eggsbenjamin/web_crawler"), shutdown
+}
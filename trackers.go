@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// trackerSignatures returns crawler.DefaultTrackers extended with the signatures given with -tracker, each a name and a
+// regular expression separated by "=", e.g. "Acme Analytics=^cdn\.acme\.com/track\.js"
+func trackerSignatures(specs []string) ([]crawler.TrackerSignature, error) {
+	signatures := append([]crawler.TrackerSignature{}, crawler.DefaultTrackers...)
+	for _, spec := range specs {
+		name, expr, ok := strings.Cut(spec, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q is malformed, expected 'name=regexp'", spec)
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, crawler.TrackerSignature{Name: name, Pattern: pattern})
+	}
+	return signatures, nil
+}
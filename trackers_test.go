@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerSignatures(t *testing.T) {
+	signatures, err := trackerSignatures([]string{`Acme Analytics=^cdn\.acme\.com/track`})
+	require.NoError(t, err)
+	require.Len(t, signatures, len(crawler.DefaultTrackers)+1)
+	require.Equal(t, "Acme Analytics", signatures[len(signatures)-1].Name)
+	require.True(t, signatures[len(signatures)-1].Pattern.MatchString("cdn.acme.com/track.js"))
+
+	_, err = trackerSignatures([]string{"Acme"})
+	require.Error(t, err)
+	_, err = trackerSignatures([]string{"Acme=("})
+	require.Error(t, err)
+}
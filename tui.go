@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+const (
+	dashboardInterval = time.Second
+	dashboardTopHosts = 5
+)
+
+// dashboard renders crawl progress as a terminal UI, redrawn in place on each update
+type dashboard struct {
+	w io.Writer
+}
+
+func newDashboard(w io.Writer) *dashboard {
+	return &dashboard{w: w}
+}
+
+// update redraws the dashboard with a new snapshot of the crawl's progress
+func (d *dashboard) update(p crawler.Progress) {
+	// move the cursor home and clear the screen
+	fmt.Fprint(d.w, "\033[H\033[2J")
+	fmt.Fprintf(d.w, "Elapsed:  %s\n", p.Elapsed.Truncate(time.Second))
+	fmt.Fprintf(d.w, "Crawled:  %d\n", p.Crawled)
+	fmt.Fprintf(d.w, "Queued:   %d\n", p.Queued)
+	fmt.Fprintf(d.w, "Errors:   %d\n", p.Errors)
+	fmt.Fprintf(d.w, "Bytes:    %d\n", p.Bytes)
+	fmt.Fprintf(d.w, "Rate:     %.1f fetched/s, %.1f discovered/s\n", p.CompletionRate, p.DiscoveryRate)
+	fmt.Fprintf(d.w, "ETA:      %s\n", formatETA(p))
+
+	fmt.Fprint(d.w, "\nTop hosts:\n")
+	for _, host := range topHosts(p.Hosts, dashboardTopHosts) {
+		fmt.Fprintf(d.w, "\t%6d  %s\n", p.Hosts[host], host)
+	}
+
+	fmt.Fprint(d.w, "\nRecent errors:\n")
+	for _, err := range p.RecentErrors {
+		fmt.Fprintf(d.w, "\t%s\n", err)
+	}
+}
+
+// formatETA describes the estimated time remaining in the crawl as a range, or a lower bound while the frontier grows
+func formatETA(p crawler.Progress) string {
+	earliest, latest, bounded := p.ETA()
+	switch {
+	case p.CompletionRate == 0:
+		return "unknown"
+	case !bounded:
+		return fmt.Sprintf("at least %s, still discovering URLs faster than crawling them", earliest.Truncate(time.Second))
+	case latest-earliest < time.Second:
+		return earliest.Truncate(time.Second).String()
+	default:
+		return fmt.Sprintf("%s to %s", earliest.Truncate(time.Second), latest.Truncate(time.Second))
+	}
+}
+
+// topHosts returns up to n hosts with the most pages, ordered by count then name
+func topHosts(hosts map[string]int, n int) []string {
+	top := make([]string, 0, len(hosts))
+	for host := range hosts {
+		top = append(top, host)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if hosts[top[i]] != hosts[top[j]] {
+			return hosts[top[i]] > hosts[top[j]]
+		}
+		return top[i] < top[j]
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
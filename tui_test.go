@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	w := &bytes.Buffer{}
+	newDashboard(w).update(crawler.Progress{
+		Elapsed:        90*time.Second + 400*time.Millisecond,
+		Crawled:        12,
+		Queued:         30,
+		Errors:         1,
+		Bytes:          2048,
+		CompletionRate: 2,
+		DiscoveryRate:  1,
+		Hosts:          map[string]int{"a.monzo.com": 1, "b.monzo.com": 3, "monzo.com": 8},
+		RecentErrors:   []error{errors.New("GET https://monzo.com/missing: 404")},
+	})
+	require.Equal(t, "\033[H\033[2J"+`Elapsed:  1m30s
+Crawled:  12
+Queued:   30
+Errors:   1
+Bytes:    2048
+Rate:     2.0 fetched/s, 1.0 discovered/s
+ETA:      15s to 30s
+
+Top hosts:
+	     8  monzo.com
+	     3  b.monzo.com
+	     1  a.monzo.com
+
+Recent errors:
+	GET https://monzo.com/missing: 404
+`, w.String())
+}
+
+func TestFormatETA(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		progress crawler.Progress
+		expected string
+	}{
+		{"nothing fetched yet", crawler.Progress{Queued: 10}, "unknown"},
+		{"frontier shrinking", crawler.Progress{Queued: 30, CompletionRate: 2, DiscoveryRate: 1}, "15s to 30s"},
+		{"frontier growing", crawler.Progress{Queued: 30, CompletionRate: 2, DiscoveryRate: 3}, "at least 15s, still discovering URLs faster than crawling them"},
+		{"nothing discovered", crawler.Progress{Queued: 30, CompletionRate: 2}, "15s"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, formatETA(tc.progress))
+		})
+	}
+}
+
+func TestTopHosts(t *testing.T) {
+	hosts := map[string]int{"c.monzo.com": 2, "b.monzo.com": 2, "a.monzo.com": 1, "monzo.com": 5}
+	require.Equal(t, []string{"monzo.com", "b.monzo.com", "c.monzo.com"}, topHosts(hosts, 3), "ties are ordered by name")
+	require.Equal(t, []string{"monzo.com", "b.monzo.com", "c.monzo.com", "a.monzo.com"}, topHosts(hosts, 10))
+}
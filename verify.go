@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+)
+
+// defaultVerifyWorkers is the number of URLs of a manifest fetched at once unless WORKERS is set
+const defaultVerifyWorkers = 10
+
+// manifestDrift describes how the URLs of a manifest have changed since it was written, each list being sorted by URL
+type manifestDrift struct {
+	Missing        []missingPage
+	StatusChanged  []statusChange
+	ContentChanged []string
+}
+
+// missingPage is a URL of a manifest which couldn't be fetched
+type missingPage struct {
+	URL    string
+	Reason string // the error fetching the URL, e.g. its status code
+}
+
+// statusChange is a URL of a manifest which now responds with a different status code
+type statusChange struct {
+	URL      string
+	Old, New int
+}
+
+// runVerify implements the verify command, fetching each URL of the manifest at the path in args and writing how they
+// differ from it to w. It returns the exit code.
+func runVerify(args []string, w io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: web_crawler verify MANIFEST")
+		return exitConfig
+	}
+
+	manifest, err := readManifestFile(args[0])
+	if err != nil {
+		fatal("error reading manifest", "path", args[0], "error", err.Error())
+	}
+	if len(manifest) == 0 {
+		return exitOK
+	}
+	workers := getEnvInt("WORKERS")
+	if workers == 0 {
+		workers = defaultVerifyWorkers
+	}
+
+	report := &crawler.Report{}
+	c := crawler.New(workers, &http.Client{Timeout: time.Second * 2},
+		crawler.WithReport(report),
+		// only the manifest's URLs are fetched, whatever they link to
+		crawler.WithLinkFilter(func(*crawler.Page, *url.URL) bool { return false }),
+	)
+	urls := make([]string, 0, len(manifest))
+	for u := range manifest {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err = c.CrawlAll(ctx, urls, io.Discard)
+	stop()
+	if err != nil {
+		slog.Error("error fetching manifest URLs", "error", err.Error())
+		return exitCrawlFailed
+	}
+
+	drift := verifyManifest(manifest, report)
+	if _, err := w.Write(drift.Marshal()); err != nil {
+		return exitCrawlFailed
+	}
+	if len(drift.Missing) > 0 || len(drift.StatusChanged) > 0 || len(drift.ContentChanged) > 0 {
+		return exitHTTPErrors
+	}
+	return exitOK
+}
+
+func readManifestFile(path string) (map[string]manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	manifest := map[string]manifestEntry{}
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// verifyManifest compares a manifest to a report of fetching its URLs again
+func verifyManifest(manifest map[string]manifestEntry, r *crawler.Report) *manifestDrift {
+	pages := map[string]crawler.PageRecord{}
+	for _, page := range r.Pages {
+		pages[page.URL] = page
+	}
+	errs := map[string]crawler.ErrorRecord{}
+	for _, record := range r.Errors {
+		errs[record.URL] = record
+	}
+
+	urls := make([]string, 0, len(manifest))
+	for u := range manifest {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	drift := &manifestDrift{}
+	for _, u := range urls {
+		entry := manifest[u]
+		page, ok := pages[u]
+		switch {
+		case !ok:
+			reason := "not fetched"
+			if record, ok := errs[u]; ok {
+				reason = record.Error
+			}
+			drift.Missing = append(drift.Missing, missingPage{URL: u, Reason: reason})
+		case page.StatusCode != entry.Status:
+			drift.StatusChanged = append(drift.StatusChanged, statusChange{URL: u, Old: entry.Status, New: page.StatusCode})
+		case page.ContentHash != entry.SHA256:
+			drift.ContentChanged = append(drift.ContentChanged, u)
+		}
+	}
+	return drift
+}
+
+// Marshal formats the drift in the same style as the crawl's output, omitting empty sections
+func (d *manifestDrift) Marshal() []byte {
+	out := []byte{}
+	if len(d.Missing) > 0 {
+		out = append(out, []byte("Missing:\n")...)
+		for _, page := range d.Missing {
+			out = append(out, []byte("\t"+page.URL+" "+page.Reason+"\n")...)
+		}
+	}
+	if len(d.StatusChanged) > 0 {
+		out = append(out, []byte("StatusChanged:\n")...)
+		for _, change := range d.StatusChanged {
+			out = append(out, []byte(fmt.Sprintf("\t%s %d -> %d\n", change.URL, change.Old, change.New))...)
+		}
+	}
+	if len(d.ContentChanged) > 0 {
+		out = append(out, []byte("ContentChanged:\n")...)
+		for _, u := range d.ContentChanged {
+			out = append(out, []byte("\t"+u+"\n")...)
+		}
+	}
+	return out
+}
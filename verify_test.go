@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eggsbenjamin/web_crawler/crawler"
+	"github.com/eggsbenjamin/web_crawler/crawler/crawltest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyManifest(t *testing.T) {
+	manifest := map[string]manifestEntry{
+		"http://monzo.com/":        {Status: 200, SHA256: "a"},
+		"http://monzo.com/about":   {Status: 200, SHA256: "b"},
+		"http://monzo.com/blog":    {Status: 200, SHA256: "c"},
+		"http://monzo.com/old":     {Status: 200, SHA256: "d"},
+		"http://monzo.com/careers": {Status: 200, SHA256: "e"},
+	}
+	report := &crawler.Report{
+		Pages: []crawler.PageRecord{
+			{URL: "http://monzo.com/", StatusCode: 200, ContentHash: "a"},
+			{URL: "http://monzo.com/about", StatusCode: 200, ContentHash: "changed"},
+			{URL: "http://monzo.com/old", StatusCode: 301, ContentHash: ""},
+		},
+		Errors: []crawler.ErrorRecord{
+			{URL: "http://monzo.com/blog", StatusCode: 404, Error: "http://monzo.com/blog returned status code: 404"},
+		},
+	}
+
+	drift := verifyManifest(manifest, report)
+	require.Equal(t, `Missing:
+	http://monzo.com/blog http://monzo.com/blog returned status code: 404
+	http://monzo.com/careers not fetched
+StatusChanged:
+	http://monzo.com/old 200 -> 301
+ContentChanged:
+	http://monzo.com/about
+`, string(drift.Marshal()))
+}
+
+func TestRunVerify(t *testing.T) {
+	home := `<a href="/unlisted">home</a>`
+	srv := crawltest.NewServer(crawltest.Site{
+		"/":      {Body: home},
+		"/about": {Body: "about us"},
+	})
+	defer srv.Close()
+
+	hash := func(body string) string {
+		sum := sha256.Sum256([]byte(body))
+		return hex.EncodeToString(sum[:])
+	}
+	writeManifest := func(t *testing.T, manifest map[string]manifestEntry) string {
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		b, err := json.Marshal(manifest)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, b, 0o644))
+		return path
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		path := writeManifest(t, map[string]manifestEntry{
+			srv.URLFor("/"):      {Status: 200, SHA256: hash(home)},
+			srv.URLFor("/about"): {Status: 200, SHA256: hash("about us")},
+		})
+		out := &bytes.Buffer{}
+		require.Equal(t, exitOK, runVerify([]string{path}, out))
+		require.Empty(t, out.String())
+		require.Zero(t, srv.Requests("/unlisted"), "links shouldn't be followed")
+	})
+
+	t.Run("drifted", func(t *testing.T) {
+		path := writeManifest(t, map[string]manifestEntry{
+			srv.URLFor("/"):        {Status: 200, SHA256: hash(home)},
+			srv.URLFor("/about"):   {Status: 200, SHA256: hash("about")},
+			srv.URLFor("/removed"): {Status: 200, SHA256: hash("gone")},
+		})
+		out := &bytes.Buffer{}
+		require.Equal(t, exitHTTPErrors, runVerify([]string{path}, out))
+		require.Contains(t, out.String(), "Missing:\n\t"+srv.URLFor("/removed")+" ")
+		require.Contains(t, out.String(), "ContentChanged:\n\t"+srv.URLFor("/about")+"\n")
+	})
+
+	t.Run("crawl failed", func(t *testing.T) {
+		path := writeManifest(t, map[string]manifestEntry{
+			"http://[::1": {Status: 200},
+		})
+		require.Equal(t, exitCrawlFailed, runVerify([]string{path}, &bytes.Buffer{}))
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+)
+
+// webDashboardRefresh is how often, in seconds, the dashboard reloads itself
+const webDashboardRefresh = 5
+
+// webDashboard is the data the daemon's dashboard template is executed with
+type webDashboard struct {
+	Status  daemonStatus
+	Jobs    []*job // most recently submitted first
+	Refresh int
+}
+
+// serveDashboard serves a page listing the jobs with their progress, errors by class and links to download their
+// output and log, reloading itself every few seconds, for those who'd rather not use the API
+func (d *daemon) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	data := webDashboard{Status: d.status(), Jobs: d.list(""), Refresh: webDashboardRefresh}
+
+	// rendered while holding the lock, so that jobs aren't changed while written
+	b := &bytes.Buffer{}
+	d.mu.Lock()
+	err := webDashboardTemplate.Execute(b, data)
+	d.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+var webDashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>Crawl jobs</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #eee; }
+td.number { text-align: right; }
+ul { margin: 0; padding-left: 1.2em; }
+.failed, .cancelled { color: #b00; }
+.running { color: #070; }
+.paused { color: #a60; }
+</style>
+</head>
+<body>
+<h1>Crawl jobs</h1>
+
+{{with .Status}}<p>{{if .Ready}}Ready{{else}}Not ready{{end}}: {{.Running}} running, {{.Paused}} paused and {{.Queued}} queued
+of {{.Jobs}} jobs, running up to {{.Concurrency}} at once.</p>{{end}}
+
+{{if .Jobs}}<table>
+<thead><tr><th>Job</th><th>Seeds</th><th>State</th><th>Submitted</th><th>Crawled</th><th>Queued</th><th>Errors</th><th>Pages/s</th><th>ETA (s)</th><th>Errors by class</th><th>Download</th></tr></thead>
+<tbody>
+{{range .Jobs}}<tr>
+<td><a href="/jobs/{{.ID}}"><code>{{.ID}}</code></a>{{with .Schedule}}<br>{{.}}{{end}}</td>
+<td>{{range $i, $seed := .Seeds}}{{if $i}}<br>{{end}}{{$seed}}{{end}}</td>
+<td class="{{.State}}">{{.State}}{{with .Error}}<br>{{.}}{{end}}</td>
+<td>{{.Submitted.Format "2006-01-02 15:04:05"}}</td>
+{{with .Progress}}<td class="number">{{.Crawled}}</td><td class="number">{{.Queued}}</td><td class="number">{{.Errors}}</td><td class="number">{{printf "%.1f" .CompletionRate}}</td><td class="number">{{with .ETA}}{{printf "%.0f" .}}{{end}}</td>
+<td>{{with .ErrorClasses}}<ul>{{range $class, $count := .}}<li>{{$class}}: {{$count}}</li>{{end}}</ul>{{end}}</td>
+{{else}}<td></td><td></td><td></td><td></td><td></td><td></td>
+{{end}}<td><a href="/jobs/{{.ID}}/output">output</a> <a href="/jobs/{{.ID}}/log">log</a> <a href="/jobs/{{.ID}}">JSON</a></td>
+</tr>
+{{end}}</tbody>
+</table>{{else}}<p>No jobs have been submitted.</p>{{end}}
+</body>
+</html>
+`))